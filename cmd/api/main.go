@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
 	"github.com/wafi11/backend-workspaces/pkg/config"
+	"github.com/wafi11/backend-workspaces/pkg/k8sclient"
 	"github.com/wafi11/backend-workspaces/pkg/middlewares"
 	"github.com/wafi11/backend-workspaces/pkg/server"
 )
@@ -28,6 +35,14 @@ func main() {
 
 	log.Println("Database connected successfully!")
 
+	// K8s client optional: tanpa cluster, API tetap jalan tanpa fitur deployments
+	var k8sClient *k8s.K8sClient
+	if _, err := k8sclient.InitK8sClient(); err != nil {
+		log.Printf("⚠️  Failed to init k8s client: %v", err)
+	} else if k8sClient, err = k8s.NewK8sClient(); err != nil {
+		log.Printf("⚠️  Failed to create k8s client: %v", err)
+	}
+
 	app := fiber.New()
 
 	app.Use(cors.New(cors.Config{
@@ -93,11 +108,31 @@ func main() {
 		})
 	})
 
+	// Background job workers (deploy, delete, dll)
+	pool := jobs.NewWorkerPool(jobs.NewRepository(db), cfg.Jobs)
+
 	// Routes
 	api := app.Group("/api/v1")
-	server.NewRoutes(db, *cfg, api)
+	server.NewRoutes(db, *cfg, k8sClient, pool, api)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	pool.Start(ctx)
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down server...")
+		if err := app.Shutdown(); err != nil {
+			log.Printf("failed to shutdown server: %v", err)
+		}
+	}()
 
 	port := fmt.Sprintf(":%s", cfg.Server.Port)
 	log.Printf("🚀 Server starting on port %s", port)
-	log.Fatal(app.Listen(port))
+	if err := app.Listen(port); err != nil {
+		log.Fatal(err)
+	}
+
+	// Tunggu job yang sedang jalan selesai
+	pool.Wait()
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 
+	"github.com/wafi11/backend-workspaces/modules/deployments"
 	"github.com/wafi11/backend-workspaces/modules/k8s"
 	"github.com/wafi11/backend-workspaces/pkg/k8sclient"
 	corev1 "k8s.io/api/core/v1"
@@ -40,17 +42,31 @@ func main() {
 	// ============================================
 	namespace := "user123-mystore"
 	appName := "mystore"
-	subdomain := "mystore.yourplatform.com" // Ganti dengan domain kamu
-	dockerImage := "nginx:latest"           // Ganti dengan image kamu nanti
+	// Image hasil build dari git repo template (POST /deployments/:id/builds), e.g. registry/user1-app@sha256:...
+	dockerImage := os.Getenv("IMAGE")
+	if dockerImage == "" {
+		dockerImage = "nginx:latest"
+	}
+
+	// Subdomain dari slug workspace & username, sama seperti allocator di API
+	baseDomain := os.Getenv("BASE_DOMAIN")
+	if baseDomain == "" {
+		baseDomain = "yourplatform.com" // Ganti dengan domain kamu
+	}
+	subdomain := fmt.Sprintf("%s.%s", deployments.SubdomainSlug(appName, "user123"), baseDomain)
 
 	log.Printf("📦 Deploying app: %s to namespace: %s\n", appName, namespace)
 
+	// Semua resource dibuat lewat transaction, kalau ada step yang gagal
+	// resource yang sudah dibuat akan dihapus lagi (urutan terbalik)
+	tx := k8sClient.BeginTransaction()
+
 	// ============================================
 	// 3. Create Namespace
 	// ============================================
 	log.Println("\n📁 Step 1/6: Creating namespace...")
 
-	err = k8sClient.CreateNamespace(ctx, namespace, map[string]string{
+	err = tx.CreateNamespace(ctx, namespace, map[string]string{
 		"app":        appName,
 		"user":       "user123",
 		"managed-by": "your-platform",
@@ -77,14 +93,14 @@ func main() {
 		"SMTP_PORT": "587",
 	}
 
-	err = k8sClient.CreateConfigMap(
+	err = tx.CreateConfigMap(
 		ctx,
 		namespace,
 		fmt.Sprintf("%s-config", appName),
 		configData,
 	)
 	if err != nil {
-		log.Fatalf("❌ Failed to create configmap: %v", err)
+		log.Fatalf("❌ Failed to create configmap: %v", tx.Rollback(ctx, err))
 	}
 
 	log.Println("✅ ConfigMap created successfully")
@@ -102,14 +118,14 @@ func main() {
 		"SMTP_PASSWORD":         "emailPassword456",
 	}
 
-	err = k8sClient.CreateSecretFromStringData(
+	err = tx.CreateSecretFromStringData(
 		ctx,
 		namespace,
 		fmt.Sprintf("%s-secrets", appName),
 		secretData,
 	)
 	if err != nil {
-		log.Fatalf("❌ Failed to create secret: %v", err)
+		log.Fatalf("❌ Failed to create secret: %v", tx.Rollback(ctx, err))
 	}
 
 	log.Println("✅ Secret created successfully")
@@ -146,23 +162,28 @@ func main() {
 		},
 	}
 
-	err = k8sClient.CreateDeployment(ctx, deploymentConfig)
+	err = tx.CreateDeployment(ctx, deploymentConfig)
 	if err != nil {
-		log.Fatalf("❌ Failed to create deployment: %v", err)
+		log.Fatalf("❌ Failed to create deployment: %v", tx.Rollback(ctx, err))
 	}
 
 	log.Println("✅ Deployment created successfully")
 
-	// Wait a bit for pods to start
+	// Wait sampai semua replica ready
 	log.Println("⏳ Waiting for deployment to be ready...")
-	// Kamu bisa implement wait logic disini atau pakai kubectl wait
+	err = k8sClient.WaitForDeploymentReady(ctx, namespace, deploymentConfig.Name, 120, func(progress k8s.DeploymentProgress) {
+		log.Printf("   %s\n", progress.Message())
+	})
+	if err != nil {
+		log.Fatalf("❌ Deployment not ready: %v", tx.Rollback(ctx, err))
+	}
 
 	// ============================================
 	// 7. Create Service
 	// ============================================
 	log.Println("\n🔌 Step 5/6: Creating Service...")
 
-	err = k8sClient.CreateService(
+	err = tx.CreateService(
 		ctx,
 		namespace,
 		fmt.Sprintf("%s-service", appName), // service name
@@ -171,7 +192,7 @@ func main() {
 		80,                                 // target port (nginx port)
 	)
 	if err != nil {
-		log.Fatalf("❌ Failed to create service: %v", err)
+		log.Fatalf("❌ Failed to create service: %v", tx.Rollback(ctx, err))
 	}
 
 	log.Println("✅ Service created successfully")
@@ -181,20 +202,27 @@ func main() {
 	// ============================================
 	log.Println("\n🌐 Step 6/6: Creating Ingress...")
 
-	err = k8sClient.CreateIngress(
-		ctx,
-		namespace,
-		fmt.Sprintf("%s-ingress", appName), // ingress name
-		subdomain,                          // host (mystore.yourplatform.com)
-		fmt.Sprintf("%s-service", appName), // service name
-		80,                                 // service port
-	)
+	err = tx.CreateIngress(ctx, &k8s.IngressConfig{
+		Name:          fmt.Sprintf("%s-ingress", appName), // ingress name
+		Namespace:     namespace,
+		Host:          subdomain,                          // host (mystore-user123.yourplatform.com)
+		ServiceName:   fmt.Sprintf("%s-service", appName), // service name
+		ServicePort:   80,                                 // service port
+		ClassName:     "nginx",
+		TLS:           true,
+		ClusterIssuer: "letsencrypt-prod",
+		Annotations: map[string]string{
+			"nginx.ingress.kubernetes.io/rewrite-target": "/",
+		},
+	})
 	if err != nil {
-		log.Fatalf("❌ Failed to create ingress: %v", err)
+		log.Fatalf("❌ Failed to create ingress: %v", tx.Rollback(ctx, err))
 	}
 
 	log.Println("✅ Ingress created successfully")
 
+	tx.Commit()
+
 	// ============================================
 	// 9. Get Deployment Status
 	// ============================================
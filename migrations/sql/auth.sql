@@ -17,3 +17,7 @@ CREATE UNIQUE INDEX idx_users_email on users(email)  where is_deleted = false;
 CREATE UNIQUE INDEX idx_users_phone_number on users(phone_number)  where is_deleted = false;
 
 
+ALTER TABLE users ADD COLUMN plan varchar(50) NOT NULL DEFAULT 'free';
+
+-- admin: boleh membuat / mengubah template
+ALTER TABLE users ADD COLUMN role varchar(20) NOT NULL DEFAULT 'user';
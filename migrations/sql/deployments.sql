@@ -0,0 +1,181 @@
+create table deployments (
+    id serial primary key,
+    user_id int not null references users(id),
+    template_id int not null references templates(id),
+    name varchar(63) not null,
+    namespace varchar(63) not null,
+    app_name varchar(63) not null,
+    image text not null,
+    replicas int not null default 1,
+    container_port int not null,
+    host varchar(253) not null,
+    subdomain varchar(63),
+    cpu_request varchar(20),
+    cpu_limit varchar(20),
+    memory_request varchar(20),
+    memory_limit varchar(20),
+    env_vars jsonb not null default '{}',
+    autoscaling jsonb,
+    probes jsonb,
+    volumes jsonb not null default '[]',
+    database_type varchar(30),
+    ingress_annotations jsonb not null default '{}',
+    sidecars jsonb not null default '[]',
+    init_containers jsonb not null default '[]',
+    parent_id int references deployments(id),
+    preview_branch varchar(255),
+    preview_pull_request int,
+    expires_at TIMESTAMP,
+    config_map_name varchar(253) not null,
+    secret_name varchar(253) not null,
+    deployment_name varchar(253) not null,
+    service_name varchar(253) not null,
+    ingress_name varchar(253) not null,
+    status varchar(30) not null default 'pending',
+    status_message text,
+    current_revision int not null default 1,
+    idle_timeout_minutes int not null default 0,
+    sleeping boolean not null default false,
+    last_activity_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_deployments_user_name on deployments(user_id, name) where deleted_at is null;
+CREATE INDEX idx_deployments_user_id on deployments(user_id);
+CREATE UNIQUE INDEX idx_deployments_host on deployments(host) where deleted_at is null;
+CREATE UNIQUE INDEX idx_deployments_subdomain on deployments(subdomain) where deleted_at is null and subdomain is not null;
+-- Satu preview environment per branch workspace
+CREATE UNIQUE INDEX idx_deployments_preview_branch on deployments(parent_id, preview_branch) where deleted_at is null and parent_id is not null;
+
+create table deployment_status_history (
+    id serial primary key,
+    deployment_id int not null references deployments(id),
+    from_status varchar(30),
+    to_status varchar(30) not null,
+    actor varchar(100) not null,
+    reason text,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_deployment_status_history_deployment_id on deployment_status_history(deployment_id, created_at);
+
+create table deployment_revisions (
+    id serial primary key,
+    deployment_id int not null references deployments(id),
+    revision int not null,
+    spec jsonb not null,
+    change_cause text,
+    rollback_of int,
+    created_by varchar(100) not null,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_deployment_revisions_revision on deployment_revisions(deployment_id, revision);
+
+create table deployment_addons (
+    id serial primary key,
+    deployment_id int not null references deployments(id),
+    type varchar(30) not null,
+    persistent boolean not null default false,
+    status varchar(30) not null default 'provisioning',
+    status_message text,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_deployment_addons_type on deployment_addons(deployment_id, type);
+
+create table namespace_egress_rules (
+    id serial primary key,
+    user_id int not null references users(id),
+    cidr varchar(50) not null,
+    port int,
+    protocol varchar(10) not null default 'TCP',
+    description text,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_namespace_egress_rules_destination on namespace_egress_rules(user_id, cidr, COALESCE(port, 0), protocol);
+
+create table deployment_domains (
+    id serial primary key,
+    deployment_id int not null references deployments(id),
+    domain varchar(253) not null,
+    verification_token varchar(64) not null,
+    status varchar(30) not null default 'pending',
+    status_message text,
+    verified_at TIMESTAMP,
+    last_checked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_deployment_domains_domain on deployment_domains(deployment_id, domain);
+-- Satu domain hanya bisa diverifikasi oleh satu workspace
+CREATE UNIQUE INDEX idx_deployment_domains_verified on deployment_domains(domain) where status = 'verified';
+
+create table deployment_builds (
+    id serial primary key,
+    deployment_id int not null references deployments(id),
+    status varchar(30) not null default 'queued',
+    status_message text,
+    trigger varchar(30) not null default 'manual',
+    git_repo_url text not null,
+    git_branch varchar(255) not null,
+    git_commit varchar(64),
+    dockerfile_path text not null,
+    image text not null,
+    image_digest varchar(100),
+    job_name varchar(63),
+    deploy boolean not null default true,
+    deployed_revision int,
+    created_by varchar(100) not null,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_deployment_builds_deployment_id on deployment_builds(deployment_id, id);
+
+-- Log build (gzip), disimpan setelah Job selesai untuk replay
+create table deployment_build_logs (
+    build_id int primary key references deployment_builds(id),
+    content bytea not null,
+    size_bytes int not null,
+    truncated boolean not null default false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Secret per workspace untuk verifikasi signature webhook git
+create table deployment_webhooks (
+    deployment_id int primary key references deployments(id),
+    secret varchar(64) not null,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+create table deployment_webhook_deliveries (
+    id serial primary key,
+    deployment_id int not null references deployments(id),
+    provider varchar(20) not null,
+    event varchar(50),
+    delivery_id varchar(100),
+    status varchar(20) not null,
+    status_message text,
+    ref varchar(255),
+    commit_sha varchar(64),
+    build_id int references deployment_builds(id),
+    redelivery_of int references deployment_webhook_deliveries(id),
+    payload text,
+    payload_sha256 varchar(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_deployment_webhook_deliveries_deployment_id on deployment_webhook_deliveries(deployment_id, id);
+-- Replay protection: delivery id dari provider hanya diterima sekali (redeliver manual tidak dihitung)
+CREATE UNIQUE INDEX idx_deployment_webhook_deliveries_delivery_id on deployment_webhook_deliveries(deployment_id, provider, delivery_id) WHERE redelivery_of IS NULL;
+-- Header delivery id tidak ikut di-sign, body yang sama dengan delivery id baru juga ditolak
+CREATE UNIQUE INDEX idx_deployment_webhook_deliveries_payload on deployment_webhook_deliveries(deployment_id, payload_sha256) WHERE redelivery_of IS NULL;
@@ -0,0 +1,21 @@
+create table jobs (
+    id serial primary key,
+    type varchar(100) not null,
+    user_id int references users(id),
+    deployment_id int references deployments(id),
+    payload jsonb not null default '{}',
+    status varchar(20) not null default 'queued',
+    progress text,
+    attempts int not null default 0,
+    max_attempts int not null default 3,
+    last_error text,
+    run_at TIMESTAMP not null DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_jobs_claim on jobs(run_at, id) where status in ('queued', 'running');
+CREATE INDEX idx_jobs_deployment_id on jobs(deployment_id);
@@ -0,0 +1,7 @@
+-- Kolom tambahan untuk tabel templates
+alter table templates add column if not exists default_autoscaling jsonb;
+alter table templates add column if not exists probes jsonb;
+alter table templates add column if not exists volumes jsonb not null default '[]';
+alter table templates add column if not exists ingress_annotations jsonb not null default '{}';
+alter table templates add column if not exists sidecars jsonb not null default '[]';
+alter table templates add column if not exists init_containers jsonb not null default '[]';
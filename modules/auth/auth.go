@@ -5,4 +5,10 @@ import "context"
 type UserRepository interface {
 	Create(c context.Context, req RegisterUser) error
 	Login(c context.Context, req LoginUser) (*LoginResponse, error)
+	GetRole(c context.Context, userId int) (string, error)
 }
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	"github.com/wafi11/backend-workspaces/pkg/response"
+)
+
+const userIdKey = "userId"
+
+// Protected - Validate access token dari header Authorization (Bearer) atau cookie
+func Protected(cfg config.Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+		if token == "" {
+			token = c.Cookies("access_token")
+		}
+
+		claims, err := ValidateToken(token, cfg)
+		if err != nil {
+			return response.Error(c, http.StatusUnauthorized, string(ErrUnauthorized))
+		}
+
+		c.Locals(userIdKey, claims.UserId)
+		return c.Next()
+	}
+}
+
+// RequireRole - Dipasang setelah Protected. Role dibaca dari DB setiap request supaya
+// perubahan role langsung berlaku tanpa menunggu token expired.
+func RequireRole(repo UserRepository, role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userId, ok := GetUserId(c)
+		if !ok {
+			return response.Error(c, http.StatusUnauthorized, string(ErrUnauthorized))
+		}
+
+		current, err := repo.GetRole(c.Context(), userId)
+		if err != nil {
+			if strings.Contains(err.Error(), string(ErrUserNotFound)) {
+				return response.Error(c, http.StatusUnauthorized, string(ErrUnauthorized))
+			}
+			return response.Error(c, http.StatusInternalServerError, string(ErrInternalServer))
+		}
+		if current != role {
+			return response.Error(c, http.StatusForbidden, string(ErrForbidden))
+		}
+
+		return c.Next()
+	}
+}
+
+// GetUserId - Get user id yang di-set oleh Protected middleware
+func GetUserId(c *fiber.Ctx) (int, bool) {
+	userId, ok := c.Locals(userIdKey).(int)
+	return userId, ok
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -85,3 +86,19 @@ func (repo *Repository) Login(c context.Context, req LoginUser) (*LoginResponse,
 		Token: accessToken,
 	}, nil
 }
+
+func (repo *Repository) GetRole(c context.Context, userId int) (string, error) {
+	var role string
+	query := `
+		select role from users where id = $1 and is_deleted = false
+	`
+	err := repo.DB.QueryRowContext(c, query, userId).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%s", ErrUserNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return role, nil
+}
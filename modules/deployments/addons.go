@@ -0,0 +1,497 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	AddonRedis    = "redis"
+	AddonRabbitMQ = "rabbitmq"
+)
+
+const (
+	AddonStatusProvisioning = "provisioning"
+	AddonStatusReady        = "ready"
+	AddonStatusFailed       = "failed"
+	AddonStatusDetaching    = "detaching"
+)
+
+const (
+	JobAttachAddon = "deployment.addon.attach"
+	JobDetachAddon = "deployment.addon.detach"
+)
+
+const (
+	rabbitMQUser        = "app"
+	defaultAddonStorage = "1Gi"
+
+	// addonReadyTimeoutSeconds - Add-on harus ready sebelum app dibuat / di-restart
+	addonReadyTimeoutSeconds = 180
+)
+
+type addonPayload struct {
+	Type string `json:"type"`
+}
+
+// addonSpec - Cara menjalankan satu jenis add-on. Password disimpan di Secret workspace
+// (key passwordKey) dan dibaca container add-on lewat secretKeyRef.
+type addonSpec struct {
+	image       string
+	port        int
+	mountPath   string
+	passwordKey string
+	args        func(addon Addon) []string
+	env         func(data *Deployment) []corev1.EnvVar
+	connection  func(host, password string) map[string]string
+}
+
+var addonSpecs = map[string]addonSpec{
+	AddonRedis: {
+		image:       "redis:7-alpine",
+		port:        6379,
+		mountPath:   "/data",
+		passwordKey: "REDIS_PASSWORD",
+		args: func(addon Addon) []string {
+			args := []string{"--requirepass", "$(REDIS_PASSWORD)"}
+			if addon.Persistent {
+				args = append(args, "--appendonly", "yes")
+			}
+			return args
+		},
+		env: func(data *Deployment) []corev1.EnvVar {
+			return []corev1.EnvVar{secretEnv("REDIS_PASSWORD", data.SecretName, "REDIS_PASSWORD")}
+		},
+		connection: func(host, password string) map[string]string {
+			redisURL := url.URL{
+				Scheme: "redis",
+				User:   url.UserPassword("", password),
+				Host:   fmt.Sprintf("%s:%d", host, 6379),
+			}
+			return map[string]string{
+				"REDIS_URL":      redisURL.String(),
+				"REDIS_HOST":     host,
+				"REDIS_PORT":     "6379",
+				"REDIS_PASSWORD": password,
+			}
+		},
+	},
+	AddonRabbitMQ: {
+		image:       "rabbitmq:3-alpine",
+		port:        5672,
+		mountPath:   "/var/lib/rabbitmq",
+		passwordKey: "RABBITMQ_PASSWORD",
+		args:        func(addon Addon) []string { return nil },
+		env: func(data *Deployment) []corev1.EnvVar {
+			return []corev1.EnvVar{
+				{Name: "RABBITMQ_DEFAULT_USER", Value: rabbitMQUser},
+				secretEnv("RABBITMQ_DEFAULT_PASS", data.SecretName, "RABBITMQ_PASSWORD"),
+				// Nama node tetap supaya data di PVC tetap terbaca setelah pod diganti
+				{Name: "RABBITMQ_NODENAME", Value: "rabbit@localhost"},
+			}
+		},
+		connection: func(host, password string) map[string]string {
+			amqpURL := url.URL{
+				Scheme: "amqp",
+				User:   url.UserPassword(rabbitMQUser, password),
+				Host:   fmt.Sprintf("%s:%d", host, 5672),
+				Path:   "/",
+			}
+			return map[string]string{
+				"RABBITMQ_URL":      amqpURL.String(),
+				"AMQP_URL":          amqpURL.String(),
+				"RABBITMQ_HOST":     host,
+				"RABBITMQ_PORT":     "5672",
+				"RABBITMQ_USER":     rabbitMQUser,
+				"RABBITMQ_PASSWORD": password,
+			}
+		},
+	},
+}
+
+// templateAddons - Add-on yang diminta template, persistence mengikuti config
+func templateAddons(tmpl *templates.Template, cfg config.Config) []Addon {
+	addons := []Addon{}
+	if tmpl.RequiresRedis {
+		addons = append(addons, Addon{Type: AddonRedis, Persistent: cfg.Addons.Redis.Persistent, Status: AddonStatusProvisioning})
+	}
+	if tmpl.RequiresRabbitMQ {
+		addons = append(addons, Addon{Type: AddonRabbitMQ, Persistent: cfg.Addons.RabbitMQ.Persistent, Status: AddonStatusProvisioning})
+	}
+	return addons
+}
+
+// ListAddons - Add-on yang terpasang di deployment
+func (s Service) ListAddons(c context.Context, id, userId int) ([]Addon, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListAddons(c, id)
+}
+
+// AttachAddon - Simpan add-on (status provisioning) lalu enqueue job attach
+func (s Service) AttachAddon(c context.Context, id, userId int, req AttachAddonRequest) (*AddonJob, error) {
+	req.Type = strings.ToLower(req.Type)
+	if _, ok := addonSpecs[req.Type]; !ok {
+		return nil, fmt.Errorf("%s: %s", ErrUnsupportedAddon, req.Type)
+	}
+
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAddonChange(data); err != nil {
+		return nil, err
+	}
+
+	persistent := s.addonConfig(req.Type).Persistent
+	if req.Persistent != nil {
+		persistent = *req.Persistent
+	}
+	attach := Addon{
+		DeploymentId: data.Id,
+		Type:         req.Type,
+		Persistent:   persistent,
+		Status:       AddonStatusProvisioning,
+	}
+
+	unlock, err := s.lockQuota(c, data.UserId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	addons, err := s.repo.ListAddons(c, data.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(c, data, append(addons, attach)); err != nil {
+		return nil, err
+	}
+
+	addon, err := s.repo.CreateAddon(c, attach)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobAttachAddon, data, addonPayload{Type: addon.Type})
+	if err != nil {
+		if deleteErr := s.repo.DeleteAddon(c, addon.Id); deleteErr != nil {
+			log.Printf("failed to delete addon %d: %s", addon.Id, deleteErr.Error())
+		}
+		return nil, err
+	}
+
+	return &AddonJob{Addon: addon, Job: job}, nil
+}
+
+// DetachAddon - Tandai add-on detaching lalu enqueue job detach. Data add-on ikut dihapus.
+func (s Service) DetachAddon(c context.Context, id, userId int, addonType string) (*AddonJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAddonChange(data); err != nil {
+		return nil, err
+	}
+
+	addon, err := s.repo.GetAddon(c, data.Id, strings.ToLower(addonType))
+	if err != nil {
+		return nil, err
+	}
+	if addon.Status == AddonStatusProvisioning || addon.Status == AddonStatusDetaching {
+		return nil, fmt.Errorf("%s: %s is %s", ErrAddonBusy, addon.Type, addon.Status)
+	}
+
+	if err := s.repo.SetAddonStatus(c, addon.Id, AddonStatusDetaching, ""); err != nil {
+		return nil, err
+	}
+	addon.Status = AddonStatusDetaching
+
+	job, err := s.enqueue(c, JobDetachAddon, data, addonPayload{Type: addon.Type})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AddonJob{Addon: addon, Job: job}, nil
+}
+
+// checkAddonChange - Add-on hanya bisa diubah saat resource workspace sudah ada
+func checkAddonChange(data *Deployment) error {
+	switch data.Status {
+	case StatusRunning, StatusDegraded, StatusStopped:
+		return nil
+	default:
+		return fmt.Errorf("%s: cannot change addons while deployment is %s", ErrInvalidTransition, data.Status)
+	}
+}
+
+// handleAttachAddon - Tulis connection string ke Secret workspace → buat add-on → restart app
+func (s Service) handleAttachAddon(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	data, addon, err := s.jobAddon(c, job)
+	if err != nil {
+		return err
+	}
+	if addon.Status == AddonStatusReady {
+		return nil
+	}
+
+	// Password lama dipakai lagi saat retry, data di PVC dibuat dengan password itu
+	connection, err := s.addonConnection(c, data, addon.Type)
+	if err != nil {
+		return err
+	}
+
+	report(fmt.Sprintf("writing %s connection to secret", addon.Type))
+	if err := s.k8s.UpdateSecretKeys(c, data.Namespace, data.SecretName, connection, nil); err != nil {
+		return s.failAddon(c, job, addon, err)
+	}
+
+	report(fmt.Sprintf("creating %s", addon.Type))
+	tx := s.k8s.BeginTransaction()
+	if err := s.provisionAddon(c, tx, data, *addon); err != nil {
+		err = tx.Rollback(c, err)
+		if job.IsLastAttempt() {
+			s.removeAddonKeys(context.WithoutCancel(c), data, addon.Type)
+		}
+		return s.failAddon(c, job, addon, err)
+	}
+	tx.Commit()
+
+	if err := s.repo.SetAddonStatus(c, addon.Id, AddonStatusReady, ""); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	// Env dari Secret hanya dibaca saat pod start
+	if data.Status != StatusStopped {
+		report("restarting workspace")
+		if err := s.k8s.RestartDeployment(c, data.Namespace, data.DeploymentName); err != nil {
+			log.Printf("failed to restart deployment %d after attaching %s: %s", data.Id, addon.Type, err.Error())
+		}
+	}
+	return nil
+}
+
+// handleDetachAddon - Hapus connection string → restart app → hapus resource add-on
+func (s Service) handleDetachAddon(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	data, addon, err := s.jobAddon(c, job)
+	if err != nil {
+		return err
+	}
+
+	report(fmt.Sprintf("removing %s connection from secret", addon.Type))
+	if err := s.removeAddonKeys(c, data, addon.Type); err != nil {
+		return err
+	}
+	if data.Status != StatusStopped {
+		report("restarting workspace")
+		if err := s.k8s.RestartDeployment(c, data.Namespace, data.DeploymentName); err != nil {
+			log.Printf("failed to restart deployment %d after detaching %s: %s", data.Id, addon.Type, err.Error())
+		}
+	}
+
+	report(fmt.Sprintf("deleting %s", addon.Type))
+	// Detach dilakukan eksplisit oleh user, datanya ikut dihapus
+	s.runTeardown(data, s.teardownAddon(c, data, *addon, true))
+
+	return s.repo.DeleteAddon(c, addon.Id)
+}
+
+func (s Service) jobAddon(c context.Context, job *jobs.Job) (*Deployment, *Addon, error) {
+	var payload addonPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return nil, nil, jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addon, err := s.repo.GetAddon(c, data.Id, payload.Type)
+	if err != nil {
+		return nil, nil, jobs.Permanent(err)
+	}
+
+	return data, addon, nil
+}
+
+// failAddon - Status add-on jadi failed di attempt terakhir, workspace tetap jalan
+func (s Service) failAddon(c context.Context, job *jobs.Job, addon *Addon, cause error) error {
+	if job.IsLastAttempt() {
+		if err := s.repo.SetAddonStatus(context.WithoutCancel(c), addon.Id, AddonStatusFailed, cause.Error()); err != nil {
+			log.Printf("failed to mark addon %d failed: %s", addon.Id, err.Error())
+		}
+	}
+	return cause
+}
+
+// addonConnection - Env untuk app; password yang sudah ada di Secret dipakai lagi
+func (s Service) addonConnection(c context.Context, data *Deployment, addonType string) (map[string]string, error) {
+	spec := addonSpecs[addonType]
+
+	existing, err := s.k8s.GetSecretData(c, data.Namespace, data.SecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	password := existing[spec.passwordKey]
+	if password == "" {
+		if password, err = generatePassword(); err != nil {
+			return nil, err
+		}
+	}
+
+	return spec.connection(addonHost(data, addonType), password), nil
+}
+
+func (s Service) removeAddonKeys(c context.Context, data *Deployment, addonType string) error {
+	keys := []string{}
+	for key := range addonSpecs[addonType].connection("", "") {
+		keys = append(keys, key)
+	}
+
+	return s.k8s.UpdateSecretKeys(c, data.Namespace, data.SecretName, nil, keys)
+}
+
+// provisionAddon - PVC (kalau persistent) → Deployment → Service, lalu tunggu ready.
+// Password harus sudah ada di Secret workspace.
+func (s Service) provisionAddon(c context.Context, tx *k8s.Transaction, data *Deployment, addon Addon) error {
+	spec := addonSpecs[addon.Type]
+	cfg := s.addonConfig(addon.Type)
+	name := addonName(data, addon.Type)
+
+	deployment := &k8s.DeploymentConfig{
+		Name:          name,
+		Namespace:     data.Namespace,
+		AppName:       name,
+		Image:         valueOr(cfg.Image, spec.image),
+		Replicas:      1,
+		ContainerPort: int32(spec.port),
+		Args:          spec.args(addon),
+		CPURequest:    cfg.CPURequest,
+		CPULimit:      cfg.CPULimit,
+		MemoryRequest: cfg.MemoryRequest,
+		MemoryLimit:   cfg.MemoryLimit,
+		EnvVars:       spec.env(data),
+		ReadinessProbe: &k8s.ProbeConfig{
+			Type:          k8s.ProbeTCP,
+			PeriodSeconds: 5,
+		},
+	}
+
+	if addon.Persistent {
+		volume := k8s.VolumeConfig{
+			Name:       "data",
+			ClaimName:  addonClaimName(data, addon.Type),
+			MountPath:  spec.mountPath,
+			Size:       valueOr(cfg.StorageSize, defaultAddonStorage),
+			AccessMode: templates.AccessReadWriteOnce,
+		}
+		if cfg.StorageClass != "" {
+			volume.StorageClass = &cfg.StorageClass
+		}
+
+		exists, err := s.k8s.PersistentVolumeClaimExists(c, data.Namespace, volume.ClaimName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			err := tx.CreatePersistentVolumeClaim(c, data.Namespace, volume, map[string]string{
+				"app":        name,
+				"managed-by": managedBy,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		deployment.Volumes = []k8s.VolumeConfig{volume}
+	}
+
+	if err := tx.CreateDeployment(c, deployment); err != nil {
+		return err
+	}
+
+	if err := tx.CreateService(c, data.Namespace, name, name, spec.port, spec.port); err != nil {
+		return err
+	}
+
+	return s.k8s.WaitForDeploymentReady(c, data.Namespace, name, addonReadyTimeoutSeconds, nil)
+}
+
+// teardownAddon - Resource add-on dihapus. PVC hanya ikut dihapus kalau deleteVolume,
+// sama seperti volume workspace (lihat Delete).
+func (s Service) teardownAddon(c context.Context, data *Deployment, addon Addon, deleteVolume bool) []teardownStep {
+	name := addonName(data, addon.Type)
+	steps := []teardownStep{
+		{addon.Type + " service", func() error { return s.k8s.DeleteService(c, data.Namespace, name) }},
+		{addon.Type + " deployment", func() error { return s.k8s.DeleteDeployment(c, data.Namespace, name) }},
+	}
+	if addon.Persistent && deleteVolume {
+		steps = append(steps, teardownStep{addon.Type + " pvc", func() error {
+			return s.k8s.DeletePersistentVolumeClaim(c, data.Namespace, addonClaimName(data, addon.Type))
+		}})
+	}
+	return steps
+}
+
+// addonHealth - Status add-on di DB digabung dengan replica yang ready di cluster
+func (s Service) addonHealth(c context.Context, data *Deployment) ([]AddonHealth, error) {
+	addons, err := s.repo.ListAddons(c, data.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]AddonHealth, 0, len(addons))
+	for _, addon := range addons {
+		health := AddonHealth{Addon: addon}
+		if status, err := s.k8s.GetDeploymentStatus(c, data.Namespace, addonName(data, addon.Type)); err == nil {
+			health.ReadyReplicas = status.ReadyReplicas
+			health.IsReady = status.IsReady && status.ReadyReplicas > 0
+		}
+		results = append(results, health)
+	}
+
+	return results, nil
+}
+
+func (s Service) addonConfig(addonType string) config.AddonConfig {
+	if addonType == AddonRabbitMQ {
+		return s.cfg.Addons.RabbitMQ
+	}
+	return s.cfg.Addons.Redis
+}
+
+// Nama resource add-on, semuanya diturunkan dari nama deployment
+func addonName(data *Deployment, addonType string) string {
+	return fmt.Sprintf("%s-%s", data.Name, addonType)
+}
+
+func addonClaimName(data *Deployment, addonType string) string {
+	return fmt.Sprintf("%s-%s-data", data.Name, addonType)
+}
+
+func addonHost(data *Deployment, addonType string) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", addonName(data, addonType), data.Namespace)
+}
+
+func secretEnv(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
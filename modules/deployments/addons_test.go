@@ -0,0 +1,46 @@
+package deployments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTeardownAddonVolume(t *testing.T) {
+	tests := []struct {
+		name         string
+		deleteVolume bool
+		wantClaim    bool
+	}{
+		{name: "plain delete keeps the pvc", wantClaim: true},
+		{name: "delete with volumes removes the pvc", deleteVolume: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := &Deployment{Id: 1, UserId: 1, Name: "shop", Namespace: testNamespace}
+			claimName := addonClaimName(data, AddonRedis)
+			clientset := fake.NewClientset(&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: claimName, Namespace: testNamespace},
+			})
+			service := NewService(nil, nil, jobs.Service{}, k8s.NewK8sClientFromClientset(clientset), config.Config{})
+
+			addon := Addon{Id: 1, DeploymentId: 1, Type: AddonRedis, Persistent: true}
+			service.runTeardown(data, service.teardownAddon(context.Background(), data, addon, tt.deleteVolume))
+
+			_, err := clientset.CoreV1().PersistentVolumeClaims(testNamespace).Get(context.Background(), claimName, metav1.GetOptions{})
+			if tt.wantClaim && err != nil {
+				t.Fatalf("expected pvc to be kept, got %v", err)
+			}
+			if !tt.wantClaim && !k8s.IsNotFound(err) {
+				t.Fatalf("expected pvc to be deleted, got %v", err)
+			}
+		})
+	}
+}
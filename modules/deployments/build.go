@@ -0,0 +1,515 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+const (
+	BuildStatusQueued    = "queued"
+	BuildStatusRunning   = "running"
+	BuildStatusSucceeded = "succeeded"
+	BuildStatusFailed    = "failed"
+)
+
+const (
+	BuildTriggerManual = "manual"
+	BuildTriggerCreate = "create"
+)
+
+const JobBuild = "deployment.build"
+
+const (
+	defaultBuildTimeoutSeconds = 1200
+	defaultDockerfilePath      = "Dockerfile"
+	defaultPushImage           = "gcr.io/go-containerregistry/crane:v0.20.2"
+	defaultDNSNamespace        = "kube-system"
+
+	// buildMaxAttempts - Build lebih lama dari lease job, attempt berikutnya lanjut menunggu
+	// Job yang sama. Attempt juga dipakai untuk menunggu workspace selesai provision.
+	buildMaxAttempts = 10
+)
+
+var (
+	gitRefRegex    = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+	gitCommitRegex = regexp.MustCompile(`^[0-9a-f]{7,64}$`) // SHA-1 atau SHA-256
+	digestRegex    = regexp.MustCompile(`sha256:[0-9a-f]{64}`)
+)
+
+type buildPayload struct {
+	BuildId int `json:"buildId"`
+}
+
+// StartBuild - Simpan build (status queued) lalu enqueue job build
+func (s Service) StartBuild(c context.Context, id, userId int, req BuildRequest) (*BuildJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if data.Status == StatusDeleting || data.Status == StatusDeleted {
+		return nil, fmt.Errorf("%s: cannot build while deployment is %s", ErrInvalidTransition, data.Status)
+	}
+
+	tmpl, err := s.templates.FindById(c, data.TemplateId)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := s.lockQuota(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return s.startBuild(c, data, tmpl, req, BuildTriggerManual, userActor(userId))
+}
+
+func (s Service) ListBuilds(c context.Context, id, userId int) ([]Build, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListBuilds(c, id)
+}
+
+func (s Service) GetBuild(c context.Context, id, userId, buildId int) (*Build, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetBuild(c, id, buildId)
+}
+
+// startBuild - Caller harus memegang lockQuota (build dihitung ke quota user) sampai build tersimpan
+func (s Service) startBuild(c context.Context, data *Deployment, tmpl *templates.Template, req BuildRequest, trigger, actor string) (*BuildJob, error) {
+	if !s.cfg.Builds.Enabled {
+		return nil, fmt.Errorf("%s", ErrBuildsDisabled)
+	}
+	if tmpl.GitRepoURL == "" {
+		return nil, fmt.Errorf("%s: %s", ErrNoGitRepo, tmpl.Name)
+	}
+	if _, err := buildContext(tmpl.GitRepoURL, "main", ""); err != nil {
+		return nil, err
+	}
+
+	branch := valueOr(strings.TrimSpace(req.Branch), valueOr(tmpl.GitBranch, "main"))
+	if !gitRefRegex.MatchString(branch) || strings.Contains(branch, "..") {
+		return nil, fmt.Errorf("%s: branch %q", ErrInvalidBuild, branch)
+	}
+	commit := strings.ToLower(strings.TrimSpace(req.Commit))
+	if commit != "" && !gitCommitRegex.MatchString(commit) {
+		return nil, fmt.Errorf("%s: commit %q", ErrInvalidBuild, req.Commit)
+	}
+
+	active, err := s.repo.HasActiveBuild(c, data.Id)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, fmt.Errorf("%s: deployment %s", ErrBuildRunning, data.Name)
+	}
+
+	if err := s.checkBuildQuota(c, data.UserId); err != nil {
+		return nil, err
+	}
+	if err := s.ensurePushSecret(c, data); err != nil {
+		return nil, err
+	}
+
+	deploy := true
+	if req.Deploy != nil {
+		deploy = *req.Deploy
+	}
+	build := Build{
+		DeploymentId:   data.Id,
+		Status:         BuildStatusQueued,
+		Trigger:        trigger,
+		GitRepoURL:     tmpl.GitRepoURL,
+		GitBranch:      branch,
+		DockerfilePath: valueOr(tmpl.DockerfilePath, defaultDockerfilePath),
+		Image:          s.buildRepository(data),
+		Deploy:         deploy,
+		CreatedBy:      actor,
+	}
+	if commit != "" {
+		build.GitCommit = &commit
+	}
+
+	created, err := s.repo.CreateBuild(c, build)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.Enqueue(c, jobs.EnqueueRequest{
+		Type:         JobBuild,
+		UserId:       data.UserId,
+		DeploymentId: data.Id,
+		Payload:      buildPayload{BuildId: created.Id},
+		MaxAttempts:  buildMaxAttempts,
+	})
+	if err != nil {
+		err = fmt.Errorf("%s: %w", ErrEnqueueFailed, err)
+		if finishErr := s.repo.FinishBuild(context.WithoutCancel(c), created.Id, BuildStatusFailed, err.Error(), ""); finishErr != nil {
+			log.Printf("failed to mark build %d failed: %s", created.Id, finishErr.Error())
+		}
+		return nil, err
+	}
+
+	return &BuildJob{Build: created, Job: job}, nil
+}
+
+// handleBuild - Jalankan Job builder → simpan digest → apply image sebagai revision baru.
+// Setiap langkah idempotent: retry melanjutkan dari status build yang tersimpan.
+func (s Service) handleBuild(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	var payload buildPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	build, err := s.repo.GetBuild(c, data.Id, payload.BuildId)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	if build.Status == BuildStatusQueued || build.Status == BuildStatusRunning {
+		if err := s.runBuild(c, job, data, build, report); err != nil {
+			return err
+		}
+	}
+
+	if build.Status != BuildStatusSucceeded || !build.Deploy || build.DeployedRevision != nil {
+		return nil
+	}
+	return s.deployBuild(c, job, data, build, report)
+}
+
+func (s Service) runBuild(c context.Context, job *jobs.Job, data *Deployment, build *Build, report jobs.ProgressFunc) error {
+	namespace := s.cfg.Builds.Namespace
+	jobName := buildJobName(build)
+
+	if err := s.ensureBuildNamespace(c); err != nil {
+		return s.failBuild(c, job, build, err, false)
+	}
+
+	exists, err := s.k8s.JobExists(c, namespace, jobName)
+	if err != nil {
+		return s.failBuild(c, job, build, err, false)
+	}
+	if !exists {
+		// Secret per user dibuat saat build di-start (ensurePushSecret), kalau sudah dihapus build gagal
+		secret := s.buildPushSecret(data)
+		found, err := s.k8s.SecretExists(c, namespace, secret)
+		if err != nil {
+			return s.failBuild(c, job, build, err, false)
+		}
+		if !found {
+			return s.failBuild(c, job, build, fmt.Errorf("%s: secret %s in namespace %s", ErrRegistrySecret, secret, namespace), true)
+		}
+
+		config, err := s.buildJobConfig(data, build)
+		if err != nil {
+			return s.failBuild(c, job, build, err, true)
+		}
+
+		report(fmt.Sprintf("starting build %d from %s@%s", build.Id, build.GitRepoURL, build.GitBranch))
+		if err := s.k8s.CreateBuildJob(c, config); err != nil {
+			return s.failBuild(c, job, build, err, false)
+		}
+	}
+	if err := s.repo.StartBuild(c, build.Id, jobName); err != nil {
+		return err
+	}
+	build.Status = BuildStatusRunning
+
+	report(fmt.Sprintf("waiting for build job %s", jobName))
+	result, err := s.k8s.WaitForJob(c, namespace, jobName, s.buildTimeoutSeconds()+60)
+	if err != nil {
+		if errors.Is(err, k8s.ErrWaitTimeout) && !job.IsLastAttempt() {
+			// Lease job habis sebelum build selesai, attempt berikutnya menunggu Job yang sama
+			return fmt.Errorf("build %d still running", build.Id)
+		}
+		return s.failBuild(c, job, build, err, false)
+	}
+	s.saveBuildLog(c, build, result.PodName)
+
+	if !result.Succeeded {
+		// Pod yang stuck (e.g., gagal pull image builder) masih jalan sampai deadline
+		if err := s.k8s.DeleteJob(context.WithoutCancel(c), namespace, jobName); err != nil {
+			log.Printf("failed to delete build job %s: %s", jobName, err.Error())
+		}
+		return s.failBuild(c, job, build, fmt.Errorf("build job failed: %s", result.Message), true)
+	}
+
+	digest := digestRegex.FindString(result.TerminationMessage)
+	if digest == "" {
+		return s.failBuild(c, job, build, fmt.Errorf("build job did not report an image digest"), true)
+	}
+
+	if err := s.repo.FinishBuild(c, build.Id, BuildStatusSucceeded, "", digest); err != nil {
+		return err
+	}
+	build.Status = BuildStatusSucceeded
+	build.ImageDigest = &digest
+	report(fmt.Sprintf("built %s", buildImageRef(build)))
+
+	return nil
+}
+
+// deployBuild - Apply image (by digest) sebagai revision baru. Workspace yang masih
+// provision / update ditunggu lewat retry job.
+func (s Service) deployBuild(c context.Context, job *jobs.Job, data *Deployment, build *Build, report jobs.ProgressFunc) error {
+	image := buildImageRef(build)
+
+	spec := data.Spec()
+	spec.Image = image
+	changeCause := fmt.Sprintf("build %d (%s@%s)", build.Id, build.GitBranch, shortDigest(*build.ImageDigest))
+
+	deployed, err := s.applyRevision(c, data, spec, build.CreatedBy, changeCause, nil)
+	if err != nil {
+		busy := data.Status == StatusPending || data.Status == StatusProvisioning || data.Status == StatusUpdating || data.Status == StatusPausing
+		if busy && !job.IsLastAttempt() {
+			return fmt.Errorf("deployment is %s, build %d will be rolled out later", data.Status, build.Id)
+		}
+
+		message := fmt.Sprintf("image was not deployed: %s", err.Error())
+		if setErr := s.repo.SetBuildDeployed(c, build.Id, nil, message); setErr != nil {
+			return setErr
+		}
+		report(message)
+		return nil
+	}
+
+	revision := deployed.Deployment.CurrentRevision
+	if err := s.repo.SetBuildDeployed(c, build.Id, &revision, ""); err != nil {
+		return err
+	}
+	report(fmt.Sprintf("rolling out %s as revision %d", image, revision))
+	return nil
+}
+
+// failBuild - Status build jadi failed kalau error permanen atau attempt terakhir
+func (s Service) failBuild(c context.Context, job *jobs.Job, build *Build, cause error, permanent bool) error {
+	if permanent || job.IsLastAttempt() {
+		if err := s.repo.FinishBuild(context.WithoutCancel(c), build.Id, BuildStatusFailed, cause.Error(), ""); err != nil {
+			log.Printf("failed to mark build %d failed: %s", build.Id, err.Error())
+		}
+		build.Status = BuildStatusFailed
+	}
+	if permanent {
+		return jobs.Permanent(cause)
+	}
+	return cause
+}
+
+// buildJobConfig - Kaniko build dari context git:// ke tarball tanpa credential,
+// crane push tarball ke registry dan menulis digest ke termination log
+func (s Service) buildJobConfig(data *Deployment, build *Build) (*k8s.BuildJobConfig, error) {
+	var commit string
+	if build.GitCommit != nil {
+		commit = *build.GitCommit
+	}
+	gitContext, err := buildContext(build.GitRepoURL, build.GitBranch, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	tarball := k8s.BuildOutputPath + "/image.tar"
+	args := []string{
+		"--context=" + gitContext,
+		"--dockerfile=" + build.DockerfilePath,
+		"--destination=" + buildImageTag(build),
+		"--no-push",
+		"--tar-path=" + tarball,
+	}
+	args = append(args, s.cfg.Builds.ExtraArgs...)
+
+	return &k8s.BuildJobConfig{
+		Name:      buildJobName(build),
+		Namespace: s.cfg.Builds.Namespace,
+		Image:     s.cfg.Builds.BuilderImage,
+		Args:      args,
+		PushImage: valueOr(s.cfg.Builds.PushImage, defaultPushImage),
+		PushArgs:  []string{"push", tarball, buildImageTag(build), "--image-refs=/dev/termination-log"},
+		Labels: map[string]string{
+			"app":           "workspace-build",
+			"deployment-id": fmt.Sprintf("%d", data.Id),
+			"user":          fmt.Sprintf("user%d", data.UserId),
+			"managed-by":    managedBy,
+		},
+		DockerConfigSecret:      s.buildPushSecret(data),
+		CPULimit:                s.cfg.Builds.CPULimit,
+		MemoryLimit:             s.cfg.Builds.MemoryLimit,
+		ActiveDeadlineSeconds:   int64(s.buildTimeoutSeconds()),
+		TTLSecondsAfterFinished: int32(s.cfg.Builds.TTLSecondsAfterFinished),
+	}, nil
+}
+
+// ensureBuildNamespace - Namespace build dibuat sekali, secret registry di-manage admin.
+// NetworkPolicy di-apply setiap build supaya ikut perubahan config.
+func (s Service) ensureBuildNamespace(c context.Context) error {
+	namespace := s.cfg.Builds.Namespace
+	exists, err := s.k8s.NamespaceExists(c, namespace)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err := s.k8s.CreateNamespace(c, namespace, map[string]string{
+			"managed-by": managedBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.k8s.ApplyBuildIsolation(c, namespace, valueOr(s.cfg.Network.DNSNamespace, defaultDNSNamespace), s.buildEgressRules())
+}
+
+// buildEgressRules - Internet (git & registry) kecuali network yang diblok untuk user,
+// ditambah CIDR dari config untuk registry / git server di dalam cluster
+func (s Service) buildEgressRules() []k8s.EgressRule {
+	rules := []k8s.EgressRule{
+		{CIDR: "0.0.0.0/0", Except: s.clusterExcept("0.0.0.0/0")},
+		{CIDR: "::/0", Except: s.clusterExcept("::/0")},
+	}
+	for _, cidr := range s.cfg.Builds.EgressCIDRs {
+		rules = append(rules, k8s.EgressRule{CIDR: cidr})
+	}
+	return rules
+}
+
+// buildPushSecret - Credential push milik user, hanya bisa menulis ke repository user itu
+func (s Service) buildPushSecret(data *Deployment) string {
+	return fmt.Sprintf("%s-user%d", s.cfg.Builds.RegistrySecret, data.UserId)
+}
+
+// ensurePushSecret - Secret per user yang dibuat admin dipakai apa adanya, kalau belum ada
+// di-copy dari secret bersama saat build pertama. Tanpa keduanya build ditolak sebelum
+// disimpan, bukan gagal di worker.
+func (s Service) ensurePushSecret(c context.Context, data *Deployment) error {
+	namespace := s.cfg.Builds.Namespace
+	if s.cfg.Builds.RegistrySecret == "" {
+		return fmt.Errorf("%s: registry_secret is empty", ErrRegistrySecret)
+	}
+
+	secret := s.buildPushSecret(data)
+	found, err := s.k8s.SecretExists(c, namespace, secret)
+	if err != nil || found {
+		return err
+	}
+
+	shared, err := s.k8s.SecretExists(c, namespace, s.cfg.Builds.RegistrySecret)
+	if err != nil {
+		return err
+	}
+	if !shared {
+		return fmt.Errorf("%s: neither %s nor %s exists in namespace %s", ErrRegistrySecret, secret, s.cfg.Builds.RegistrySecret, namespace)
+	}
+
+	return s.k8s.CopySecretAs(c, namespace, s.cfg.Builds.RegistrySecret, namespace, secret, map[string]string{
+		"user":       fmt.Sprintf("user%d", data.UserId),
+		"managed-by": managedBy,
+	})
+}
+
+// ensurePullSecret - Copy pull secret dari namespace build ke namespace user, dipakai
+// sebagai imagePullSecrets. Di-copy ulang setiap deploy supaya credential yang di-rotate ikut.
+func (s Service) ensurePullSecret(c context.Context, namespace string) error {
+	if s.cfg.Builds.PullSecret == "" {
+		return nil
+	}
+
+	return s.k8s.CopySecret(c, s.cfg.Builds.Namespace, namespace, s.cfg.Builds.PullSecret, map[string]string{
+		"managed-by": managedBy,
+	})
+}
+
+// teardownBuilds - Hentikan build yang masih berjalan saat workspace dihapus
+func (s Service) teardownBuilds(c context.Context, data *Deployment) error {
+	builds, err := s.repo.ListBuilds(c, data.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, build := range builds {
+		if build.Status != BuildStatusQueued && build.Status != BuildStatusRunning {
+			continue
+		}
+		if build.JobName != nil {
+			if err := s.k8s.DeleteJob(c, s.cfg.Builds.Namespace, *build.JobName); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.FinishBuild(c, build.Id, BuildStatusFailed, "deployment deleted", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildRepository - Repository image per workspace: <registry>/user<id>-<name>
+func (s Service) buildRepository(data *Deployment) string {
+	registry := strings.TrimSuffix(s.cfg.Builds.Registry, "/")
+	return fmt.Sprintf("%s/user%d-%s", registry, data.UserId, data.Name)
+}
+
+func (s Service) buildTimeoutSeconds() int {
+	if s.cfg.Builds.TimeoutSeconds > 0 {
+		return s.cfg.Builds.TimeoutSeconds
+	}
+	return defaultBuildTimeoutSeconds
+}
+
+func buildJobName(build *Build) string {
+	return fmt.Sprintf("build-%d", build.Id)
+}
+
+// buildImageTag - Tag yang di-push builder, Build.Image hanya berisi repository
+func buildImageTag(build *Build) string {
+	return fmt.Sprintf("%s:build-%d", build.Image, build.Id)
+}
+
+// buildImageRef - Image yang di-deploy di-pin ke digest, tag bisa ditimpa
+func buildImageRef(build *Build) string {
+	return build.Image + "@" + *build.ImageDigest
+}
+
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if len(digest) > 12 {
+		return digest[:12]
+	}
+	return digest
+}
+
+// buildContext - URL repo (https/http/git) → context git:// Kaniko dengan ref branch / commit.
+// URL ssh tidak didukung: builder meng-clone tanpa credentials.
+func buildContext(repoURL, branch, commit string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil || parsed.Host == "" || parsed.Path == "" {
+		return "", fmt.Errorf("%s: git repository url %q", ErrInvalidBuild, repoURL)
+	}
+	switch parsed.Scheme {
+	case "https", "http", "git":
+	default:
+		return "", fmt.Errorf("%s: unsupported git repository scheme %q", ErrInvalidBuild, parsed.Scheme)
+	}
+
+	gitContext := fmt.Sprintf("git://%s%s#refs/heads/%s", parsed.Host, parsed.Path, branch)
+	if commit != "" {
+		gitContext += "#" + commit
+	}
+	return gitContext, nil
+}
@@ -0,0 +1,277 @@
+package deployments
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	BuildEventLog    = "log"
+	BuildEventStatus = "status"
+	BuildEventResult = "result"
+	BuildEventError  = "error"
+)
+
+const (
+	// maxBuildLogBytes / maxBuildLogLines - Hanya bagian akhir log yang dibaca dari kubelet
+	// dan disimpan (error build ada di akhir log)
+	maxBuildLogBytes = 5 << 20
+	maxBuildLogLines = 50000
+
+	// buildPodWaitTimeout - Batas menunggu pod build start (queue job + pull image builder)
+	buildPodWaitTimeout = 10 * time.Minute
+
+	// buildResultWaitTimeout - Setelah log selesai, worker butuh satu poll untuk simpan status akhir
+	buildResultWaitTimeout = 2 * time.Minute
+
+	buildLogPollInterval = 2 * time.Second
+)
+
+// EventSender - Tujuan event stream (response.EventWriter). Error = client sudah disconnect.
+type EventSender interface {
+	Send(event string, data interface{}) error
+	Comment(text string) error
+}
+
+// BuildStatusEvent - Dikirim selama build belum punya log (queued, pod pending)
+type BuildStatusEvent struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// StreamBuildLogs - Build yang sudah selesai di-replay dari database, build yang masih
+// jalan di-follow dari pod builder. Event terakhir selalu "result" berisi build.
+func (s Service) StreamBuildLogs(ctx context.Context, build *Build, events EventSender) error {
+	if !buildFinished(build) {
+		pod, current, err := s.waitForBuildPod(ctx, build, events)
+		if err != nil {
+			return err
+		}
+		build = current
+
+		if pod != nil {
+			if err := s.followBuildLog(ctx, pod.Name, events); err != nil {
+				return err
+			}
+
+			build, err = s.waitForBuildResult(ctx, build, events)
+			if err != nil {
+				return err
+			}
+			return events.Send(BuildEventResult, build)
+		}
+	}
+
+	if err := s.replayBuildLog(ctx, build, events); err != nil {
+		return err
+	}
+	return events.Send(BuildEventResult, build)
+}
+
+// followBuildLog - Step build (e.g., download dependency) bisa lama tanpa log baru,
+// keep-alive dikirim supaya proxy tidak menutup koneksi yang idle
+func (s Service) followBuildLog(ctx context.Context, pod string, events EventSender) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sender := &syncSender{events: events}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.k8s.StreamPodLogs(ctx, s.cfg.Builds.Namespace, pod, k8s.LogOptions{
+			Container: k8s.BuilderContainer,
+			Follow:    true,
+		}, func(line k8s.LogLine) error {
+			return sender.Send(BuildEventLog, buildLogLine(line))
+		})
+	}()
+
+	ticker := time.NewTicker(podLogKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if err := sender.Comment("keep-alive"); err != nil {
+				cancel()
+				<-done
+				return err
+			}
+		}
+	}
+}
+
+// waitForBuildPod - Pod nil kalau build sudah selesai sebelum container builder start
+func (s Service) waitForBuildPod(ctx context.Context, build *Build, events EventSender) (*corev1.Pod, *Build, error) {
+	ctx, cancel := context.WithTimeout(ctx, buildPodWaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(buildLogPollInterval)
+	defer ticker.Stop()
+
+	var last BuildStatusEvent
+	for {
+		current, err := s.repo.GetBuild(ctx, build.DeploymentId, build.Id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if buildFinished(current) {
+			return nil, current, nil
+		}
+
+		status := BuildStatusEvent{Status: current.Status, Message: "waiting for build job"}
+		if current.Status == BuildStatusRunning {
+			pod, err := s.k8s.GetJobPod(ctx, s.cfg.Builds.Namespace, buildJobName(current))
+			if err != nil {
+				return nil, nil, err
+			}
+			if pod != nil && k8s.ContainerStarted(pod, k8s.BuilderContainer) {
+				return pod, current, nil
+			}
+			status.Message = "waiting for build pod"
+			if pod != nil {
+				status.Message = fmt.Sprintf("build pod %s is %s", pod.Name, pod.Status.Phase)
+			}
+		}
+
+		if status != last {
+			if err := events.Send(BuildEventStatus, status); err != nil {
+				return nil, nil, err
+			}
+			last = status
+		} else if err := events.Comment("keep-alive"); err != nil {
+			return nil, nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("timed out waiting for build %d to start", build.Id)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForBuildResult - Status akhir disimpan worker setelah Job selesai
+func (s Service) waitForBuildResult(ctx context.Context, build *Build, events EventSender) (*Build, error) {
+	ctx, cancel := context.WithTimeout(ctx, buildResultWaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(buildLogPollInterval)
+	defer ticker.Stop()
+
+	for {
+		current, err := s.repo.GetBuild(ctx, build.DeploymentId, build.Id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return build, nil
+			}
+			return nil, err
+		}
+		if buildFinished(current) {
+			return current, nil
+		}
+		build = current
+		if err := events.Comment("keep-alive"); err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return build, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s Service) replayBuildLog(ctx context.Context, build *Build, events EventSender) error {
+	stored, err := s.repo.GetBuildLog(ctx, build.Id)
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return events.Send(BuildEventStatus, BuildStatusEvent{Status: build.Status, Message: "no logs were recorded for this build"})
+	}
+	if stored.Truncated {
+		message := fmt.Sprintf("log truncated to the last %d lines or %d bytes", maxBuildLogLines, maxBuildLogBytes)
+		if err := events.Send(BuildEventStatus, BuildStatusEvent{Status: build.Status, Message: message}); err != nil {
+			return err
+		}
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(stored.Content))
+	if err != nil {
+		return fmt.Errorf("failed to decompress build log: %w", err)
+	}
+	defer reader.Close()
+
+	return k8s.ReadLines(reader, k8s.MaxLogLineBytes, func(line string) error {
+		return events.Send(BuildEventLog, buildLogLine(k8s.ParseLogLine(line)))
+	})
+}
+
+// saveBuildLog - Simpan log pod builder sebelum Job dihapus (TTL / gagal). Error hanya di-log,
+// hasil build tetap dipakai.
+func (s Service) saveBuildLog(c context.Context, build *Build, podName string) {
+	if podName == "" {
+		return
+	}
+
+	// Log tidak pernah dibaca utuh ke memory: kubelet hanya mengirim baris terakhir, dibatasi byte
+	tailLines := int64(maxBuildLogLines)
+	limitBytes := int64(maxBuildLogBytes)
+	data, err := s.k8s.GetPodLogs(c, s.cfg.Builds.Namespace, podName, k8s.LogOptions{
+		Container:  k8s.BuilderContainer,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+	})
+	if err != nil {
+		log.Printf("failed to read logs of build %d: %s", build.Id, err.Error())
+		return
+	}
+	truncated := len(data) >= maxBuildLogBytes || bytes.Count(data, []byte("\n")) >= maxBuildLogLines
+
+	content, err := compressBuildLog(data)
+	if err != nil {
+		log.Printf("failed to compress logs of build %d: %s", build.Id, err.Error())
+		return
+	}
+
+	err = s.repo.SaveBuildLog(c, BuildLog{
+		BuildId:   build.Id,
+		Content:   content,
+		SizeBytes: len(data),
+		Truncated: truncated,
+	})
+	if err != nil {
+		log.Printf("failed to save logs of build %d: %s", build.Id, err.Error())
+	}
+}
+
+func compressBuildLog(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := io.Copy(writer, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildLogLine - Pod & container tidak relevan untuk log build
+func buildLogLine(line k8s.LogLine) k8s.LogLine {
+	return k8s.LogLine{Timestamp: line.Timestamp, Line: line.Line}
+}
+
+func buildFinished(build *Build) bool {
+	return build.Status == BuildStatusSucceeded || build.Status == BuildStatusFailed
+}
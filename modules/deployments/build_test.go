@@ -0,0 +1,131 @@
+package deployments
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testBuildNamespace = "workspace-builds"
+
+func testRegistrySecret(name, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testBuildNamespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(token)},
+	}
+}
+
+func TestEnsurePushSecret(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []runtime.Object
+		wantToken string
+		wantErr   bool
+	}{
+		{
+			name:      "per-user secret is used as is",
+			existing:  []runtime.Object{testRegistrySecret("registry-push", "shared"), testRegistrySecret("registry-push-user1", "scoped")},
+			wantToken: "scoped",
+		},
+		{
+			name:      "first build derives it from the shared secret",
+			existing:  []runtime.Object{testRegistrySecret("registry-push", "shared")},
+			wantToken: "shared",
+		},
+		{
+			name:    "missing credentials",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewClientset(tt.existing...)
+			cfg := config.Config{Builds: config.BuildsConfig{Namespace: testBuildNamespace, RegistrySecret: "registry-push"}}
+			service := NewService(nil, nil, jobs.Service{}, k8s.NewK8sClientFromClientset(clientset), cfg)
+
+			err := service.ensurePushSecret(context.Background(), &Deployment{Id: 1, UserId: 1, Name: "shop"})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), string(ErrRegistrySecret)) {
+					t.Fatalf("expected %s, got %v", ErrRegistrySecret, err)
+				}
+				if code := determineStatusCode(err); code != http.StatusUnprocessableEntity {
+					t.Fatalf("expected status %d, got %d", http.StatusUnprocessableEntity, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected push secret, got %v", err)
+			}
+
+			secret, err := clientset.CoreV1().Secrets(testBuildNamespace).Get(context.Background(), "registry-push-user1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected per-user secret, got %v", err)
+			}
+			if got := string(secret.Data[corev1.DockerConfigJsonKey]); got != tt.wantToken {
+				t.Fatalf("expected credential %q, got %q", tt.wantToken, got)
+			}
+		})
+	}
+}
+
+func TestBuildJobConfig(t *testing.T) {
+	cfg := config.Config{Builds: config.BuildsConfig{Namespace: testBuildNamespace, RegistrySecret: "registry-push", BuilderImage: "kaniko"}}
+	service := NewService(nil, nil, jobs.Service{}, nil, cfg)
+
+	build := &Build{Id: 7, GitRepoURL: "https://git.example.com/shop.git", GitBranch: "main", DockerfilePath: "Dockerfile", Image: "registry.local/user1-shop"}
+	jobConfig, err := service.buildJobConfig(&Deployment{Id: 1, UserId: 1, Name: "shop"}, build)
+	if err != nil {
+		t.Fatalf("expected build job config, got %v", err)
+	}
+
+	// Builder tidak pernah push sendiri, credential hanya dipakai container push
+	args := strings.Join(jobConfig.Args, " ")
+	if !strings.Contains(args, "--no-push") || !strings.Contains(args, "--tar-path="+k8s.BuildOutputPath) {
+		t.Fatalf("expected builder to write a tarball without pushing, got %v", jobConfig.Args)
+	}
+	if jobConfig.PushImage != defaultPushImage || jobConfig.DockerConfigSecret != "registry-push-user1" {
+		t.Fatalf("unexpected push step %q with secret %q", jobConfig.PushImage, jobConfig.DockerConfigSecret)
+	}
+	if !strings.Contains(strings.Join(jobConfig.PushArgs, " "), buildImageTag(build)) {
+		t.Fatalf("expected push to %s, got %v", buildImageTag(build), jobConfig.PushArgs)
+	}
+}
+
+func TestBuildContext(t *testing.T) {
+	tests := []struct {
+		repoURL string
+		commit  string
+		want    string
+	}{
+		{repoURL: "https://git.example.com/shop.git", want: "git://git.example.com/shop.git#refs/heads/main"},
+		{repoURL: "git://git.example.com/shop.git", commit: "abc1234", want: "git://git.example.com/shop.git#refs/heads/main#abc1234"},
+		{repoURL: "ssh://git@git.example.com/shop.git"},
+		{repoURL: "git@git.example.com:shop.git"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repoURL, func(t *testing.T) {
+			got, err := buildContext(tt.repoURL, "main", tt.commit)
+			if tt.want == "" {
+				if err == nil || !strings.Contains(err.Error(), string(ErrInvalidBuild)) {
+					t.Fatalf("expected %s, got %q (%v)", ErrInvalidBuild, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("expected %s, got %q (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
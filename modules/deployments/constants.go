@@ -0,0 +1,245 @@
+package deployments
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type ErrorMessage string
+
+const (
+	// Validation errors
+	ErrInvalidName       ErrorMessage = "deployment name is invalid"
+	ErrInvalidHost       ErrorMessage = "host is invalid"
+	ErrMissingEnvVar     ErrorMessage = "required env var is missing"
+	ErrTemplateNotFound  ErrorMessage = "template not found"
+	ErrTemplateInactive  ErrorMessage = "template is not active"
+	ErrDeploymentExists  ErrorMessage = "deployment name already exists"
+	ErrDeploymentMissing ErrorMessage = "deployment not found"
+	ErrInvalidTransition ErrorMessage = "invalid status transition"
+	ErrRevisionNotFound  ErrorMessage = "revision not found"
+	ErrAlreadyAtRevision ErrorMessage = "deployment is already at revision"
+	ErrInvalidImage      ErrorMessage = "image is invalid"
+	ErrInvalidResource   ErrorMessage = "resource quantity is invalid"
+	ErrInvalidReplicas   ErrorMessage = "replicas is invalid"
+	ErrSecretEnvVar      ErrorMessage = "secret env var cannot be updated through revisions"
+	ErrReplicaLimit      ErrorMessage = "replica limit exceeded"
+	ErrQuotaExceeded     ErrorMessage = "plan quota exceeded"
+	ErrStatusConflict    ErrorMessage = "deployment status was changed concurrently"
+	ErrInvalidIdle       ErrorMessage = "idle timeout is invalid"
+	ErrInvalidAutoscale  ErrorMessage = "autoscaling config is invalid"
+	ErrAutoscaleEnabled  ErrorMessage = "replicas are managed by autoscaling"
+	ErrDeleteConfirm     ErrorMessage = "volume deletion must be confirmed with the deployment name"
+	ErrUnsupportedDB     ErrorMessage = "database type is not supported"
+	ErrUnsupportedAddon  ErrorMessage = "addon type is not supported"
+	ErrAddonExists       ErrorMessage = "addon is already attached"
+	ErrAddonMissing      ErrorMessage = "addon not found"
+	ErrAddonBusy         ErrorMessage = "addon is still being provisioned or detached"
+	ErrInvalidEgress     ErrorMessage = "egress rule is invalid"
+	ErrEgressExists      ErrorMessage = "egress rule already exists"
+	ErrEgressMissing     ErrorMessage = "egress rule not found"
+	ErrInvalidDomain     ErrorMessage = "domain is invalid"
+	ErrInvalidSubdomain  ErrorMessage = "subdomain is invalid"
+	ErrSubdomainReserved ErrorMessage = "subdomain is reserved"
+	ErrSubdomainTaken    ErrorMessage = "subdomain is already taken"
+	ErrDomainExists      ErrorMessage = "domain is already in use"
+	ErrDomainMissing     ErrorMessage = "domain not found"
+	ErrDomainLimit       ErrorMessage = "custom domain limit exceeded"
+	ErrDomainUnverified  ErrorMessage = "domain verification failed"
+	ErrBuildsDisabled    ErrorMessage = "image builds are not enabled"
+	ErrNoGitRepo         ErrorMessage = "template has no git repository"
+	ErrInvalidBuild      ErrorMessage = "build request is invalid"
+	ErrBuildMissing      ErrorMessage = "build not found"
+	ErrBuildRunning      ErrorMessage = "a build is already in progress"
+	ErrRegistrySecret    ErrorMessage = "registry push credentials are not configured"
+	ErrInvalidWebhook    ErrorMessage = "webhook request is invalid"
+	ErrInvalidPreview    ErrorMessage = "preview environment request is invalid"
+	ErrPreviewExists     ErrorMessage = "preview environment already exists for branch"
+	ErrPreviewLimit      ErrorMessage = "preview environment limit exceeded"
+	ErrWebhookSignature  ErrorMessage = "webhook signature is invalid"
+	ErrWebhookMissing    ErrorMessage = "webhook not found"
+	ErrDeliveryMissing   ErrorMessage = "webhook delivery not found"
+	ErrDeliveryReplayed  ErrorMessage = "webhook delivery was already received"
+	ErrDeliveryExpired   ErrorMessage = "webhook delivery is too old"
+	ErrInvalidLogs       ErrorMessage = "log request is invalid"
+	ErrPodsMissing       ErrorMessage = "no pods found for deployment"
+	ErrJobInProgress     ErrorMessage = "a deployment job is still in progress"
+
+	// Provisioning errors
+	ErrProvisionFailed ErrorMessage = "failed to provision deployment"
+	ErrEnqueueFailed   ErrorMessage = "failed to enqueue deployment job"
+	ErrQueryFailed     ErrorMessage = "query execution failed"
+)
+
+var (
+	dnsLabelRegex = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+	hostRegex     = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?\.)+[a-z]{2,}$`)
+
+	// previewNameRegex - "<parent>-pr-<n>" dipakai preview pull request (lihat previewName)
+	previewNameRegex = regexp.MustCompile(`-pr-[0-9]+$`)
+)
+
+var reservedNameSuffixes = []string{"-postgres", "-" + AddonRedis, "-" + AddonRabbitMQ}
+
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%s: name cannot be empty", ErrInvalidName)
+	}
+
+	// Nama dipakai sebagai prefix resource K8s ("<name>-deployment"), jadi harus pendek
+	if len(name) > 40 {
+		return fmt.Errorf("%s: name must be at most 40 characters", ErrInvalidName)
+	}
+
+	if !dnsLabelRegex.MatchString(name) {
+		return fmt.Errorf("%s: name can only contain lowercase letters, numbers, and '-'", ErrInvalidName)
+	}
+
+	// Label app database & add-on adalah "<name>-<type>", workspace dengan nama itu
+	// akan ikut ter-select oleh Service / Deployment-nya
+	for _, suffix := range reservedNameSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return fmt.Errorf("%s: name cannot end with %q", ErrInvalidName, suffix)
+		}
+	}
+
+	return nil
+}
+
+// validateUserName - validateName untuk nama yang dipilih user, pola nama preview ditolak
+// supaya tidak bentrok dengan preview pull request yang dibuat kemudian
+func validateUserName(name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+	if previewNameRegex.MatchString(name) {
+		return fmt.Errorf("%s: names ending with -pr-<number> are reserved for preview environments", ErrInvalidName)
+	}
+	return nil
+}
+
+func validateHost(host string) error {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return fmt.Errorf("%s: host cannot be empty", ErrInvalidHost)
+	}
+
+	if len(host) > 253 || !hostRegex.MatchString(host) {
+		return fmt.Errorf("%s: %s is not a valid hostname", ErrInvalidHost, host)
+	}
+
+	return nil
+}
+
+// maxIdleTimeoutMinutes - 7 hari
+const maxIdleTimeoutMinutes = 7 * 24 * 60
+
+func validateIdleTimeout(minutes int) error {
+	if minutes < 0 || minutes > maxIdleTimeoutMinutes {
+		return fmt.Errorf("%s: must be between 0 and %d minutes", ErrInvalidIdle, maxIdleTimeoutMinutes)
+	}
+	return nil
+}
+
+func determineStatusCode(err error) int {
+	errMsg := err.Error()
+
+	// Validation errors (400)
+	validationErrors := []ErrorMessage{
+		ErrInvalidName,
+		ErrInvalidHost,
+		ErrMissingEnvVar,
+		ErrTemplateInactive,
+		ErrInvalidImage,
+		ErrInvalidResource,
+		ErrInvalidReplicas,
+		ErrSecretEnvVar,
+		ErrInvalidIdle,
+		ErrInvalidAutoscale,
+		ErrAutoscaleEnabled,
+		ErrDeleteConfirm,
+		ErrUnsupportedDB,
+		ErrUnsupportedAddon,
+		ErrInvalidEgress,
+		ErrInvalidDomain,
+		ErrInvalidSubdomain,
+		ErrSubdomainReserved,
+		ErrBuildsDisabled,
+		ErrNoGitRepo,
+		ErrInvalidBuild,
+		ErrInvalidWebhook,
+		ErrInvalidPreview,
+		ErrInvalidLogs,
+	}
+	for _, validErr := range validationErrors {
+		if strings.Contains(errMsg, string(validErr)) {
+			return http.StatusBadRequest
+		}
+	}
+
+	// Not found errors (404)
+	notFoundErrors := []ErrorMessage{
+		ErrTemplateNotFound,
+		ErrDeploymentMissing,
+		ErrRevisionNotFound,
+		ErrAddonMissing,
+		ErrEgressMissing,
+		ErrDomainMissing,
+		ErrBuildMissing,
+		ErrWebhookMissing,
+		ErrDeliveryMissing,
+		ErrPodsMissing,
+	}
+	for _, notFoundErr := range notFoundErrors {
+		if strings.Contains(errMsg, string(notFoundErr)) {
+			return http.StatusNotFound
+		}
+	}
+
+	// Unauthorized errors (401)
+	if strings.Contains(errMsg, string(ErrWebhookSignature)) {
+		return http.StatusUnauthorized
+	}
+
+	// Limit errors (422)
+	limitErrors := []ErrorMessage{
+		ErrReplicaLimit,
+		ErrQuotaExceeded,
+		ErrDomainLimit,
+		ErrDomainUnverified,
+		ErrPreviewLimit,
+		ErrRegistrySecret,
+	}
+	for _, limitErr := range limitErrors {
+		if strings.Contains(errMsg, string(limitErr)) {
+			return http.StatusUnprocessableEntity
+		}
+	}
+
+	// Conflict errors (409)
+	conflictErrors := []ErrorMessage{
+		ErrDeploymentExists,
+		ErrInvalidTransition,
+		ErrStatusConflict,
+		ErrAlreadyAtRevision,
+		ErrAddonExists,
+		ErrAddonBusy,
+		ErrEgressExists,
+		ErrDomainExists,
+		ErrSubdomainTaken,
+		ErrBuildRunning,
+		ErrPreviewExists,
+		ErrJobInProgress,
+		ErrDeliveryReplayed,
+		ErrDeliveryExpired,
+	}
+	for _, conflictErr := range conflictErrors {
+		if strings.Contains(errMsg, string(conflictErr)) {
+			return http.StatusConflict
+		}
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,187 @@
+package deployments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DatabasePostgres = "postgresql"
+
+	databasePort = 5432
+	databaseUser = "app"
+
+	// databaseReadyTimeoutSeconds - Postgres harus ready sebelum app dibuat, kalau tidak app crash loop
+	databaseReadyTimeoutSeconds = 180
+)
+
+const (
+	defaultPostgresImage       = "postgres:16-alpine"
+	defaultDatabaseStorageSize = "1Gi"
+)
+
+// resolveDatabaseType - Database yang harus di-provision untuk template, "" = tidak ada
+func resolveDatabaseType(tmpl *templates.Template) (string, error) {
+	if !tmpl.RequiresDatabase {
+		return "", nil
+	}
+	if tmpl.DefaultDatabaseType == nil {
+		return DatabasePostgres, nil
+	}
+
+	switch strings.ToLower(*tmpl.DefaultDatabaseType) {
+	case "", "postgres", DatabasePostgres:
+		return DatabasePostgres, nil
+	default:
+		return "", fmt.Errorf("%s: %s", ErrUnsupportedDB, *tmpl.DefaultDatabaseType)
+	}
+}
+
+// Nama resource database, semuanya diturunkan dari nama deployment
+func databaseName(data *Deployment) string {
+	return fmt.Sprintf("%s-postgres", data.Name)
+}
+
+func databaseSecretName(data *Deployment) string {
+	return fmt.Sprintf("%s-database", data.Name)
+}
+
+func databaseClaimName(data *Deployment) string {
+	return fmt.Sprintf("%s-postgres-data", data.Name)
+}
+
+// databaseCredentials - Secret untuk container postgres (POSTGRES_*) sekaligus untuk app (DATABASE_URL, PG*)
+func databaseCredentials(data *Deployment) (map[string]string, error) {
+	password, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	dbName := strings.ReplaceAll(data.Name, "-", "_")
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", databaseName(data), data.Namespace)
+	databaseURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(databaseUser, password),
+		Host:     fmt.Sprintf("%s:%d", host, databasePort),
+		Path:     "/" + dbName,
+		RawQuery: "sslmode=disable",
+	}
+
+	return map[string]string{
+		"POSTGRES_USER":     databaseUser,
+		"POSTGRES_PASSWORD": password,
+		"POSTGRES_DB":       dbName,
+		"DATABASE_URL":      databaseURL.String(),
+		"PGHOST":            host,
+		"PGPORT":            fmt.Sprintf("%d", databasePort),
+		"PGUSER":            databaseUser,
+		"PGPASSWORD":        password,
+		"PGDATABASE":        dbName,
+	}, nil
+}
+
+// provisionDatabase - Secret → PVC → StatefulSet → Service, lalu tunggu postgres ready.
+// Semua step tercatat di tx, jadi ikut di-rollback kalau provision gagal.
+func (s Service) provisionDatabase(c context.Context, tx *k8s.Transaction, data *Deployment) error {
+	addon := s.cfg.Addons.Postgres
+	labels := map[string]string{
+		"app":        databaseName(data),
+		"managed-by": managedBy,
+	}
+
+	credentials, err := databaseCredentials(data)
+	if err != nil {
+		return err
+	}
+	if err := tx.CreateSecretFromStringData(c, data.Namespace, databaseSecretName(data), credentials); err != nil {
+		return err
+	}
+
+	volume := k8s.VolumeConfig{
+		Name:       "data",
+		ClaimName:  databaseClaimName(data),
+		MountPath:  "/var/lib/postgresql/data",
+		Size:       valueOr(addon.StorageSize, defaultDatabaseStorageSize),
+		AccessMode: templates.AccessReadWriteOnce,
+	}
+	if addon.StorageClass != "" {
+		volume.StorageClass = &addon.StorageClass
+	}
+	if err := tx.CreatePersistentVolumeClaim(c, data.Namespace, volume, labels); err != nil {
+		return err
+	}
+
+	readiness := &k8s.ProbeConfig{
+		Type:             k8s.ProbeExec,
+		Command:          []string{"sh", "-c", `pg_isready -U "$POSTGRES_USER" -d "$POSTGRES_DB"`},
+		PeriodSeconds:    5,
+		FailureThreshold: 6,
+	}
+	err = tx.CreateStatefulSet(c, &k8s.StatefulSetConfig{
+		Name:          databaseName(data),
+		Namespace:     data.Namespace,
+		AppName:       databaseName(data),
+		ServiceName:   databaseName(data),
+		Image:         valueOr(addon.Image, defaultPostgresImage),
+		ContainerPort: databasePort,
+		CPURequest:    addon.CPURequest,
+		CPULimit:      addon.CPULimit,
+		MemoryRequest: addon.MemoryRequest,
+		MemoryLimit:   addon.MemoryLimit,
+		SecretName:    databaseSecretName(data),
+		EnvVars: []corev1.EnvVar{
+			// Subdirectory karena root volume bisa berisi lost+found
+			{Name: "PGDATA", Value: "/var/lib/postgresql/data/pgdata"},
+		},
+		Volumes:        []k8s.VolumeConfig{volume},
+		ReadinessProbe: readiness,
+		LivenessProbe: &k8s.ProbeConfig{
+			Type:                k8s.ProbeTCP,
+			InitialDelaySeconds: 30,
+			PeriodSeconds:       10,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.CreateService(c, data.Namespace, databaseName(data), databaseName(data), databasePort, databasePort); err != nil {
+		return err
+	}
+
+	return s.k8s.WaitForStatefulSetReady(c, data.Namespace, databaseName(data), databaseReadyTimeoutSeconds)
+}
+
+// teardownDatabase - Database ikut dihapus bersama workspace, termasuk datanya
+func (s Service) teardownDatabase(c context.Context, data *Deployment) []teardownStep {
+	return []teardownStep{
+		{"database service", func() error { return s.k8s.DeleteService(c, data.Namespace, databaseName(data)) }},
+		{"database statefulset", func() error { return s.k8s.DeleteStatefulSet(c, data.Namespace, databaseName(data)) }},
+		{"database pvc", func() error { return s.k8s.DeletePersistentVolumeClaim(c, data.Namespace, databaseClaimName(data)) }},
+		{"database secret", func() error { return s.k8s.DeleteSecret(c, data.Namespace, databaseSecretName(data)) }},
+	}
+}
+
+// generatePassword - Password random untuk database dan add-on
+func generatePassword() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -1 +1,87 @@
 package deployments
+
+import (
+	"context"
+	"time"
+)
+
+type DeploymentRepository interface {
+	Create(c context.Context, req Deployment, actor string) (*Deployment, error)
+	FindById(c context.Context, id, userId int) (*Deployment, error)
+	ListByUser(c context.Context, userId int) ([]Deployment, error)
+	SumReplicasByUser(c context.Context, userId, excludeId int) (int, error)
+	FindByHost(c context.Context, host string) (*Deployment, error)
+
+	// Subdomain di bawah ingress.base_domain
+	SubdomainExists(c context.Context, subdomain string) (bool, error)
+	UpdateSubdomain(c context.Context, id int, subdomain, host string) error
+	GetUsername(c context.Context, userId int) (string, error)
+
+	// Preview environment per branch
+	ListPreviews(c context.Context, parentId int) ([]Deployment, error)
+	ListExpiredPreviews(c context.Context) ([]Deployment, error)
+	ListOrphanedPreviews(c context.Context) ([]Deployment, error)
+
+	// Scale-to-zero
+	ListIdle(c context.Context) ([]Deployment, error)
+	TouchActivity(c context.Context, id int) error
+	SetSleeping(c context.Context, id int, sleeping bool) error
+	SetIdleTimeout(c context.Context, id, minutes int) error
+
+	// Add-ons
+	CreateAddon(c context.Context, addon Addon) (*Addon, error)
+	ListAddons(c context.Context, deploymentId int) ([]Addon, error)
+	GetAddon(c context.Context, deploymentId int, addonType string) (*Addon, error)
+	SetAddonStatus(c context.Context, id int, status, message string) error
+	DeleteAddon(c context.Context, id int) error
+	ListAddonsByUser(c context.Context, userId int) ([]Addon, error)
+
+	// Custom domain
+	CreateDomain(c context.Context, domain Domain) (*Domain, error)
+	ListDomains(c context.Context, deploymentId int) ([]Domain, error)
+	GetDomain(c context.Context, deploymentId, id int) (*Domain, error)
+	ListPendingDomains(c context.Context, since time.Time) ([]Domain, error)
+	SetDomainStatus(c context.Context, id int, status, message string) error
+	DeleteDomain(c context.Context, id int) error
+	DeleteDomainsByDeployment(c context.Context, deploymentId int) error
+
+	// Build image dari git repo template
+	CreateBuild(c context.Context, build Build) (*Build, error)
+	ListBuilds(c context.Context, deploymentId int) ([]Build, error)
+	GetBuild(c context.Context, deploymentId, id int) (*Build, error)
+	HasActiveBuild(c context.Context, deploymentId int) (bool, error)
+	CountActiveBuildsByUser(c context.Context, userId int) (int, error)
+	StartBuild(c context.Context, id int, jobName string) error
+	FinishBuild(c context.Context, id int, status, message, digest string) error
+	SetBuildDeployed(c context.Context, id int, revision *int, message string) error
+	SaveBuildLog(c context.Context, log BuildLog) error
+	GetBuildLog(c context.Context, buildId int) (*BuildLog, error)
+
+	// Webhook git
+	GetWebhook(c context.Context, deploymentId int) (*Webhook, error)
+	SaveWebhook(c context.Context, deploymentId int, secret string) (*Webhook, error)
+	DeleteWebhook(c context.Context, deploymentId int) error
+	CreateDelivery(c context.Context, delivery WebhookDelivery) (*WebhookDelivery, error)
+	UpdateDeliveryResult(c context.Context, delivery WebhookDelivery) error
+	ListDeliveries(c context.Context, deploymentId int) ([]WebhookDelivery, error)
+	GetDelivery(c context.Context, deploymentId, id int) (*WebhookDelivery, error)
+
+	// Egress yang dibuka user untuk namespace-nya
+	CreateEgressRule(c context.Context, rule EgressRule) (*EgressRule, error)
+	ListEgressRules(c context.Context, userId int) ([]EgressRule, error)
+	DeleteEgressRule(c context.Context, id, userId int) error
+
+	// Plan user (tabel users)
+	GetUserPlan(c context.Context, userId int) (string, error)
+	LockUser(c context.Context, userId int) (func(), error)
+	ListUserPlans(c context.Context) (map[int]string, error)
+	Transition(c context.Context, id int, from, to, actor, reason string) error
+	ListHistory(c context.Context, id int) ([]StatusTransition, error)
+
+	// Revisions
+	SaveRevision(c context.Context, id int, from string, spec DeploymentSpec, actor, changeCause string, rollbackOf *int) (*Revision, error)
+	ListRevisions(c context.Context, id int) ([]Revision, error)
+	GetRevision(c context.Context, id, revision int) (*Revision, error)
+}
+
+const managedBy = "backend-workspaces"
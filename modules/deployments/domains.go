@@ -0,0 +1,337 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+)
+
+const (
+	DomainStatusPending  = "pending"
+	DomainStatusVerified = "verified"
+)
+
+const (
+	defaultVerificationPrefix   = "_workspaces-challenge"
+	defaultDomainVerifyInterval = 5 * time.Minute
+
+	// domainVerifyWindow - Domain pending yang lebih lama dari ini tidak di-check otomatis lagi
+	domainVerifyWindow = 7 * 24 * time.Hour
+
+	verificationValuePrefix = "workspaces-verification="
+)
+
+// TXTResolver - Lookup record TXT, *net.Resolver memenuhi interface ini
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DomainVerifier - Cek kepemilikan domain lewat record TXT <prefix>.<domain>
+type DomainVerifier struct {
+	resolver TXTResolver
+	prefix   string
+}
+
+func NewDomainVerifier(resolver TXTResolver, prefix string) DomainVerifier {
+	if prefix == "" {
+		prefix = defaultVerificationPrefix
+	}
+	return DomainVerifier{resolver: resolver, prefix: prefix}
+}
+
+// Record - Record TXT yang harus dibuat user untuk domain & token ini
+func (v DomainVerifier) Record(domain, token string) *DomainVerification {
+	return &DomainVerification{
+		Type:  "TXT",
+		Name:  fmt.Sprintf("%s.%s", v.prefix, domain),
+		Value: verificationValuePrefix + token,
+	}
+}
+
+// Verify - nil kalau salah satu value record TXT sama dengan token
+func (v DomainVerifier) Verify(ctx context.Context, domain, token string) error {
+	record := v.Record(domain, token)
+
+	values, err := v.resolver.LookupTXT(ctx, record.Name)
+	if err != nil {
+		return fmt.Errorf("%s: lookup TXT %s: %s", ErrDomainUnverified, record.Name, err.Error())
+	}
+
+	for _, value := range values {
+		if strings.TrimSpace(value) == record.Value {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: TXT %s does not contain %q", ErrDomainUnverified, record.Name, record.Value)
+}
+
+// ListDomains - Custom domain workspace beserta record TXT-nya
+func (s Service) ListDomains(c context.Context, id, userId int) ([]Domain, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	domains, err := s.repo.ListDomains(c, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range domains {
+		s.withVerification(&domains[i])
+	}
+
+	return domains, nil
+}
+
+// AddDomain - Simpan domain (status pending) dan token verifikasi. Domain baru masuk
+// ke Ingress setelah VerifyDomain / check periodik menemukan record TXT-nya.
+func (s Service) AddDomain(c context.Context, id, userId int, req DomainRequest) (*Domain, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDomainChange(data); err != nil {
+		return nil, err
+	}
+
+	name, err := s.validateDomain(data, req.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if max := s.cfg.Ingress.CustomDomains.MaxPerDeployment; max > 0 {
+		domains, err := s.repo.ListDomains(c, data.Id)
+		if err != nil {
+			return nil, err
+		}
+		if len(domains) >= max {
+			return nil, fmt.Errorf("%s: at most %d domains per deployment", ErrDomainLimit, max)
+		}
+	}
+
+	token, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.repo.CreateDomain(c, Domain{
+		DeploymentId:      data.Id,
+		UserId:            data.UserId,
+		Domain:            name,
+		VerificationToken: token,
+		Status:            DomainStatusPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withVerification(domain), nil
+}
+
+// VerifyDomain - Cek record TXT sekarang, kalau cocok domain ditambahkan ke Ingress
+func (s Service) VerifyDomain(c context.Context, id, userId, domainId int) (*Domain, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDomainChange(data); err != nil {
+		return nil, err
+	}
+
+	domain, err := s.repo.GetDomain(c, data.Id, domainId)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain.Status != DomainStatusVerified {
+		if err := s.verifyDomain(c, data, domain); err != nil {
+			return nil, err
+		}
+	}
+
+	domain, err = s.repo.GetDomain(c, data.Id, domainId)
+	if err != nil {
+		return nil, err
+	}
+	return s.withVerification(domain), nil
+}
+
+// DeleteDomain - Keluarkan domain dari Ingress dulu, baru hapus dari DB
+func (s Service) DeleteDomain(c context.Context, id, userId, domainId int) error {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return err
+	}
+
+	domain, err := s.repo.GetDomain(c, data.Id, domainId)
+	if err != nil {
+		return err
+	}
+
+	if domain.Status == DomainStatusVerified {
+		if err := checkDomainChange(data); err != nil {
+			return err
+		}
+		if err := s.syncDomainHosts(c, data, domain.Id); err != nil {
+			return err
+		}
+		s.deleteDomainSecret(c, data, *domain)
+	}
+
+	return s.repo.DeleteDomain(c, domain.Id)
+}
+
+// teardownDomains - Dipanggil saat workspace dihapus: secret TLS domain ikut dihapus
+// dan domain dilepas supaya bisa diverifikasi workspace lain
+func (s Service) teardownDomains(c context.Context, data *Deployment) error {
+	domains, err := s.repo.ListDomains(c, data.Id)
+	if err != nil {
+		return err
+	}
+	for _, domain := range domains {
+		if domain.Status == DomainStatusVerified {
+			s.deleteDomainSecret(c, data, domain)
+		}
+	}
+
+	return s.repo.DeleteDomainsByDeployment(c, data.Id)
+}
+
+// deleteDomainSecret - Certificate cert-manager ikut terhapus bersama rule Ingress,
+// secret-nya tidak, jadi dihapus manual (error hanya di-log)
+func (s Service) deleteDomainSecret(c context.Context, data *Deployment, domain Domain) {
+	if !s.cfg.Ingress.TLS {
+		return
+	}
+	if err := s.k8s.DeleteSecret(c, data.Namespace, domainSecretName(data, domain)); err != nil {
+		log.Printf("failed to delete tls secret for domain %s: %s", domain.Domain, err.Error())
+	}
+}
+
+// verifyDomain - Record TXT cocok → klaim domain di DB → update Ingress.
+// Kalau update Ingress gagal, domain dikembalikan ke pending.
+func (s Service) verifyDomain(c context.Context, data *Deployment, domain *Domain) error {
+	if err := s.verifier.Verify(c, domain.Domain, domain.VerificationToken); err != nil {
+		if statusErr := s.repo.SetDomainStatus(c, domain.Id, DomainStatusPending, err.Error()); statusErr != nil {
+			log.Printf("failed to update domain %d: %s", domain.Id, statusErr.Error())
+		}
+		return err
+	}
+
+	if err := s.repo.SetDomainStatus(c, domain.Id, DomainStatusVerified, ""); err != nil {
+		return err
+	}
+
+	if err := s.syncDomainHosts(c, data, 0); err != nil {
+		if statusErr := s.repo.SetDomainStatus(c, domain.Id, DomainStatusPending, err.Error()); statusErr != nil {
+			log.Printf("failed to update domain %d: %s", domain.Id, statusErr.Error())
+		}
+		return err
+	}
+
+	log.Printf("domain %s verified for deployment %d", domain.Domain, data.Id)
+	return nil
+}
+
+// syncDomainHosts - Host tambahan Ingress = semua domain verified kecuali excludeId
+func (s Service) syncDomainHosts(c context.Context, data *Deployment, excludeId int) error {
+	domains, err := s.repo.ListDomains(c, data.Id)
+	if err != nil {
+		return err
+	}
+
+	hosts := []k8s.IngressHost{}
+	for _, domain := range domains {
+		if domain.Id == excludeId || domain.Status != DomainStatusVerified {
+			continue
+		}
+		host := k8s.IngressHost{Host: domain.Domain}
+		if s.cfg.Ingress.TLS {
+			host.SecretName = domainSecretName(data, domain)
+		}
+		hosts = append(hosts, host)
+	}
+
+	return s.k8s.SetIngressHosts(c, data.Namespace, data.IngressName, hosts)
+}
+
+// verifyPendingDomains - Periodic task: re-check domain pending supaya user tidak perlu
+// memanggil verify setelah record DNS-nya propagate
+func (s Service) verifyPendingDomains(c context.Context) error {
+	domains, err := s.repo.ListPendingDomains(c, time.Now().Add(-domainVerifyWindow))
+	if err != nil {
+		return err
+	}
+
+	for i := range domains {
+		domain := &domains[i]
+
+		data, err := s.repo.FindById(c, domain.DeploymentId, domain.UserId)
+		if err != nil {
+			log.Printf("failed to find deployment for domain %d: %s", domain.Id, err.Error())
+			continue
+		}
+		if checkDomainChange(data) != nil {
+			continue
+		}
+
+		if err := s.verifyDomain(c, data, domain); err != nil && !strings.Contains(err.Error(), string(ErrDomainUnverified)) {
+			log.Printf("failed to verify domain %s: %s", domain.Domain, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// checkDomainChange - Ingress hanya bisa diubah saat resource workspace sudah ada
+func checkDomainChange(data *Deployment) error {
+	switch data.Status {
+	case StatusRunning, StatusDegraded, StatusStopped:
+		return nil
+	default:
+		return fmt.Errorf("%s: cannot change domains while deployment is %s", ErrInvalidTransition, data.Status)
+	}
+}
+
+// validateDomain - Domain dinormalisasi (lowercase, tanpa titik di akhir). Host platform
+// dan subdomain base domain tidak bisa diklaim sebagai custom domain.
+func (s Service) validateDomain(data *Deployment, domain string) (string, error) {
+	name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(name) > 253 || !hostRegex.MatchString(name) {
+		return "", fmt.Errorf("%s: %s is not a valid hostname", ErrInvalidDomain, domain)
+	}
+
+	if name == data.Host {
+		return "", fmt.Errorf("%s: %s is already the deployment host", ErrInvalidDomain, name)
+	}
+
+	if base := strings.ToLower(s.cfg.Ingress.BaseDomain); base != "" && (name == base || strings.HasSuffix(name, "."+base)) {
+		return "", fmt.Errorf("%s: %s belongs to the platform domain", ErrInvalidDomain, name)
+	}
+
+	return name, nil
+}
+
+func (s Service) withVerification(domain *Domain) *Domain {
+	if domain.Status != DomainStatusVerified {
+		domain.Verification = s.verifier.Record(domain.Domain, domain.VerificationToken)
+	}
+	return domain
+}
+
+func (s Service) domainVerifyInterval() time.Duration {
+	if s.cfg.Ingress.CustomDomains.VerifyIntervalSeconds <= 0 {
+		return defaultDomainVerifyInterval
+	}
+	return time.Duration(s.cfg.Ingress.CustomDomains.VerifyIntervalSeconds) * time.Second
+}
+
+// domainSecretName - Satu secret TLS per domain supaya gagal issue di satu domain
+// tidak mempengaruhi certificate host utama
+func domainSecretName(data *Deployment, domain Domain) string {
+	return fmt.Sprintf("%s-domain-%d-tls", data.IngressName, domain.Id)
+}
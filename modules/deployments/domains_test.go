@@ -0,0 +1,133 @@
+package deployments
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testDomain      = "shop.example.com"
+	testDomainToken = "token-123"
+	testNamespace   = "user1-workspaces"
+)
+
+// fakeResolver - Record TXT per nama, nama yang tidak ada dianggap NXDOMAIN
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	values, ok := r[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return values, nil
+}
+
+// fakeDomainRepo - Hanya method yang dipakai VerifyDomain, sisanya panic lewat interface nil
+type fakeDomainRepo struct {
+	DeploymentRepository
+	deployment *Deployment
+	domain     Domain
+}
+
+func (r *fakeDomainRepo) FindById(c context.Context, id, userId int) (*Deployment, error) {
+	return r.deployment, nil
+}
+
+func (r *fakeDomainRepo) GetDomain(c context.Context, deploymentId, id int) (*Domain, error) {
+	domain := r.domain
+	return &domain, nil
+}
+
+func (r *fakeDomainRepo) ListDomains(c context.Context, deploymentId int) ([]Domain, error) {
+	return []Domain{r.domain}, nil
+}
+
+func (r *fakeDomainRepo) SetDomainStatus(c context.Context, id int, status, message string) error {
+	r.domain.Status = status
+	r.domain.StatusMessage = &message
+	return nil
+}
+
+func testIngress() *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "shop-ingress", Namespace: testNamespace},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{Host: "shop.workspaces.test"}},
+		},
+	}
+}
+
+func TestVerifyDomain(t *testing.T) {
+	record := defaultVerificationPrefix + "." + testDomain
+
+	tests := []struct {
+		name       string
+		records    fakeResolver
+		wantErr    bool
+		wantStatus string
+		wantHosts  int
+	}{
+		{
+			name:       "verified",
+			records:    fakeResolver{record: {"unrelated", verificationValuePrefix + testDomainToken}},
+			wantStatus: DomainStatusVerified,
+			wantHosts:  2,
+		},
+		{
+			name:       "missing txt record",
+			records:    fakeResolver{},
+			wantErr:    true,
+			wantStatus: DomainStatusPending,
+			wantHosts:  1,
+		},
+		{
+			name:       "wrong token",
+			records:    fakeResolver{record: {verificationValuePrefix + "other-token"}},
+			wantErr:    true,
+			wantStatus: DomainStatusPending,
+			wantHosts:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeDomainRepo{
+				deployment: &Deployment{Id: 1, UserId: 1, Namespace: testNamespace, IngressName: "shop-ingress", Status: StatusRunning},
+				domain:     Domain{Id: 7, DeploymentId: 1, UserId: 1, Domain: testDomain, VerificationToken: testDomainToken, Status: DomainStatusPending},
+			}
+			clientset := fake.NewClientset(testIngress())
+			client := k8s.NewK8sClientFromClientset(clientset)
+			service := NewService(repo, nil, jobs.Service{}, client, config.Config{}).WithResolver(tt.records)
+
+			_, err := service.VerifyDomain(context.Background(), 1, 1, 7)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), string(ErrDomainUnverified)) {
+					t.Fatalf("expected %s, got %v", ErrDomainUnverified, err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected verified, got %v", err)
+			}
+
+			if repo.domain.Status != tt.wantStatus {
+				t.Fatalf("expected domain status %s, got %s", tt.wantStatus, repo.domain.Status)
+			}
+
+			ingress, err := clientset.NetworkingV1().Ingresses(testNamespace).Get(context.Background(), "shop-ingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ingress.Spec.Rules) != tt.wantHosts {
+				t.Fatalf("expected %d ingress hosts, got %d", tt.wantHosts, len(ingress.Spec.Rules))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package deployments
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+// resolveEnvVars - Gabungkan env dari user dengan schema template.
+// Env dengan `secret: true` masuk ke Secret, sisanya ke ConfigMap.
+func resolveEnvVars(schema templates.EnvVarsSchema, values map[string]string) (configData, secretData map[string]string, err error) {
+	configData = map[string]string{}
+	secretData = map[string]string{}
+
+	// Sort supaya error missing env selalu konsisten
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		prop := schema[key]
+
+		value, ok := values[key]
+		if !ok || value == "" {
+			value, ok = defaultValue(prop.Default)
+		}
+		if !ok {
+			if prop.Required {
+				return nil, nil, fmt.Errorf("%s: %s", ErrMissingEnvVar, key)
+			}
+			continue
+		}
+
+		if prop.Secret {
+			secretData[key] = value
+		} else {
+			configData[key] = value
+		}
+	}
+
+	// Env yang tidak ada di schema dianggap non-secret
+	for key, value := range values {
+		if _, ok := schema[key]; !ok {
+			configData[key] = value
+		}
+	}
+
+	return configData, secretData, nil
+}
+
+// resolveConfigEnvVars - Dipakai saat update: env secret tetap di Secret K8s dan tidak ikut
+// divalidasi, env non-secret yang required harus ada atau punya default seperti saat Create
+func resolveConfigEnvVars(schema templates.EnvVarsSchema, values map[string]string) (map[string]string, error) {
+	configSchema := templates.EnvVarsSchema{}
+	for key, prop := range schema {
+		if !prop.Secret {
+			configSchema[key] = prop
+		}
+	}
+
+	configData, _, err := resolveEnvVars(configSchema, values)
+	return configData, err
+}
+
+func defaultValue(raw json.RawMessage) (string, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", false
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str, true
+	}
+
+	// Number / bool default disimpan apa adanya
+	return string(raw), true
+}
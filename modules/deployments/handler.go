@@ -0,0 +1,957 @@
+package deployments
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wafi11/backend-workspaces/modules/auth"
+	"github.com/wafi11/backend-workspaces/pkg/response"
+)
+
+type Handler struct {
+	s Service
+}
+
+func NewHandler(s Service) Handler {
+	return Handler{s: s}
+}
+
+func (h Handler) Create(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	var req CreateDeploymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.Create(c.Context(), userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "deployment queued successfully", data)
+}
+
+func (h Handler) List(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	data, err := h.s.List(c.Context(), userId)
+	if err != nil {
+		return response.Error(c, http.StatusInternalServerError, "failed to retrieved list deployments")
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved list deployments", data)
+}
+
+func (h Handler) FindById(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.FindById(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved deployment", data)
+}
+
+func (h Handler) Delete(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req DeleteDeploymentRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid query params")
+	}
+
+	data, err := h.s.Delete(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "delete deployment queued successfully", data)
+}
+
+func (h Handler) ListJobs(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListJobs(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved deployment jobs", data)
+}
+
+func (h Handler) History(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.History(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved deployment history", data)
+}
+
+func (h Handler) Update(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req UpdateDeploymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.Update(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "update deployment queued successfully", data)
+}
+
+func (h Handler) ListRevisions(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListRevisions(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved deployment revisions", data)
+}
+
+func (h Handler) Rollback(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req RollbackRequest
+	if err := c.BodyParser(&req); err != nil || req.Revision <= 0 {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.Rollback(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "rollback deployment queued successfully", data)
+}
+
+func (h Handler) Scale(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req ScaleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.Scale(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "scale deployment queued successfully", data)
+}
+
+func (h Handler) Pause(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.Pause(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "pause deployment queued successfully", data)
+}
+
+func (h Handler) Resume(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.Resume(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "resume deployment queued successfully", data)
+}
+
+func (h Handler) Status(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.Status(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved deployment status", data)
+}
+
+func (h Handler) SetIdleTimeout(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req IdleTimeoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.SetIdleTimeout(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to update idle timeout", data)
+}
+
+// Wake - Halaman "starting" untuk workspace yang di-park (tanpa auth, diakses lewat Ingress workspace)
+func (h Handler) Wake(c *fiber.Ctx) error {
+	data, err := h.s.Wake(c.Context(), c.Hostname())
+	if err != nil {
+		status := determineStatusCode(err)
+		c.Status(status)
+		return renderWakePage(c, &WakeStatus{Status: http.StatusText(status)})
+	}
+
+	// Retry-After untuk client non-browser
+	c.Set(fiber.HeaderRetryAfter, "5")
+	if data.Paused {
+		c.Status(http.StatusServiceUnavailable)
+	} else {
+		c.Status(http.StatusAccepted)
+	}
+	return renderWakePage(c, data)
+}
+
+// Activity - Mirror request dari Ingress workspace, hanya mencatat last activity
+func (h Handler) Activity(c *fiber.Ctx) error {
+	if err := h.s.RecordActivity(c.Context(), c.Hostname()); err != nil {
+		return c.SendStatus(determineStatusCode(err))
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// WakeAuth - Endpoint wake hanya untuk Ingress workspace: token dari config dikirim lewat
+// header atau query (ingress-nginx tidak bisa menambah header per Ingress tanpa snippet)
+func (h Handler) WakeAuth(c *fiber.Ctx) error {
+	expected := h.s.cfg.Workspace.WakeToken
+	token := c.Get(wakeTokenHeader)
+	if token == "" {
+		token = c.Query(wakeTokenQuery)
+	}
+
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+	return c.Next()
+}
+
+func (h Handler) ListAddons(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListAddons(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved addons", data)
+}
+
+func (h Handler) AttachAddon(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req AttachAddonRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.AttachAddon(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "attach addon queued successfully", data)
+}
+
+func (h Handler) DetachAddon(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.DetachAddon(c.Context(), id, userId, c.Params("type"))
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "detach addon queued successfully", data)
+}
+
+func (h Handler) Quota(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	data, err := h.s.Quota(c.Context(), userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved quota", data)
+}
+
+func (h Handler) ListEgressRules(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	data, err := h.s.ListEgressRules(c.Context(), userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved egress rules", data)
+}
+
+func (h Handler) AddEgressRule(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	var req EgressRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.AddEgressRule(c.Context(), userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusCreated, "successfully to add egress rule", data)
+}
+
+func (h Handler) DeleteEgressRule(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	ruleId, err := strconv.Atoi(c.Params("ruleId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "rule id must be number")
+	}
+
+	if err := h.s.DeleteEgressRule(c.Context(), userId, ruleId); err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to delete egress rule", nil)
+}
+
+func (h Handler) ListDomains(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListDomains(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved domains", data)
+}
+
+func (h Handler) AddDomain(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req DomainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.AddDomain(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusCreated, "successfully to add domain", data)
+}
+
+func (h Handler) VerifyDomain(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	domainId, err := strconv.Atoi(c.Params("domainId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "domain id must be number")
+	}
+
+	data, err := h.s.VerifyDomain(c.Context(), id, userId, domainId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to verify domain", data)
+}
+
+func (h Handler) DeleteDomain(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	domainId, err := strconv.Atoi(c.Params("domainId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "domain id must be number")
+	}
+
+	if err := h.s.DeleteDomain(c.Context(), id, userId, domainId); err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to delete domain", nil)
+}
+
+func (h Handler) RenameSubdomain(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req SubdomainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.RenameSubdomain(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to rename subdomain", data)
+}
+
+func (h Handler) TLSStatus(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.TLSStatus(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved tls status", data)
+}
+
+func (h Handler) StartBuild(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req BuildRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.Error(c, http.StatusBadRequest, "invalid body request")
+		}
+	}
+
+	data, err := h.s.StartBuild(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "build queued successfully", data)
+}
+
+func (h Handler) ListBuilds(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListBuilds(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved builds", data)
+}
+
+func (h Handler) GetBuild(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	buildId, err := strconv.Atoi(c.Params("buildId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "build id must be number")
+	}
+
+	data, err := h.s.GetBuild(c.Context(), id, userId, buildId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved build", data)
+}
+
+// BuildLogs - SSE: event "log" per baris, "status" selama menunggu pod, "result" di akhir
+func (h Handler) BuildLogs(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	buildId, err := strconv.Atoi(c.Params("buildId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "build id must be number")
+	}
+
+	build, err := h.s.GetBuild(c.Context(), id, userId, buildId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Stream(c, func(ctx context.Context, events *response.EventWriter) {
+		if err := h.s.StreamBuildLogs(ctx, build, events); err != nil && ctx.Err() == nil {
+			events.Send(BuildEventError, fiber.Map{"message": err.Error()})
+		}
+	})
+}
+
+// ReceiveWebhook - Push dari GitHub, GitLab atau Gitea, provider dideteksi dari header
+func (h Handler) ReceiveWebhook(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	// Gitea juga mengirim header X-GitHub-*, jadi dicek lebih dulu
+	req := WebhookRequest{Body: c.Body()}
+	switch {
+	case c.Get("X-Gitea-Event") != "":
+		req.Provider = WebhookGitea
+		req.Event = c.Get("X-Gitea-Event")
+		req.DeliveryId = c.Get("X-Gitea-Delivery")
+		req.Signature = c.Get("X-Gitea-Signature")
+	case c.Get("X-Gitlab-Event") != "":
+		req.Provider = WebhookGitLab
+		req.Event = c.Get("X-Gitlab-Event")
+		req.DeliveryId = c.Get("X-Gitlab-Event-UUID")
+		req.Signature = c.Get("X-Gitlab-Token")
+	case c.Get("X-GitHub-Event") != "":
+		req.Provider = WebhookGitHub
+		req.Event = c.Get("X-GitHub-Event")
+		req.DeliveryId = c.Get("X-GitHub-Delivery")
+		req.Signature = c.Get("X-Hub-Signature-256")
+	}
+
+	data, err := h.s.ReceiveWebhook(c.Context(), id, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "webhook received successfully", data)
+}
+
+func (h Handler) GetWebhook(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.GetWebhook(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved webhook", data)
+}
+
+func (h Handler) RotateWebhook(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.RotateWebhook(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to generate webhook secret", data)
+}
+
+func (h Handler) DeleteWebhook(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	if err := h.s.DeleteWebhook(c.Context(), id, userId); err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to delete webhook", nil)
+}
+
+func (h Handler) ListDeliveries(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListDeliveries(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved webhook deliveries", data)
+}
+
+func (h Handler) Redeliver(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	deliveryId, err := strconv.Atoi(c.Params("deliveryId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "delivery id must be number")
+	}
+
+	data, err := h.s.Redeliver(c.Context(), id, userId, deliveryId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to redeliver webhook", data)
+}
+
+func (h Handler) CreatePreview(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req PreviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid body request")
+	}
+
+	data, err := h.s.CreatePreview(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "preview queued successfully", data)
+}
+
+func (h Handler) ListPreviews(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListPreviews(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved list previews", data)
+}
+
+func (h Handler) DeletePreview(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	previewId, err := strconv.Atoi(c.Params("previewId"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "preview id must be number")
+	}
+
+	data, err := h.s.DeletePreview(c.Context(), id, userId, previewId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusAccepted, "delete preview queued successfully", data)
+}
+
+func (h Handler) ListPods(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.ListPods(c.Context(), id, userId)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved list pods", data)
+}
+
+// PodLogs - SSE, log semua replica (atau ?pod=) dengan follow, tailLines, sinceSeconds,
+// container dan previous
+func (h Handler) PodLogs(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	var req PodLogRequest
+	if err := c.QueryParser(&req); err != nil {
+		return response.Error(c, http.StatusBadRequest, "invalid query params")
+	}
+
+	stream, err := h.s.PodLogs(c.Context(), id, userId, req)
+	if err != nil {
+		return response.Error(c, determineStatusCode(err), err.Error())
+	}
+
+	return response.Stream(c, func(ctx context.Context, events *response.EventWriter) {
+		if err := h.s.StreamPodLogs(ctx, stream, events); err != nil && ctx.Err() == nil {
+			events.Send(PodEventError, fiber.Map{"message": err.Error()})
+		}
+	})
+}
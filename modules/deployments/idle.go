@@ -0,0 +1,297 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+)
+
+const (
+	// Path backend yang melayani halaman "starting" untuk workspace yang tidur
+	wakePath     = "/api/v1/wake"
+	activityPath = "/api/v1/wake/activity"
+
+	rewriteAnnotation      = "nginx.ingress.kubernetes.io/rewrite-target"
+	mirrorTargetAnnotation = "nginx.ingress.kubernetes.io/mirror-target"
+	mirrorHostAnnotation   = "nginx.ingress.kubernetes.io/mirror-host"
+
+	defaultIdleCheckInterval = 60 * time.Second
+
+	wakeTokenQuery  = "token"
+	wakeTokenHeader = "X-Wake-Token"
+)
+
+// SetIdleTimeout - Ubah idle timeout (0 = tidak pernah di-park)
+func (s Service) SetIdleTimeout(c context.Context, id, userId int, req IdleTimeoutRequest) (*Deployment, error) {
+	if err := validateIdleTimeout(req.Minutes); err != nil {
+		return nil, err
+	}
+
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetIdleTimeout(c, data.Id, req.Minutes); err != nil {
+		return nil, err
+	}
+	data.IdleTimeoutMinutes = req.Minutes
+
+	// Ingress workspace yang tidur sedang mengarah ke wake service, mirror di-set lagi saat bangun
+	if !data.Sleeping && data.Status != StatusPending && data.Status != StatusDeleting && data.Status != StatusDeleted {
+		if err := s.syncActivityMirror(c, data); err != nil {
+			log.Printf("failed to update activity mirror for deployment %d: %s", data.Id, err.Error())
+		}
+	}
+
+	return data, nil
+}
+
+// Wake - Dipanggil oleh Ingress workspace yang sedang tidur. Request pertama
+// membangunkan workspace, request berikutnya hanya melihat progress.
+func (s Service) Wake(c context.Context, host string) (*WakeStatus, error) {
+	data, err := s.repo.FindByHost(c, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.TouchActivity(c, data.Id); err != nil {
+		log.Printf("failed to touch activity for deployment %d: %s", data.Id, err.Error())
+	}
+
+	result := &WakeStatus{
+		Name:     data.Name,
+		Status:   data.Status,
+		Replicas: int32(data.Replicas),
+	}
+
+	if data.Status == StatusPausing {
+		result.Paused = true
+		return result, nil
+	}
+
+	if data.Status == StatusStopped {
+		// Di-pause oleh user, bukan karena idle: jangan dibangunkan
+		if !data.Sleeping {
+			result.Paused = true
+			return result, nil
+		}
+
+		reason := "woken by incoming request"
+		if err := s.transition(c, data, StatusUpdating, actorSystem, reason); err != nil {
+			return nil, err
+		}
+		if _, err := s.enqueue(c, JobResume, data, nil); err != nil {
+			s.markFailed(c, data, err)
+			return nil, err
+		}
+		result.Status = data.Status
+		return result, nil
+	}
+
+	if status, err := s.k8s.GetDeploymentStatus(c, data.Namespace, data.DeploymentName); err == nil {
+		result.ReadyReplicas = status.ReadyReplicas
+		result.IsReady = status.IsReady && status.ReadyReplicas > 0
+	}
+
+	return result, nil
+}
+
+// RecordActivity - Dipanggil lewat mirror request Ingress setiap ada traffic
+func (s Service) RecordActivity(c context.Context, host string) error {
+	data, err := s.repo.FindByHost(c, host)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.TouchActivity(c, data.Id)
+}
+
+// parkIdleDeployments - Periodic task: enqueue JobSleep untuk workspace yang idle
+func (s Service) parkIdleDeployments(c context.Context) error {
+	idle, err := s.repo.ListIdle(c)
+	if err != nil {
+		return err
+	}
+
+	for i := range idle {
+		data := &idle[i]
+
+		// Ditandai dulu supaya tick berikutnya tidak enqueue job yang sama
+		if err := s.repo.SetSleeping(c, data.Id, true); err != nil {
+			log.Printf("failed to mark deployment %d sleeping: %s", data.Id, err.Error())
+			continue
+		}
+
+		if _, err := s.enqueue(c, JobSleep, data, nil); err != nil {
+			log.Printf("failed to enqueue sleep for deployment %d: %s", data.Id, err.Error())
+			if err := s.repo.SetSleeping(c, data.Id, false); err != nil {
+				log.Printf("failed to unmark deployment %d sleeping: %s", data.Id, err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// handleSleep - Arahkan Ingress ke wake service lalu scale ke 0
+func (s Service) handleSleep(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	// Ada request masuk / status berubah sejak job di-enqueue
+	if data.Status != StatusRunning || !data.Sleeping {
+		report(fmt.Sprintf("skipped: deployment is %s", data.Status))
+		if data.Sleeping {
+			return s.repo.SetSleeping(c, data.Id, false)
+		}
+		return nil
+	}
+
+	if err := s.park(c, data, report); err != nil {
+		if job.IsLastAttempt() {
+			s.unpark(context.WithoutCancel(c), data)
+		}
+		return err
+	}
+
+	reason := fmt.Sprintf("idle for %d minutes", data.IdleTimeoutMinutes)
+	if err := s.transition(c, data, StatusStopped, actorSystem, reason); err != nil {
+		s.unpark(context.WithoutCancel(c), data)
+		return jobs.Permanent(err)
+	}
+	return nil
+}
+
+func (s Service) park(c context.Context, data *Deployment, report jobs.ProgressFunc) error {
+	host, port := s.wakeService()
+	if host == "" {
+		return jobs.Permanent(fmt.Errorf("wake service or wake token is not configured"))
+	}
+
+	exists, err := s.k8s.ServiceExists(c, data.Namespace, wakeServiceName(data))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := s.k8s.CreateExternalNameService(c, data.Namespace, wakeServiceName(data), host, port); err != nil {
+			return err
+		}
+	}
+
+	report("routing ingress to wake service")
+	err = s.k8s.UpdateIngressBackend(c, data.Namespace, data.IngressName, wakeServiceName(data), port, map[string]string{
+		rewriteAnnotation:      s.wakeURL(wakePath),
+		mirrorTargetAnnotation: "",
+		mirrorHostAnnotation:   "",
+	})
+	if err != nil {
+		return err
+	}
+
+	report("scaling to 0 replicas")
+	if err := s.k8s.ScaleDeployment(c, data.Namespace, data.DeploymentName, 0); err != nil {
+		return err
+	}
+
+	report("stopping database and addons")
+	return s.scaleBackingServices(c, data, 0)
+}
+
+// unpark - Kembalikan Ingress ke Service workspace; error hanya di-log
+func (s Service) unpark(c context.Context, data *Deployment) {
+	// rewrite-target kembali ke nilai dari config/template ("" = dihapus)
+	err := s.k8s.UpdateIngressBackend(c, data.Namespace, data.IngressName, data.ServiceName, servicePort, map[string]string{
+		rewriteAnnotation: s.ingressAnnotations(data)[rewriteAnnotation],
+	})
+	if err != nil {
+		log.Printf("failed to restore ingress for deployment %d: %s", data.Id, err.Error())
+		return
+	}
+
+	if err := s.syncActivityMirror(c, data); err != nil {
+		log.Printf("failed to restore activity mirror for deployment %d: %s", data.Id, err.Error())
+	}
+
+	if data.Status != StatusStopped {
+		if err := s.scaleBackingServices(c, data, 1); err != nil {
+			log.Printf("failed to restore database and addons for deployment %d: %s", data.Id, err.Error())
+		}
+		if err := s.k8s.ScaleDeployment(c, data.Namespace, data.DeploymentName, int32(data.Replicas)); err != nil {
+			log.Printf("failed to restore replicas for deployment %d: %s", data.Id, err.Error())
+		}
+	}
+
+	if err := s.repo.SetSleeping(c, data.Id, false); err != nil {
+		log.Printf("failed to unmark deployment %d sleeping: %s", data.Id, err.Error())
+		return
+	}
+	data.Sleeping = false
+
+	if err := s.repo.TouchActivity(c, data.Id); err != nil {
+		log.Printf("failed to touch activity for deployment %d: %s", data.Id, err.Error())
+	}
+}
+
+// syncActivityMirror - Mirror setiap request ke backend supaya last activity tercatat.
+// Hanya dipasang kalau workspace punya idle timeout.
+func (s Service) syncActivityMirror(c context.Context, data *Deployment) error {
+	host, port := s.wakeService()
+	if host == "" {
+		return nil
+	}
+
+	annotations := map[string]string{
+		mirrorTargetAnnotation: "",
+		mirrorHostAnnotation:   "",
+	}
+	if data.IdleTimeoutMinutes > 0 {
+		annotations[mirrorTargetAnnotation] = fmt.Sprintf("http://%s:%d%s", host, port, s.wakeURL(activityPath))
+		annotations[mirrorHostAnnotation] = data.Host
+	}
+
+	return s.k8s.UpdateIngressAnnotations(c, data.Namespace, data.IngressName, annotations)
+}
+
+func (s Service) deleteWakeService(c context.Context, data *Deployment) error {
+	exists, err := s.k8s.ServiceExists(c, data.Namespace, wakeServiceName(data))
+	if err != nil || !exists {
+		return err
+	}
+
+	return s.k8s.DeleteService(c, data.Namespace, wakeServiceName(data))
+}
+
+// wakeService - Host "" kalau wake service atau token-nya tidak dikonfigurasi
+func (s Service) wakeService() (string, int) {
+	port := s.cfg.Workspace.WakeServicePort
+	if port <= 0 {
+		port = 80
+	}
+	if s.cfg.Workspace.WakeToken == "" {
+		return "", port
+	}
+	return s.cfg.Workspace.WakeServiceHost, port
+}
+
+// wakeURL - Path endpoint wake beserta token, dipasang di annotation Ingress workspace
+func (s Service) wakeURL(path string) string {
+	return path + "?" + url.Values{wakeTokenQuery: {s.cfg.Workspace.WakeToken}}.Encode()
+}
+
+func (s Service) idleCheckInterval() time.Duration {
+	if s.cfg.Workspace.IdleCheckIntervalSeconds <= 0 {
+		return defaultIdleCheckInterval
+	}
+	return time.Duration(s.cfg.Workspace.IdleCheckIntervalSeconds) * time.Second
+}
+
+func wakeServiceName(data *Deployment) string {
+	return fmt.Sprintf("%s-wake", data.AppName)
+}
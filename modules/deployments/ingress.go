@@ -0,0 +1,40 @@
+package deployments
+
+import (
+	"log"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+// ingressConfig - Ingress workspace dari config ingress + annotations template
+func (s Service) ingressConfig(data *Deployment) *k8s.IngressConfig {
+	return &k8s.IngressConfig{
+		Name:          data.IngressName,
+		Namespace:     data.Namespace,
+		Host:          data.Host,
+		ServiceName:   data.ServiceName,
+		ServicePort:   servicePort,
+		ClassName:     s.cfg.Ingress.ClassName,
+		TLS:           s.cfg.Ingress.TLS,
+		ClusterIssuer: s.cfg.Ingress.ClusterIssuer,
+		Annotations:   s.ingressAnnotations(data),
+	}
+}
+
+// ingressAnnotations - Default dari config, di-override annotations template
+// (value "" = annotation default tidak dipasang)
+func (s Service) ingressAnnotations(data *Deployment) map[string]string {
+	annotations := map[string]string{}
+	for _, annotation := range s.cfg.Ingress.DefaultAnnotations {
+		annotations[annotation.Key] = annotation.Value
+	}
+	for key, value := range data.IngressAnnotations {
+		if !templates.IsAllowedIngressAnnotation(key) {
+			log.Printf("skipping ingress annotation %s on deployment %d: not allowed", key, data.Id)
+			continue
+		}
+		annotations[key] = value
+	}
+	return annotations
+}
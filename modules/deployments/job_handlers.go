@@ -0,0 +1,402 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+)
+
+const (
+	JobProvision = "deployment.provision"
+	JobUpdate    = "deployment.update"
+	JobPause     = "deployment.pause"
+	JobResume    = "deployment.resume"
+	JobDelete    = "deployment.delete"
+	JobSleep     = "deployment.sleep"
+)
+
+// mutatingJobs - Job yang mengubah resource workspace di cluster, Delete menunggu semuanya selesai.
+// Build tidak termasuk: Job builder dihapus saat teardown dan handleBuild berhenti sendiri.
+var mutatingJobs = []string{JobProvision, JobUpdate, JobPause, JobResume, JobSleep, JobAttachAddon, JobDetachAddon}
+
+// expiredJobStatuses - Status deployment yang ditinggalkan job kalau worker-nya mati di
+// attempt terakhir, dipindah ke failed oleh handleExpiredJob
+var expiredJobStatuses = map[string][]string{
+	JobProvision: {StatusPending, StatusProvisioning},
+	JobUpdate:    {StatusUpdating},
+	JobResume:    {StatusUpdating},
+	JobPause:     {StatusPausing},
+	JobDelete:    {StatusDeleting},
+}
+
+// readyTimeoutSeconds - Batas waktu menunggu semua replica ready setelah provision
+const readyTimeoutSeconds = 300
+
+// provisionPayload - SecretName = Secret sementara berisi env secret (lihat stageSecrets)
+type provisionPayload struct {
+	SecretName string `json:"secretName,omitempty"`
+}
+
+type deletePayload struct {
+	DeleteVolumes bool `json:"deleteVolumes"`
+}
+
+type updatePayload struct {
+	Revision int `json:"revision"`
+}
+
+// RegisterJobs - Daftarkan handler job deployments ke worker pool
+func (s Service) RegisterJobs(pool *jobs.WorkerPool) {
+	pool.Register(JobProvision, s.handleProvision)
+	pool.Register(JobUpdate, s.handleUpdate)
+	pool.Register(JobPause, s.handlePause)
+	pool.Register(JobResume, s.handleResume)
+	pool.Register(JobDelete, s.handleDelete)
+	pool.Register(JobSleep, s.handleSleep)
+	pool.Register(JobAttachAddon, s.handleAttachAddon)
+	pool.Register(JobDetachAddon, s.handleDetachAddon)
+	pool.Register(JobBuild, s.handleBuild)
+
+	for _, jobType := range append(mutatingJobs, JobDelete, JobBuild) {
+		pool.OnExpired(jobType, s.handleExpiredJob)
+	}
+
+	pool.Every("deployments.idle-detector", s.idleCheckInterval(), s.parkIdleDeployments)
+	pool.Every("deployments.quota-sync", s.quotaSyncInterval(), s.syncQuotas)
+	pool.Every("deployments.domain-verify", s.domainVerifyInterval(), s.verifyPendingDomains)
+	pool.Every("deployments.preview-expiry", s.previewCheckInterval(), s.expirePreviews)
+}
+
+func (s Service) jobDeployment(c context.Context, job *jobs.Job) (*Deployment, error) {
+	if job.DeploymentId == nil || job.UserId == nil {
+		return nil, jobs.Permanent(fmt.Errorf("job %d has no deployment", job.Id))
+	}
+
+	data, err := s.repo.FindById(c, *job.DeploymentId, *job.UserId)
+	if err != nil {
+		return nil, jobs.Permanent(err)
+	}
+
+	// Job yang di-enqueue sebelum delete tidak boleh menyentuh resource yang sedang di-teardown
+	if job.Type != JobDelete && (data.Status == StatusDeleting || data.Status == StatusDeleted) {
+		return nil, jobs.Permanent(fmt.Errorf("%s: deployment %d is %s", ErrInvalidTransition, data.Id, data.Status))
+	}
+
+	return data, nil
+}
+
+// handleExpiredJob - Worker mati di attempt terakhir, handler tidak sempat menulis hasil.
+// Deployment (atau build / add-on) yang masih di status transisi dipindah ke failed supaya
+// bisa di-retry atau dihapus lagi, kecuali job lain untuk deployment itu masih berjalan.
+func (s Service) handleExpiredJob(c context.Context, job *jobs.Job) error {
+	if job.DeploymentId == nil || job.UserId == nil {
+		return nil
+	}
+	data, err := s.repo.FindById(c, *job.DeploymentId, *job.UserId)
+	if err != nil {
+		return err
+	}
+
+	message := "lease expired on the last attempt"
+	if job.LastError != nil {
+		message = *job.LastError
+	}
+	cause := fmt.Errorf("job %d (%s): %s", job.Id, job.Type, message)
+
+	switch job.Type {
+	case JobBuild:
+		var payload buildPayload
+		if err := job.DecodePayload(&payload); err != nil {
+			return err
+		}
+		build, err := s.repo.GetBuild(c, data.Id, payload.BuildId)
+		if err != nil {
+			return err
+		}
+		if buildFinished(build) {
+			return nil
+		}
+		return s.repo.FinishBuild(c, build.Id, BuildStatusFailed, cause.Error(), "")
+
+	case JobAttachAddon, JobDetachAddon:
+		var payload addonPayload
+		if err := job.DecodePayload(&payload); err != nil {
+			return err
+		}
+		addon, err := s.repo.GetAddon(c, data.Id, payload.Type)
+		if err != nil {
+			return err
+		}
+		if addon.Status != AddonStatusProvisioning && addon.Status != AddonStatusDetaching {
+			return nil
+		}
+		return s.repo.SetAddonStatus(c, addon.Id, AddonStatusFailed, cause.Error())
+	}
+
+	stuck := false
+	for _, status := range expiredJobStatuses[job.Type] {
+		stuck = stuck || data.Status == status
+	}
+	if !stuck {
+		return nil
+	}
+	// Status transisi milik job lain yang di-enqueue setelah job ini
+	active, err := s.jobs.HasActive(c, data.Id, append(mutatingJobs, JobDelete)...)
+	if err != nil || active {
+		return err
+	}
+
+	s.markFailed(c, data, cause)
+	return nil
+}
+
+func (s Service) handleProvision(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	var payload provisionPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	if err := s.transition(c, data, StatusProvisioning, actorSystem, fmt.Sprintf("job %d attempt %d", job.Id, job.Attempts)); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	secretData := map[string]string{}
+	if payload.SecretName != "" {
+		secretData, err = s.k8s.GetSecretData(c, data.Namespace, payload.SecretName)
+		if err != nil {
+			if job.IsLastAttempt() {
+				s.markFailed(c, data, err)
+			}
+			return fmt.Errorf("%s: %w", ErrProvisionFailed, err)
+		}
+	}
+
+	report("creating kubernetes resources")
+	if err := s.provision(c, data, secretData); err != nil {
+		// Resource sudah di-rollback, jadi aman untuk di-retry
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return fmt.Errorf("%s: %w", ErrProvisionFailed, err)
+	}
+
+	if payload.SecretName != "" {
+		if err := s.k8s.DeleteSecret(c, data.Namespace, payload.SecretName); err != nil {
+			log.Printf("failed to delete staged secrets of deployment %d: %s", data.Id, err.Error())
+		}
+	}
+
+	err = s.k8s.WaitForDeploymentReady(c, data.Namespace, data.DeploymentName, readyTimeoutSeconds, func(progress k8s.DeploymentProgress) {
+		report(progress.Message())
+	})
+	if err != nil {
+		// Resource tetap dibiarkan supaya user bisa lihat pod/log yang bermasalah
+		s.markFailed(c, data, err)
+		return jobs.Permanent(err)
+	}
+
+	if err := s.transition(c, data, StatusRunning, actorSystem, ""); err != nil {
+		return jobs.Permanent(err)
+	}
+	return nil
+}
+
+// handleUpdate - Apply spec yang aktif (revision terbaru) ke ConfigMap dan Deployment
+func (s Service) handleUpdate(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	var payload updatePayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	// Sudah ada revision yang lebih baru, job untuk revision itu yang akan apply
+	if payload.Revision < data.CurrentRevision {
+		report(fmt.Sprintf("revision %d superseded by revision %d", payload.Revision, data.CurrentRevision))
+		return nil
+	}
+
+	report(fmt.Sprintf("applying revision %d", data.CurrentRevision))
+	if err := s.k8s.UpdateConfigMap(c, data.Namespace, data.ConfigMapName, data.EnvVars); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+
+	// Namespace lama dibuat sebelum ada pull secret
+	if err := s.ensurePullSecret(c, data.Namespace); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+	if err := s.k8s.ApplyDeploymentConfig(c, s.deploymentConfig(data)); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+
+	return s.waitRollout(c, data, report)
+}
+
+// handlePause - Scale ke 0, Service & Ingress tetap ada
+func (s Service) handlePause(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	report("scaling to 0 replicas")
+	if err := s.k8s.ScaleDeployment(c, data.Namespace, data.DeploymentName, 0); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+
+	report("stopping database and addons")
+	if err := s.scaleBackingServices(c, data, 0); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+
+	if err := s.transition(c, data, StatusStopped, actorSystem, "paused"); err != nil {
+		return jobs.Permanent(err)
+	}
+	return nil
+}
+
+// handleResume - Scale kembali ke replica di spec lalu tunggu ready
+func (s Service) handleResume(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	// Database & add-on harus ready dulu, kalau tidak app crash loop
+	report("starting database and addons")
+	if err := s.scaleBackingServices(c, data, 1); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+
+	report(fmt.Sprintf("scaling to %d replicas", data.Replicas))
+	if err := s.k8s.ScaleDeployment(c, data.Namespace, data.DeploymentName, int32(data.Replicas)); err != nil {
+		if job.IsLastAttempt() {
+			s.markFailed(c, data, err)
+		}
+		return err
+	}
+
+	return s.waitRollout(c, data, report)
+}
+
+// scaleBackingServices - Database StatefulSet & Deployment add-on ikut di-stop saat pause / idle.
+// PVC tidak disentuh, data tetap ada. Saat start, tunggu sampai semuanya ready.
+func (s Service) scaleBackingServices(c context.Context, data *Deployment, replicas int32) error {
+	if data.DatabaseType != nil {
+		if err := s.k8s.ScaleStatefulSet(c, data.Namespace, databaseName(data), replicas); err != nil {
+			return err
+		}
+	}
+
+	addons, err := s.repo.ListAddons(c, data.Id)
+	if err != nil {
+		return err
+	}
+	for _, addon := range addons {
+		if addon.Status != AddonStatusReady {
+			continue
+		}
+		if err := s.k8s.ScaleDeployment(c, data.Namespace, addonName(data, addon.Type), replicas); err != nil {
+			return err
+		}
+	}
+
+	if replicas == 0 {
+		return nil
+	}
+
+	if data.DatabaseType != nil {
+		if err := s.k8s.WaitForStatefulSetReady(c, data.Namespace, databaseName(data), databaseReadyTimeoutSeconds); err != nil {
+			return err
+		}
+	}
+	for _, addon := range addons {
+		if addon.Status != AddonStatusReady {
+			continue
+		}
+		if err := s.k8s.WaitForDeploymentReady(c, data.Namespace, addonName(data, addon.Type), addonReadyTimeoutSeconds, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitRollout - Tunggu rollout selesai; kalau gagal deployment jadi degraded
+func (s Service) waitRollout(c context.Context, data *Deployment, report jobs.ProgressFunc) error {
+	err := s.k8s.WaitForDeploymentReady(c, data.Namespace, data.DeploymentName, readyTimeoutSeconds, func(progress k8s.DeploymentProgress) {
+		report(progress.Message())
+	})
+	if err != nil {
+		if transitionErr := s.transition(context.WithoutCancel(c), data, StatusDegraded, actorSystem, err.Error()); transitionErr != nil {
+			return jobs.Permanent(transitionErr)
+		}
+		return jobs.Permanent(err)
+	}
+
+	if err := s.transition(c, data, StatusRunning, actorSystem, ""); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	// Workspace yang tadinya di-park: Ingress baru dikembalikan setelah pod ready
+	if data.Sleeping {
+		report("routing ingress back to workspace")
+		s.unpark(c, data)
+	}
+	return nil
+}
+
+func (s Service) handleDelete(c context.Context, job *jobs.Job, report jobs.ProgressFunc) error {
+	data, err := s.jobDeployment(c, job)
+	if err != nil {
+		return err
+	}
+
+	var payload deletePayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	report("deleting kubernetes resources")
+	s.teardown(c, data, payload.DeleteVolumes)
+
+	if payload.DeleteVolumes {
+		report(fmt.Sprintf("deleting %d volumes", len(data.Volumes)))
+		s.deleteVolumes(c, data)
+	} else if len(data.Volumes) > 0 {
+		report(fmt.Sprintf("keeping %d volumes", len(data.Volumes)))
+	}
+
+	if err := s.transition(c, data, StatusDeleted, actorSystem, ""); err != nil {
+		return jobs.Permanent(err)
+	}
+	return nil
+}
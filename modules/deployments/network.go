@@ -0,0 +1,187 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+)
+
+// linkLocalCIDRs - Metadata endpoint cloud (169.254.169.254) ada di range link-local
+var linkLocalCIDRs = []string{"169.254.0.0/16", "fe80::/10"}
+
+// ListEgressRules - Egress yang dibuka untuk namespace user
+func (s Service) ListEgressRules(c context.Context, userId int) ([]EgressRule, error) {
+	return s.repo.ListEgressRules(c, userId)
+}
+
+// AddEgressRule - Simpan rule lalu sinkronkan NetworkPolicy egress namespace user
+func (s Service) AddEgressRule(c context.Context, userId int, req EgressRuleRequest) (*EgressRule, error) {
+	rule, err := s.validateEgressRule(req)
+	if err != nil {
+		return nil, err
+	}
+	rule.UserId = userId
+
+	created, err := s.repo.CreateEgressRule(c, *rule)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.syncEgressRules(c, userId); err != nil {
+		if deleteErr := s.repo.DeleteEgressRule(c, created.Id, userId); deleteErr != nil {
+			return nil, fmt.Errorf("%w (rule %d was not removed: %s)", err, created.Id, deleteErr.Error())
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// DeleteEgressRule - Hapus rule lalu sinkronkan NetworkPolicy egress namespace user
+func (s Service) DeleteEgressRule(c context.Context, userId, ruleId int) error {
+	if err := s.repo.DeleteEgressRule(c, ruleId, userId); err != nil {
+		return err
+	}
+
+	return s.syncEgressRules(c, userId)
+}
+
+// ensureNetworkIsolation - Dipanggil saat provision, setelah namespace user ada
+func (s Service) ensureNetworkIsolation(c context.Context, userId int) error {
+	if !s.cfg.Network.Isolation {
+		return nil
+	}
+
+	err := s.k8s.ApplyNamespaceIsolation(c, userNamespace(userId), k8s.NetworkIsolationConfig{
+		IngressNamespace: s.cfg.Network.IngressNamespace,
+		DNSNamespace:     s.cfg.Network.DNSNamespace,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.syncEgressRules(c, userId)
+}
+
+// syncEgressRules - Namespace yang belum ada di-skip, rules di-apply saat provision pertama
+func (s Service) syncEgressRules(c context.Context, userId int) error {
+	if !s.cfg.Network.Isolation {
+		return nil
+	}
+
+	namespace := userNamespace(userId)
+	exists, err := s.k8s.NamespaceExists(c, namespace)
+	if err != nil || !exists {
+		return err
+	}
+
+	rules, err := s.repo.ListEgressRules(c, userId)
+	if err != nil {
+		return err
+	}
+
+	egress := make([]k8s.EgressRule, 0, len(rules))
+	for _, rule := range rules {
+		// Rule lama yang sekarang masuk network yang diblok (config berubah) tidak di-apply
+		if _, err := s.validateEgressRule(EgressRuleRequest{CIDR: rule.CIDR, Protocol: rule.Protocol}); err != nil {
+			log.Printf("skipping egress rule %d for user %d: %s", rule.Id, userId, err.Error())
+			continue
+		}
+
+		item := k8s.EgressRule{
+			CIDR:     rule.CIDR,
+			Except:   s.clusterExcept(rule.CIDR),
+			Protocol: rule.Protocol,
+		}
+		if rule.Port != nil {
+			item.Port = int32(*rule.Port)
+		}
+		egress = append(egress, item)
+	}
+
+	return s.k8s.ApplyEgressRules(c, namespace, egress)
+}
+
+// validateEgressRule - CIDR dinormalisasi; tujuan di dalam CIDR cluster, node, control plane,
+// atau link-local ditolak supaya egress rule tidak bisa dipakai untuk menjangkau namespace
+// user lain, kubelet, API server, atau metadata cloud
+func (s Service) validateEgressRule(req EgressRuleRequest) (*EgressRule, error) {
+	_, network, err := net.ParseCIDR(strings.TrimSpace(req.CIDR))
+	if err != nil {
+		return nil, fmt.Errorf("%s: cidr %q must be like 203.0.113.0/24", ErrInvalidEgress, req.CIDR)
+	}
+
+	for _, blocked := range s.blockedNetworks() {
+		if containsNetwork(blocked, network) {
+			return nil, fmt.Errorf("%s: %s is inside blocked network %s", ErrInvalidEgress, network.String(), blocked.String())
+		}
+	}
+
+	protocol := strings.ToUpper(req.Protocol)
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	if protocol != "TCP" && protocol != "UDP" {
+		return nil, fmt.Errorf("%s: protocol must be TCP or UDP", ErrInvalidEgress)
+	}
+
+	if req.Port != nil && (*req.Port < 1 || *req.Port > 65535) {
+		return nil, fmt.Errorf("%s: port %d is out of range", ErrInvalidEgress, *req.Port)
+	}
+
+	rule := &EgressRule{
+		CIDR:     network.String(),
+		Port:     req.Port,
+		Protocol: protocol,
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		rule.Description = &description
+	}
+	return rule, nil
+}
+
+// clusterExcept - Network yang diblok dan ada di dalam CIDR rule (e.g., 0.0.0.0/0) tetap diblok
+func (s Service) clusterExcept(cidr string) []string {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil
+	}
+
+	var except []string
+	for _, blocked := range s.blockedNetworks() {
+		if containsNetwork(network, blocked) {
+			except = append(except, blocked.String())
+		}
+	}
+	return except
+}
+
+// blockedNetworks - Link-local (metadata cloud) selalu diblok, ditambah CIDR dari config.
+// CIDR config yang tidak valid dilewati.
+func (s Service) blockedNetworks() []*net.IPNet {
+	cidrs := append([]string{}, linkLocalCIDRs...)
+	cidrs = append(cidrs, s.cfg.Network.ClusterCIDRs...)
+	cidrs = append(cidrs, s.cfg.Network.NodeCIDRs...)
+	cidrs = append(cidrs, s.cfg.Network.ControlPlaneCIDRs...)
+
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// containsNetwork - inner sepenuhnya berada di dalam outer (family IP harus sama)
+func containsNetwork(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
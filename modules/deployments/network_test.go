@@ -0,0 +1,109 @@
+package deployments
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+// testNetworkService - CIDR config yang tidak valid ("not-a-cidr") harus dilewati
+func testNetworkService() Service {
+	cfg := config.Config{}
+	cfg.Network.ClusterCIDRs = []string{"10.0.0.0/8", "not-a-cidr"}
+	cfg.Network.NodeCIDRs = []string{"192.168.0.0/16"}
+	cfg.Network.ControlPlaneCIDRs = []string{"172.16.0.10/32"}
+	return NewService(nil, nil, jobs.Service{}, nil, cfg)
+}
+
+func TestValidateEgressRule(t *testing.T) {
+	port := func(p int) *int { return &p }
+
+	tests := []struct {
+		name     string
+		req      EgressRuleRequest
+		wantCIDR string
+		wantErr  string
+	}{
+		{name: "public network is normalized", req: EgressRuleRequest{CIDR: " 203.0.113.7/24 "}, wantCIDR: "203.0.113.0/24"},
+		{name: "whole internet is allowed", req: EgressRuleRequest{CIDR: "0.0.0.0/0", Port: port(443)}, wantCIDR: "0.0.0.0/0"},
+		{name: "ipv6 against ipv4 blocked ranges", req: EgressRuleRequest{CIDR: "2001:db8::/32"}, wantCIDR: "2001:db8::/32"},
+		{name: "metadata endpoint", req: EgressRuleRequest{CIDR: "169.254.169.254/32"}, wantErr: "inside blocked network 169.254.0.0/16"},
+		{name: "ipv6 link-local", req: EgressRuleRequest{CIDR: "fe80::1/128"}, wantErr: "inside blocked network fe80::/10"},
+		{name: "pod network", req: EgressRuleRequest{CIDR: "10.1.2.0/24"}, wantErr: "inside blocked network 10.0.0.0/8"},
+		{name: "control plane", req: EgressRuleRequest{CIDR: "172.16.0.10/32"}, wantErr: "inside blocked network 172.16.0.10/32"},
+		{name: "invalid cidr", req: EgressRuleRequest{CIDR: "10.0.0.1"}, wantErr: "must be like"},
+		{name: "invalid protocol", req: EgressRuleRequest{CIDR: "203.0.113.0/24", Protocol: "icmp"}, wantErr: "protocol must be TCP or UDP"},
+		{name: "port out of range", req: EgressRuleRequest{CIDR: "203.0.113.0/24", Port: port(70000)}, wantErr: "out of range"},
+	}
+
+	service := testNetworkService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := service.validateEgressRule(tt.req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), string(ErrInvalidEgress)) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected %s (%s), got %v", ErrInvalidEgress, tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected rule to be valid, got %v", err)
+			}
+			if rule.CIDR != tt.wantCIDR || rule.Protocol != "TCP" {
+				t.Fatalf("expected %s TCP, got %s %s", tt.wantCIDR, rule.CIDR, rule.Protocol)
+			}
+		})
+	}
+}
+
+func TestClusterExcept(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want []string
+	}{
+		{cidr: "0.0.0.0/0", want: []string{"169.254.0.0/16", "10.0.0.0/8", "192.168.0.0/16", "172.16.0.10/32"}},
+		{cidr: "::/0", want: []string{"fe80::/10"}},
+		{cidr: "172.16.0.0/12", want: []string{"172.16.0.10/32"}},
+		{cidr: "203.0.113.0/24"},
+		{cidr: "not-a-cidr"},
+	}
+
+	service := testNetworkService()
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			got := service.clusterExcept(tt.cidr)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("expected except %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContainsNetwork(t *testing.T) {
+	tests := []struct {
+		outer, inner string
+		want         bool
+	}{
+		{outer: "10.0.0.0/8", inner: "10.1.0.0/16", want: true},
+		{outer: "10.0.0.0/8", inner: "10.0.0.0/8", want: true},
+		{outer: "10.1.0.0/16", inner: "10.0.0.0/8"},
+		{outer: "10.0.0.0/8", inner: "192.168.0.0/16"},
+		{outer: "::/0", inner: "10.0.0.0/8"},
+		{outer: "0.0.0.0/0", inner: "2001:db8::/32"},
+		{outer: "fe80::/10", inner: "fe80::1/128", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.outer+" "+tt.inner, func(t *testing.T) {
+			_, outer, _ := net.ParseCIDR(tt.outer)
+			_, inner, _ := net.ParseCIDR(tt.inner)
+			if got := containsNetwork(outer, inner); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+)
+
+const (
+	PodEventPods  = "pods"
+	PodEventLog   = "log"
+	PodEventError = "error"
+	PodEventEnd   = "end"
+)
+
+const (
+	// defaultPodLogTailLines - Dipakai kalau tailLines & sinceSeconds tidak diisi
+	defaultPodLogTailLines = 500
+	maxPodLogTailLines     = 10000
+
+	podLogKeepAlive = 15 * time.Second
+)
+
+// PodLogStream - Pod & opsi log yang sudah divalidasi, dibuat sebelum response SSE dibuka
+// supaya error masih bisa dikembalikan sebagai JSON
+type PodLogStream struct {
+	Deployment *Deployment
+	Pods       []k8s.PodInfo
+	Options    k8s.LogOptions
+}
+
+// PodLogError - Gagal baca log satu pod tidak menghentikan stream pod lain
+type PodLogError struct {
+	Pod     string `json:"pod"`
+	Message string `json:"message"`
+}
+
+// ListPods - Replica workspace (label app=<appName>) beserta nama container-nya
+func (s Service) ListPods(c context.Context, id, userId int) ([]k8s.PodInfo, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if data.Status == StatusPending || data.Status == StatusDeleted {
+		return []k8s.PodInfo{}, nil
+	}
+
+	return s.k8s.ListAppPods(c, data.Namespace, data.AppName)
+}
+
+func (s Service) PodLogs(c context.Context, id, userId int, req PodLogRequest) (*PodLogStream, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if data.Status == StatusPending || data.Status == StatusDeleted {
+		return nil, fmt.Errorf("%s: deployment %s is %s", ErrPodsMissing, data.Name, data.Status)
+	}
+
+	opts, err := podLogOptions(data, req)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := s.k8s.ListAppPods(c, data.Namespace, data.AppName)
+	if err != nil {
+		return nil, err
+	}
+	if pod := strings.TrimSpace(req.Pod); pod != "" {
+		pods = filterPods(pods, pod)
+		if len(pods) == 0 {
+			return nil, fmt.Errorf("%s: pod %s", ErrPodsMissing, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("%s: deployment %s has no running replicas", ErrPodsMissing, data.Name)
+	}
+
+	found := false
+	for _, pod := range pods {
+		if pod.HasContainer(opts.Container) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: container %q not found, available: %s",
+			ErrInvalidLogs, opts.Container, strings.Join(pods[0].Containers, ", "))
+	}
+
+	return &PodLogStream{Deployment: data, Pods: pods, Options: opts}, nil
+}
+
+// StreamPodLogs - Log semua replica di-multiplex dalam satu stream, setiap event "log"
+// berisi nama pod. Event pertama "pods", event terakhir "end" setelah semua stream selesai
+// (tanpa follow, atau saat container berhenti). Pod baru hasil rollout tidak ikut di-follow.
+func (s Service) StreamPodLogs(ctx context.Context, stream *PodLogStream, events EventSender) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sender := &syncSender{events: events}
+	send := sender.Send
+
+	if err := send(PodEventPods, stream.Pods); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, pod := range stream.Pods {
+		if !pod.HasContainer(stream.Options.Container) {
+			message := fmt.Sprintf("pod has no container %q", stream.Options.Container)
+			if err := send(PodEventError, PodLogError{Pod: pod.Name, Message: message}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(pod string) {
+			defer wg.Done()
+
+			err := s.k8s.StreamPodLogs(ctx, stream.Deployment.Namespace, pod, stream.Options, func(line k8s.LogLine) error {
+				return send(PodEventLog, line)
+			})
+			if err != nil && ctx.Err() == nil {
+				if err := send(PodEventError, PodLogError{Pod: pod, Message: err.Error()}); err != nil {
+					cancel()
+				}
+			}
+		}(pod.Name)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Follow bisa lama tanpa log baru, proxy menutup koneksi yang idle
+	ticker := time.NewTicker(podLogKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return send(PodEventEnd, struct{}{})
+		case <-ticker.C:
+			if err := sender.Comment("keep-alive"); err != nil {
+				cancel()
+			}
+		}
+	}
+}
+
+// syncSender - EventSender yang aman dipakai dari beberapa goroutine (stream log + keep-alive)
+type syncSender struct {
+	mu     sync.Mutex
+	events EventSender
+}
+
+func (s *syncSender) Send(event string, data interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events.Send(event, data)
+}
+
+func (s *syncSender) Comment(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.events.Comment(text)
+}
+
+// podLogOptions - previous=true membaca container sebelum restart, tidak bisa di-follow
+func podLogOptions(data *Deployment, req PodLogRequest) (k8s.LogOptions, error) {
+	if req.TailLines < 0 || req.TailLines > maxPodLogTailLines {
+		return k8s.LogOptions{}, fmt.Errorf("%s: tailLines must be between 0 and %d", ErrInvalidLogs, maxPodLogTailLines)
+	}
+	if req.SinceSeconds < 0 {
+		return k8s.LogOptions{}, fmt.Errorf("%s: sinceSeconds must be positive", ErrInvalidLogs)
+	}
+
+	opts := k8s.LogOptions{
+		Container: valueOr(strings.TrimSpace(req.Container), data.AppName),
+		Follow:    req.Follow && !req.Previous,
+		Previous:  req.Previous,
+	}
+
+	tailLines := req.TailLines
+	if tailLines == 0 && req.SinceSeconds == 0 {
+		tailLines = defaultPodLogTailLines
+	}
+	if tailLines > 0 {
+		opts.TailLines = &tailLines
+	}
+	if req.SinceSeconds > 0 {
+		sinceSeconds := req.SinceSeconds
+		opts.SinceSeconds = &sinceSeconds
+	}
+	return opts, nil
+}
+
+func filterPods(pods []k8s.PodInfo, name string) []k8s.PodInfo {
+	for _, pod := range pods {
+		if pod.Name == name {
+			return []k8s.PodInfo{pod}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package deployments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+const BuildTriggerPreview = "preview"
+
+const (
+	defaultPreviewTTLHours       = 72
+	defaultPreviewCheckInterval  = 5 * time.Minute
+	maxPreviewSuffix             = 20
+	previewHashLength            = 6
+	previewReason                = "preview environment removed"
+	previewExpiredReason         = "preview environment expired"
+	previewParentDeletedReason   = "parent deployment deleted"
+	previewPullRequestDoneReason = "pull request closed"
+)
+
+// CreatePreview - Salinan workspace untuk satu branch: resource K8s dengan suffix nama
+// dan subdomain sendiri, image di-build dari branch lalu di-roll out otomatis
+func (s Service) CreatePreview(c context.Context, id, userId int, req PreviewRequest) (*PreviewJob, error) {
+	parent, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createPreview(c, parent, req, userActor(userId))
+}
+
+func (s Service) ListPreviews(c context.Context, id, userId int) ([]Deployment, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListPreviews(c, id)
+}
+
+// DeletePreview - Preview selalu dihapus beserta volume-nya
+func (s Service) DeletePreview(c context.Context, id, userId, previewId int) (*DeploymentJob, error) {
+	preview, err := s.repo.FindById(c, previewId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if preview.ParentId == nil || *preview.ParentId != id {
+		return nil, fmt.Errorf("%s: preview %d", ErrDeploymentMissing, previewId)
+	}
+
+	return s.destroyPreview(c, preview, userActor(userId), previewReason)
+}
+
+func (s Service) createPreview(c context.Context, parent *Deployment, req PreviewRequest, actor string) (*PreviewJob, error) {
+	if parent.ParentId != nil {
+		return nil, fmt.Errorf("%s: %s is already a preview environment", ErrInvalidPreview, parent.Name)
+	}
+	if parent.Status == StatusDeleting || parent.Status == StatusDeleted {
+		return nil, fmt.Errorf("%s: deployment %s is %s", ErrInvalidPreview, parent.Name, parent.Status)
+	}
+	if !s.cfg.Builds.Enabled {
+		return nil, fmt.Errorf("%s", ErrBuildsDisabled)
+	}
+	if s.cfg.Ingress.BaseDomain == "" {
+		return nil, fmt.Errorf("%s: ingress base domain is not configured", ErrInvalidPreview)
+	}
+
+	tmpl, err := s.templates.FindById(c, parent.TemplateId)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.GitRepoURL == "" {
+		return nil, fmt.Errorf("%s: %s", ErrNoGitRepo, tmpl.Name)
+	}
+
+	branch := strings.TrimSpace(req.Branch)
+	if !gitRefRegex.MatchString(branch) || strings.Contains(branch, "..") {
+		return nil, fmt.Errorf("%s: branch %q", ErrInvalidPreview, req.Branch)
+	}
+	if req.PullRequest < 0 {
+		return nil, fmt.Errorf("%s: pullRequest must be positive", ErrInvalidPreview)
+	}
+	ttl, err := s.previewTTL(req.TTLHours)
+	if err != nil {
+		return nil, err
+	}
+
+	previews, err := s.repo.ListPreviews(c, parent.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range previews {
+		if existing.PreviewBranch != nil && *existing.PreviewBranch == branch {
+			return nil, fmt.Errorf("%s: %s", ErrPreviewExists, branch)
+		}
+	}
+	if limit := s.cfg.Previews.MaxPerDeployment; limit > 0 && len(previews) >= limit {
+		return nil, fmt.Errorf("%s: deployment %s already has %d preview environments", ErrPreviewLimit, parent.Name, limit)
+	}
+
+	name := previewName(parent.Name, branch, req.PullRequest)
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	subdomain, err := s.allocateSubdomain(c, parent.UserId, CreateDeploymentRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	// Secret env tidak disimpan di DB, diambil dari Secret K8s workspace parent
+	secretData, err := s.k8s.GetSecretData(c, parent.Namespace, parent.SecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := previewDeployment(parent, name)
+	preview.Subdomain = &subdomain
+	preview.Host = s.subdomainHost(subdomain)
+	preview.Addons = templateAddons(tmpl, s.cfg)
+	preview.PreviewBranch = &branch
+	if req.PullRequest > 0 {
+		preview.PreviewPullRequest = &req.PullRequest
+	}
+	expiresAt := time.Now().Add(ttl)
+	preview.ExpiresAt = &expiresAt
+
+	unlock, err := s.lockQuota(c, parent.UserId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.checkReplicaLimitFor(c, parent.UserId, 0, tmpl, preview.Spec().maxReplicas()); err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(c, &preview, preview.Addons); err != nil {
+		return nil, err
+	}
+
+	data, err := s.repo.Create(c, preview, actor)
+	if err != nil {
+		// Nama preview bisa sudah dipakai workspace lain milik user
+		if strings.Contains(err.Error(), string(ErrDeploymentExists)) {
+			return nil, fmt.Errorf("%s: %s (workspace name %s is already in use)", ErrPreviewExists, branch, name)
+		}
+		return nil, err
+	}
+
+	secretName, err := s.stageSecrets(c, data, templateSecrets(tmpl, secretData))
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobProvision, data, provisionPayload{SecretName: secretName})
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	result := &PreviewJob{Deployment: data, Job: job}
+
+	// Build menunggu provision selesai sebelum roll out (lihat deployBuild)
+	deploy := true
+	build, err := s.startBuild(c, data, tmpl, BuildRequest{Branch: branch, Deploy: &deploy}, BuildTriggerPreview, actor)
+	if err != nil {
+		log.Printf("failed to start build for preview %d: %s", data.Id, err.Error())
+		return result, nil
+	}
+	result.Build = build.Build
+
+	return result, nil
+}
+
+// destroyPreview - Preview yang sudah dalam proses hapus diabaikan (PR close + TTL bisa bersamaan).
+// Preview yang masih di-provision ditolak, expiry job menghapusnya setelah provision selesai
+// (TTL habis atau parent sudah dihapus).
+func (s Service) destroyPreview(c context.Context, preview *Deployment, actor, reason string) (*DeploymentJob, error) {
+	if preview.Status == StatusDeleting || preview.Status == StatusDeleted {
+		return &DeploymentJob{Deployment: preview}, nil
+	}
+	if err := s.checkNoActiveJob(c, preview, JobProvision); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(c, preview, StatusDeleting, actor, reason); err != nil {
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobDelete, preview, deletePayload{DeleteVolumes: true})
+	if err != nil {
+		s.markFailed(c, preview, err)
+		return nil, err
+	}
+
+	return &DeploymentJob{Deployment: preview, Job: job}, nil
+}
+
+// destroyPreviews - Dipanggil saat workspace parent dihapus, error hanya di-log. Preview yang
+// gagal dihapus (e.g., masih di-provision) diulang expirePreviews lewat ListOrphanedPreviews.
+func (s Service) destroyPreviews(c context.Context, parent *Deployment, actor string) {
+	previews, err := s.repo.ListPreviews(c, parent.Id)
+	if err != nil {
+		log.Printf("failed to list previews of deployment %d: %s", parent.Id, err.Error())
+		return
+	}
+
+	for i := range previews {
+		reason := fmt.Sprintf("parent deployment %s deleted", parent.Name)
+		if _, err := s.destroyPreview(c, &previews[i], actor, reason); err != nil {
+			log.Printf("failed to delete preview %d, retrying on the next expiry check: %s", previews[i].Id, err.Error())
+		}
+	}
+}
+
+// findPreview - nil kalau branch tidak punya preview
+func (s Service) findPreview(c context.Context, parentId int, branch string) (*Deployment, error) {
+	previews, err := s.repo.ListPreviews(c, parentId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range previews {
+		if previews[i].PreviewBranch != nil && *previews[i].PreviewBranch == branch {
+			return &previews[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// expirePreviews - Dijalankan periodik oleh job pool, hapus preview yang TTL-nya habis dan
+// preview yang parent-nya sudah dihapus
+func (s Service) expirePreviews(c context.Context) error {
+	orphaned, err := s.repo.ListOrphanedPreviews(c)
+	if err != nil {
+		return err
+	}
+	for i := range orphaned {
+		if _, err := s.destroyPreview(c, &orphaned[i], actorSystem, previewParentDeletedReason); err != nil {
+			log.Printf("failed to delete preview %d of deleted parent: %s", orphaned[i].Id, err.Error())
+		}
+	}
+
+	previews, err := s.repo.ListExpiredPreviews(c)
+	if err != nil {
+		return err
+	}
+	for i := range previews {
+		if _, err := s.destroyPreview(c, &previews[i], actorSystem, previewExpiredReason); err != nil {
+			log.Printf("failed to delete expired preview %d: %s", previews[i].Id, err.Error())
+		}
+	}
+	return nil
+}
+
+func (s Service) previewTTL(hours int) (time.Duration, error) {
+	if hours == 0 {
+		hours = s.cfg.Previews.DefaultTTLHours
+	}
+	if hours == 0 {
+		hours = defaultPreviewTTLHours
+	}
+	if hours < 0 {
+		return 0, fmt.Errorf("%s: ttlHours must be positive", ErrInvalidPreview)
+	}
+	if limit := s.cfg.Previews.MaxTTLHours; limit > 0 && hours > limit {
+		return 0, fmt.Errorf("%s: ttlHours must be at most %d", ErrInvalidPreview, limit)
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
+
+func (s Service) previewCheckInterval() time.Duration {
+	if s.cfg.Previews.CheckIntervalSeconds <= 0 {
+		return defaultPreviewCheckInterval
+	}
+	return time.Duration(s.cfg.Previews.CheckIntervalSeconds) * time.Second
+}
+
+// previewDeployment - Salin spec parent, 1 replica tanpa autoscaling. Image parent dipakai
+// sampai build branch selesai. Definisi volume ikut karena container & sidecar me-mount volume
+// by name, tapi PVC-nya baru dan kosong (nama dari preview, lihat volumeClaimName) dan ikut
+// dihapus bersama preview; data parent tidak pernah di-mount. Idle timeout tidak ikut: umur
+// preview sudah dibatasi TTL dan reviewer tidak perlu menunggu workspace dibangunkan.
+func previewDeployment(parent *Deployment, name string) Deployment {
+	preview := Deployment{
+		UserId:             parent.UserId,
+		TemplateId:         parent.TemplateId,
+		Namespace:          parent.Namespace,
+		Image:              parent.Image,
+		Replicas:           1,
+		ContainerPort:      parent.ContainerPort,
+		CPURequest:         parent.CPURequest,
+		CPULimit:           parent.CPULimit,
+		MemoryRequest:      parent.MemoryRequest,
+		MemoryLimit:        parent.MemoryLimit,
+		EnvVars:            copyMap(parent.EnvVars),
+		Probes:             parent.Probes,
+		Volumes:            parent.Volumes,
+		Sidecars:           parent.Sidecars,
+		InitContainers:     parent.InitContainers,
+		DatabaseType:       parent.DatabaseType,
+		IngressAnnotations: parent.IngressAnnotations,
+		ParentId:           &parent.Id,
+		Status:             StatusPending,
+	}
+	setResourceNames(&preview, name)
+	return preview
+}
+
+// templateSecrets - Hanya env secret dari schema template; credentials database & add-on
+// parent tidak ikut, preview mendapat database sendiri
+func templateSecrets(tmpl *templates.Template, secretData map[string]string) map[string]string {
+	secrets := map[string]string{}
+	for key, prop := range tmpl.EnvVarsSchema {
+		if value, ok := secretData[key]; ok && prop.Secret {
+			secrets[key] = value
+		}
+	}
+	return secrets
+}
+
+// previewName - "<parent>-pr-<n>" atau "<parent>-<branch slug>-<hash>", parent dipotong supaya
+// total tetap maksimal 40 karakter. Hash dari nama branch lengkap supaya branch yang slug-nya
+// sama setelah dipotong / dinormalisasi tetap dapat nama berbeda.
+func previewName(parent, branch string, pullRequest int) string {
+	suffix := fmt.Sprintf("pr-%d", pullRequest)
+	if pullRequest <= 0 {
+		sum := sha256.Sum256([]byte(branch))
+		hash := hex.EncodeToString(sum[:])[:previewHashLength]
+
+		slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(branch), "-"), "-")
+		if limit := maxPreviewSuffix - previewHashLength - 1; len(slug) > limit {
+			slug = strings.TrimRight(slug[:limit], "-")
+		}
+		if slug == "" {
+			slug = "preview"
+		}
+		suffix = slug + "-" + hash
+	}
+
+	if limit := 40 - len(suffix) - 1; len(parent) > limit {
+		parent = strings.TrimRight(parent[:limit], "-")
+	}
+	return parent + "-" + suffix
+}
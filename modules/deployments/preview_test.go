@@ -0,0 +1,219 @@
+package deployments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+// fakePreviewRepo - Parent & preview di memory, query preview dihitung dari status & ExpiresAt
+type fakePreviewRepo struct {
+	DeploymentRepository
+	deployments map[int]*Deployment
+}
+
+func (r *fakePreviewRepo) Transition(c context.Context, id int, from, to, actor, reason string) error {
+	data := r.deployments[id]
+	if data.Status != from {
+		return fmt.Errorf("%s: deployment %d is no longer %s", ErrStatusConflict, id, from)
+	}
+	data.Status = to
+	return nil
+}
+
+func (r *fakePreviewRepo) ListPreviews(c context.Context, parentId int) ([]Deployment, error) {
+	return r.previews(func(preview *Deployment) bool { return *preview.ParentId == parentId }), nil
+}
+
+func (r *fakePreviewRepo) ListExpiredPreviews(c context.Context) ([]Deployment, error) {
+	return r.previews(func(preview *Deployment) bool {
+		return preview.ExpiresAt != nil && preview.ExpiresAt.Before(time.Now()) && !deleting(preview)
+	}), nil
+}
+
+func (r *fakePreviewRepo) ListOrphanedPreviews(c context.Context) ([]Deployment, error) {
+	return r.previews(func(preview *Deployment) bool {
+		return deleting(r.deployments[*preview.ParentId]) && !deleting(preview)
+	}), nil
+}
+
+func (r *fakePreviewRepo) previews(match func(preview *Deployment) bool) []Deployment {
+	var result []Deployment
+	for id := 1; id <= len(r.deployments); id++ {
+		data := r.deployments[id]
+		if data.ParentId != nil && match(data) {
+			result = append(result, *data)
+		}
+	}
+	return result
+}
+
+func deleting(data *Deployment) bool {
+	return data.Status == StatusDeleting || data.Status == StatusDeleted
+}
+
+// fakePreviewJobRepo - Job provision aktif per deployment
+type fakePreviewJobRepo struct {
+	jobs.JobRepository
+	provisioning map[int]bool
+	deleted      []int
+}
+
+func (r *fakePreviewJobRepo) HasActive(c context.Context, deploymentId int, types []string) (bool, error) {
+	return r.provisioning[deploymentId], nil
+}
+
+func (r *fakePreviewJobRepo) Enqueue(c context.Context, req jobs.EnqueueRequest) (*jobs.Job, error) {
+	if req.Type == JobDelete {
+		r.deleted = append(r.deleted, req.DeploymentId)
+	}
+	return &jobs.Job{Id: len(r.deleted), Type: req.Type, Status: jobs.StatusQueued}, nil
+}
+
+func testPreviewRepo(previews ...Deployment) *fakePreviewRepo {
+	repo := &fakePreviewRepo{deployments: map[int]*Deployment{
+		1: {Id: 1, UserId: 1, Name: "shop", Status: StatusRunning},
+	}}
+	for i := range previews {
+		preview := previews[i]
+		preview.Id = len(repo.deployments) + 1
+		preview.UserId = 1
+		preview.ParentId = optionalInt(1)
+		repo.deployments[preview.Id] = &preview
+	}
+	return repo
+}
+
+func optionalInt(value int) *int {
+	return &value
+}
+
+func TestPreviewDeployment(t *testing.T) {
+	parent := &Deployment{
+		Id:                 1,
+		UserId:             1,
+		Name:               "shop",
+		Replicas:           3,
+		IdleTimeoutMinutes: 30,
+		Volumes:            []templates.Volume{{Name: "data", Size: "1Gi", MountPath: "/data"}},
+	}
+	setResourceNames(parent, parent.Name)
+
+	preview := previewDeployment(parent, "shop-pr-7")
+	if preview.Replicas != 1 || preview.IdleTimeoutMinutes != 0 || *preview.ParentId != parent.Id {
+		t.Fatalf("unexpected preview %+v", preview)
+	}
+	if len(preview.Volumes) != 1 {
+		t.Fatalf("expected volume definitions to be copied, got %v", preview.Volumes)
+	}
+	if volumeClaimName(&preview, preview.Volumes[0]) == volumeClaimName(parent, parent.Volumes[0]) {
+		t.Fatal("expected preview to get its own pvc")
+	}
+}
+
+func TestExpirePreviews(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name         string
+		parentStatus string
+		preview      Deployment
+		provisioning bool
+		wantDeleted  bool
+	}{
+		{name: "active preview is kept", parentStatus: StatusRunning, preview: Deployment{Status: StatusRunning, ExpiresAt: &future}},
+		{name: "expired preview", parentStatus: StatusRunning, preview: Deployment{Status: StatusRunning, ExpiresAt: &past}, wantDeleted: true},
+		{name: "preview of deleted parent", parentStatus: StatusDeleted, preview: Deployment{Status: StatusRunning, ExpiresAt: &future}, wantDeleted: true},
+		{name: "preview of deleted parent still provisioning", parentStatus: StatusDeleting, preview: Deployment{Status: StatusProvisioning, ExpiresAt: &future}, provisioning: true},
+		{name: "preview already deleting", parentStatus: StatusDeleted, preview: Deployment{Status: StatusDeleting, ExpiresAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testPreviewRepo(tt.preview)
+			repo.deployments[1].Status = tt.parentStatus
+			jobRepo := &fakePreviewJobRepo{provisioning: map[int]bool{2: tt.provisioning}}
+			service := NewService(repo, nil, jobs.NewService(jobRepo), nil, config.Config{})
+
+			if err := service.expirePreviews(context.Background()); err != nil {
+				t.Fatalf("expected expiry check to succeed, got %v", err)
+			}
+			if deleted := len(jobRepo.deleted) == 1; deleted != tt.wantDeleted {
+				t.Fatalf("expected deleted=%v, got delete jobs for %v", tt.wantDeleted, jobRepo.deleted)
+			}
+			if tt.wantDeleted && repo.deployments[2].Status != StatusDeleting {
+				t.Fatalf("expected preview to be %s, got %s", StatusDeleting, repo.deployments[2].Status)
+			}
+		})
+	}
+}
+
+// Preview yang masih di-provision saat parent dihapus ikut dihapus setelah provision selesai
+func TestDestroyPreviewsRetriesProvisioning(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	repo := testPreviewRepo(
+		Deployment{Name: "shop-pr-1", Status: StatusRunning, ExpiresAt: &future},
+		Deployment{Name: "shop-pr-2", Status: StatusProvisioning, ExpiresAt: &future},
+	)
+	jobRepo := &fakePreviewJobRepo{provisioning: map[int]bool{3: true}}
+	service := NewService(repo, nil, jobs.NewService(jobRepo), nil, config.Config{})
+
+	parent := repo.deployments[1]
+	parent.Status = StatusDeleting
+	service.destroyPreviews(context.Background(), parent, actorSystem)
+	if fmt.Sprint(jobRepo.deleted) != "[2]" {
+		t.Fatalf("expected only the running preview to be deleted, got %v", jobRepo.deleted)
+	}
+
+	// Provision selesai, expiry check berikutnya menghapus preview yang tertinggal
+	jobRepo.provisioning[3] = false
+	repo.deployments[3].Status = StatusRunning
+	if err := service.expirePreviews(context.Background()); err != nil {
+		t.Fatalf("expected expiry check to succeed, got %v", err)
+	}
+	if fmt.Sprint(jobRepo.deleted) != "[2 3]" {
+		t.Fatalf("expected orphaned preview to be deleted, got %v", jobRepo.deleted)
+	}
+}
+
+func TestPreviewName(t *testing.T) {
+	tests := []struct {
+		name        string
+		parent      string
+		branch      string
+		pullRequest int
+		want        string
+	}{
+		{name: "pull request", parent: "shop", branch: "feature/cart", pullRequest: 7, want: "shop-pr-7"},
+		{name: "branch", parent: "shop", branch: "main", want: "shop-main-" + previewHash("main")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previewName(tt.parent, tt.branch, tt.pullRequest); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	// Parent panjang dipotong, branch berbeda dengan slug sama tetap dapat nama berbeda
+	long := "a-very-long-workspace-name-that-keeps-going"
+	first := previewName(long, "feature/Cart", 0)
+	second := previewName(long, "feature-cart", 0)
+	if len(first) > 40 || first == second {
+		t.Fatalf("expected distinct names of at most 40 characters, got %s and %s", first, second)
+	}
+}
+
+func previewHash(branch string) string {
+	sum := sha256.Sum256([]byte(branch))
+	return hex.EncodeToString(sum[:])[:previewHashLength]
+}
@@ -0,0 +1,478 @@
+package deployments
+
+var (
+	queryCreate = `
+		INSERT INTO deployments (
+			user_id,
+			template_id,
+			name,
+			namespace,
+			app_name,
+			image,
+			replicas,
+			container_port,
+			host,
+			cpu_request,
+			cpu_limit,
+			memory_request,
+			memory_limit,
+			env_vars,
+			config_map_name,
+			secret_name,
+			deployment_name,
+			service_name,
+			ingress_name,
+			status,
+			idle_timeout_minutes,
+			autoscaling,
+			probes,
+			volumes,
+			database_type,
+			ingress_annotations,
+			subdomain,
+			sidecars,
+			init_containers,
+			parent_id,
+			preview_branch,
+			preview_pull_request,
+			expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
+			$31, $32, $33
+		) RETURNING id, created_at, updated_at, last_activity_at
+	`
+
+	querySelect = `
+		SELECT
+			id, user_id, template_id, name, namespace, app_name, image,
+			replicas, container_port, host,
+			cpu_request, cpu_limit, memory_request, memory_limit, env_vars,
+			config_map_name, secret_name, deployment_name, service_name, ingress_name,
+			status, status_message, current_revision,
+			idle_timeout_minutes, sleeping, last_activity_at,
+			autoscaling, probes, volumes, database_type, ingress_annotations, subdomain,
+			sidecars, init_containers,
+			parent_id, preview_branch, preview_pull_request, expires_at,
+			created_at, updated_at, deleted_at
+		FROM deployments
+	`
+
+	queryGetByIDForUpdate = `
+		SELECT current_revision FROM deployments
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	queryUpdateSpec = `
+		UPDATE deployments SET
+			image = $1,
+			replicas = $2,
+			cpu_request = $3,
+			cpu_limit = $4,
+			memory_request = $5,
+			memory_limit = $6,
+			env_vars = $7,
+			current_revision = $8,
+			autoscaling = $9,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10
+	`
+
+	queryInsertRevision = `
+		INSERT INTO deployment_revisions (deployment_id, revision, spec, change_cause, rollback_of, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id, created_at
+	`
+
+	queryRevisionColumns = `
+		SELECT id, deployment_id, revision, spec, change_cause, rollback_of, created_by, created_at
+		FROM deployment_revisions
+	`
+
+	queryListRevisions = queryRevisionColumns + `
+		WHERE deployment_id = $1
+		ORDER BY revision DESC
+	`
+
+	queryGetRevision = queryRevisionColumns + `
+		WHERE deployment_id = $1 AND revision = $2
+	`
+
+	// Total replica yang sedang dipakai user (deployment yang di-pause tidak dihitung)
+	querySumReplicasByUser = `
+		SELECT COALESCE(SUM(
+			CASE WHEN autoscaling IS NOT NULL THEN (autoscaling->>'maxReplicas')::int ELSE replicas END
+		), 0) FROM deployments
+		WHERE user_id = $1 AND id <> $2 AND deleted_at IS NULL
+			AND status NOT IN ('stopped', 'deleting', 'deleted', 'failed')
+	`
+
+	// Host utama atau custom domain yang sudah diverifikasi, keduanya dijaga unique index
+	queryGetByHost = querySelect + `
+		WHERE deleted_at IS NULL
+			AND (host = $1 OR id IN (
+				SELECT deployment_id FROM deployment_domains WHERE domain = $1 AND status = 'verified'
+			))
+	`
+
+	// Deployment running yang tidak ada traffic lebih lama dari idle timeout-nya
+	queryListIdle = querySelect + `
+		WHERE deleted_at IS NULL
+			AND status = 'running'
+			AND sleeping = false
+			AND idle_timeout_minutes > 0
+			AND last_activity_at < CURRENT_TIMESTAMP - (idle_timeout_minutes * INTERVAL '1 minute')
+		ORDER BY last_activity_at ASC
+		LIMIT 100
+	`
+
+	// Throttle: maksimal satu write per menit per deployment
+	queryTouchActivity = `
+		UPDATE deployments SET last_activity_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_activity_at IS NULL OR last_activity_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+	`
+
+	querySetSleeping = `
+		UPDATE deployments SET sleeping = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	querySetIdleTimeout = `
+		UPDATE deployments SET idle_timeout_minutes = $1, last_activity_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	queryGetByID = querySelect + `
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`
+
+	queryListByUser = querySelect + `
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+	`
+
+	// Optimistic check: status hanya berubah kalau masih sama dengan $4
+	queryTransition = `
+		UPDATE deployments SET
+			status = $1,
+			status_message = NULLIF($2, ''),
+			deleted_at = CASE WHEN $1 = 'deleted' THEN CURRENT_TIMESTAMP ELSE deleted_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = $4 AND deleted_at IS NULL
+	`
+
+	queryInsertHistory = `
+		INSERT INTO deployment_status_history (deployment_id, from_status, to_status, actor, reason)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''))
+	`
+
+	queryListHistory = `
+		SELECT id, deployment_id, from_status, to_status, actor, reason, created_at
+		FROM deployment_status_history
+		WHERE deployment_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	queryInsertAddon = `
+		INSERT INTO deployment_addons (deployment_id, type, persistent, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	querySelectAddon = `
+		SELECT id, deployment_id, type, persistent, status, status_message, created_at, updated_at
+		FROM deployment_addons
+	`
+
+	queryListAddons = querySelectAddon + `
+		WHERE deployment_id = $1
+		ORDER BY id ASC
+	`
+
+	queryGetAddon = querySelectAddon + `
+		WHERE deployment_id = $1 AND type = $2
+	`
+
+	querySetAddonStatus = `
+		UPDATE deployment_addons SET
+			status = $1,
+			status_message = NULLIF($2, ''),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+
+	queryDeleteAddon = `
+		DELETE FROM deployment_addons WHERE id = $1
+	`
+
+	queryListAddonsByUser = `
+		SELECT a.id, a.deployment_id, a.type, a.persistent, a.status, a.status_message, a.created_at, a.updated_at
+		FROM deployment_addons a
+		JOIN deployments d ON d.id = a.deployment_id
+		WHERE d.user_id = $1 AND d.deleted_at IS NULL
+		ORDER BY a.id ASC
+	`
+
+	queryInsertEgressRule = `
+		INSERT INTO namespace_egress_rules (user_id, cidr, port, protocol, description)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at
+	`
+
+	queryListEgressRules = `
+		SELECT id, user_id, cidr, port, protocol, description, created_at
+		FROM namespace_egress_rules
+		WHERE user_id = $1
+		ORDER BY id ASC
+	`
+
+	queryDeleteEgressRule = `
+		DELETE FROM namespace_egress_rules WHERE id = $1 AND user_id = $2
+	`
+
+	queryInsertDomain = `
+		INSERT INTO deployment_domains (deployment_id, domain, verification_token, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+
+	querySelectDomain = `
+		SELECT
+			dd.id, dd.deployment_id, d.user_id, dd.domain, dd.verification_token,
+			dd.status, dd.status_message, dd.verified_at, dd.last_checked_at,
+			dd.created_at, dd.updated_at
+		FROM deployment_domains dd
+		JOIN deployments d ON d.id = dd.deployment_id
+	`
+
+	queryListDomains = querySelectDomain + `
+		WHERE dd.deployment_id = $1
+		ORDER BY dd.id ASC
+	`
+
+	queryGetDomain = querySelectDomain + `
+		WHERE dd.deployment_id = $1 AND dd.id = $2
+	`
+
+	// Domain pending yang masih dalam window verifikasi, workspace belum dihapus
+	queryListPendingDomains = querySelectDomain + `
+		WHERE dd.status = 'pending'
+			AND dd.created_at > $1
+			AND d.deleted_at IS NULL
+		ORDER BY dd.last_checked_at ASC NULLS FIRST
+		LIMIT 100
+	`
+
+	querySetDomainStatus = `
+		UPDATE deployment_domains SET
+			status = $1,
+			status_message = NULLIF($2, ''),
+			verified_at = CASE WHEN $1 = 'verified' THEN CURRENT_TIMESTAMP ELSE NULL END,
+			last_checked_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+
+	queryDeleteDomain = `
+		DELETE FROM deployment_domains WHERE id = $1
+	`
+
+	queryDeleteDomainsByDeployment = `
+		DELETE FROM deployment_domains WHERE deployment_id = $1
+	`
+
+	queryInsertBuild = `
+		INSERT INTO deployment_builds (
+			deployment_id, status, trigger, git_repo_url, git_branch, git_commit,
+			dockerfile_path, image, deploy, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		RETURNING id, created_at, updated_at
+	`
+
+	querySelectBuild = `
+		SELECT
+			id, deployment_id, status, status_message, trigger, git_repo_url, git_branch,
+			git_commit, dockerfile_path, image, image_digest, job_name, deploy,
+			deployed_revision, created_by, started_at, finished_at, created_at, updated_at
+		FROM deployment_builds
+	`
+
+	queryListBuilds = querySelectBuild + `
+		WHERE deployment_id = $1
+		ORDER BY id DESC
+		LIMIT 50
+	`
+
+	queryGetBuild = querySelectBuild + `
+		WHERE deployment_id = $1 AND id = $2
+	`
+
+	queryCountActiveBuildsByUser = `
+		SELECT COUNT(*) FROM deployment_builds b
+		JOIN deployments d ON d.id = b.deployment_id
+		WHERE d.user_id = $1 AND b.status IN ('queued', 'running')
+	`
+
+	queryHasActiveBuild = `
+		SELECT EXISTS (
+			SELECT 1 FROM deployment_builds
+			WHERE deployment_id = $1 AND status IN ('queued', 'running')
+		)
+	`
+
+	queryStartBuild = `
+		UPDATE deployment_builds SET
+			status = 'running',
+			job_name = $1,
+			started_at = COALESCE(started_at, CURRENT_TIMESTAMP),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	queryFinishBuild = `
+		UPDATE deployment_builds SET
+			status = $1,
+			status_message = NULLIF($2, ''),
+			image_digest = NULLIF($3, ''),
+			finished_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4
+	`
+
+	querySetBuildDeployed = `
+		UPDATE deployment_builds SET
+			deployed_revision = $1,
+			status_message = NULLIF($2, ''),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3
+	`
+
+	queryUpsertBuildLog = `
+		INSERT INTO deployment_build_logs (build_id, content, size_bytes, truncated)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (build_id) DO UPDATE SET
+			content = EXCLUDED.content,
+			size_bytes = EXCLUDED.size_bytes,
+			truncated = EXCLUDED.truncated,
+			created_at = CURRENT_TIMESTAMP
+	`
+
+	queryGetBuildLog = `
+		SELECT build_id, content, size_bytes, truncated, created_at
+		FROM deployment_build_logs
+		WHERE build_id = $1
+	`
+
+	queryGetWebhook = `
+		SELECT w.deployment_id, d.user_id, w.secret, w.created_at, w.updated_at
+		FROM deployment_webhooks w
+		JOIN deployments d ON d.id = w.deployment_id
+		WHERE w.deployment_id = $1 AND d.deleted_at IS NULL
+	`
+
+	queryUpsertWebhook = `
+		INSERT INTO deployment_webhooks (deployment_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (deployment_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING created_at, updated_at
+	`
+
+	queryDeleteWebhook = `
+		DELETE FROM deployment_webhooks WHERE deployment_id = $1
+	`
+
+	queryInsertDelivery = `
+		INSERT INTO deployment_webhook_deliveries (
+			deployment_id, provider, event, delivery_id, status, status_message,
+			ref, commit_sha, build_id, redelivery_of, payload, payload_sha256
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`
+
+	queryUpdateDeliveryResult = `
+		UPDATE deployment_webhook_deliveries
+		SET status = $1, status_message = $2, ref = $3, commit_sha = $4, build_id = $5
+		WHERE id = $6
+	`
+
+	querySelectDelivery = `
+		SELECT
+			id, deployment_id, provider, event, delivery_id, status, status_message,
+			ref, commit_sha, build_id, redelivery_of, payload, payload_sha256, created_at
+		FROM deployment_webhook_deliveries
+	`
+
+	queryListDeliveries = querySelectDelivery + `
+		WHERE deployment_id = $1
+		ORDER BY id DESC
+		LIMIT 50
+	`
+
+	queryGetDelivery = querySelectDelivery + `
+		WHERE deployment_id = $1 AND id = $2
+	`
+
+	queryListPreviews = querySelect + `
+		WHERE parent_id = $1 AND deleted_at IS NULL
+		ORDER BY id ASC
+	`
+
+	// Preview yang TTL-nya habis dan belum dalam proses hapus
+	queryListExpiredPreviews = querySelect + `
+		WHERE parent_id IS NOT NULL
+			AND expires_at < CURRENT_TIMESTAMP
+			AND deleted_at IS NULL
+			AND status NOT IN ('deleting', 'deleted')
+		ORDER BY expires_at ASC
+		LIMIT 100
+	`
+
+	// Preview yang parent-nya sudah dihapus tapi belum ikut dihapus (saat itu masih di-provision)
+	queryListOrphanedPreviews = querySelect + `
+		WHERE parent_id IN (
+				SELECT id FROM deployments
+				WHERE status IN ('deleting', 'deleted') OR deleted_at IS NOT NULL
+			)
+			AND deleted_at IS NULL
+			AND status NOT IN ('deleting', 'deleted')
+		ORDER BY id ASC
+		LIMIT 100
+	`
+
+	querySubdomainExists = `
+		SELECT EXISTS (
+			SELECT 1 FROM deployments WHERE subdomain = $1 AND deleted_at IS NULL
+		)
+	`
+
+	queryUpdateSubdomain = `
+		UPDATE deployments SET
+			subdomain = NULLIF($1, ''),
+			host = $2,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND deleted_at IS NULL
+	`
+
+	queryGetUsername = `
+		SELECT COALESCE(username, '') FROM users WHERE id = $1
+	`
+
+	queryGetUserPlan = `
+		SELECT plan FROM users WHERE id = $1
+	`
+
+	// NO KEY UPDATE: insert deployments / jobs (FK ke users) dari koneksi lain tidak ter-block
+	queryLockUser = `
+		SELECT id FROM users WHERE id = $1 FOR NO KEY UPDATE
+	`
+
+	queryListUserPlans = `
+		SELECT id, plan FROM users WHERE is_deleted = false
+	`
+)
@@ -0,0 +1,597 @@
+package deployments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// Label namespace: plan yang terakhir di-apply dan hash config-nya
+	planLabel      = "workspaces/plan"
+	quotaHashLabel = "workspaces/quota"
+
+	defaultQuotaSyncInterval = 5 * time.Minute
+
+	// Default dari k8s.CreateDeployment kalau limits tidak di-set
+	defaultAppCPULimit    = "500m"
+	defaultAppMemoryLimit = "512Mi"
+)
+
+// footprint - Resource yang dipesan workspace menurut spec-nya (bukan pemakaian aktual)
+type footprint struct {
+	cpu      resource.Quantity
+	memory   resource.Quantity
+	storage  resource.Quantity
+	pods     int64
+	services int64
+}
+
+func (f *footprint) add(other footprint) {
+	f.cpu.Add(other.cpu)
+	f.memory.Add(other.memory)
+	f.storage.Add(other.storage)
+	f.pods += other.pods
+	f.services += other.services
+}
+
+// addQuantity - value × count, value kosong memakai fallback (default LimitRange)
+func addQuantity(total *resource.Quantity, value, fallback string, count int64) error {
+	raw := valueOr(value, fallback)
+	if raw == "" {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %q: %w", ErrInvalidResource, raw, err)
+	}
+	for i := int64(0); i < count; i++ {
+		total.Add(quantity)
+	}
+	return nil
+}
+
+func (f footprint) toMap() map[string]string {
+	return map[string]string{
+		"cpu":      f.cpu.String(),
+		"memory":   f.memory.String(),
+		"storage":  f.storage.String(),
+		"pods":     fmt.Sprintf("%d", f.pods),
+		"services": fmt.Sprintf("%d", f.services),
+	}
+}
+
+// Quota - Plan user, batasnya, total yang dipesan semua workspace, dan pemakaian di cluster
+func (s Service) Quota(c context.Context, userId int) (*QuotaStatus, error) {
+	name, plan, ok, err := s.userPlan(c, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.reservedFootprint(c, userId, 0, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &QuotaStatus{
+		Plan:     name,
+		Reserved: reserved.toMap(),
+	}
+	if ok {
+		result.Limits = map[string]string{
+			"cpu":      plan.CPU,
+			"memory":   plan.Memory,
+			"storage":  plan.Storage,
+			"pods":     fmt.Sprintf("%d", plan.Pods),
+			"services": fmt.Sprintf("%d", plan.Services),
+		}
+	}
+
+	usage, err := s.k8s.GetResourceQuotaUsage(c, userNamespace(userId))
+	if err != nil {
+		return nil, err
+	}
+	if usage != nil {
+		result.Used = usage.Used
+	}
+
+	return result, nil
+}
+
+// checkQuota - Tolak (422) kalau workspace dengan spec baru membuat total melebihi plan.
+// Dicek di API supaya tidak ada pod yang stuck karena ditolak ResourceQuota.
+// Caller harus memegang lockQuota sampai perubahan tersimpan.
+func (s Service) checkQuota(c context.Context, candidate *Deployment, addons []Addon) error {
+	name, plan, ok, err := s.userPlan(c, candidate.UserId)
+	if err != nil || !ok {
+		return err
+	}
+	if err := s.checkContainerLimits(candidate, addons, name, plan); err != nil {
+		return err
+	}
+
+	total, err := s.reservedFootprint(c, candidate.UserId, candidate.Id, plan)
+	if err != nil {
+		return err
+	}
+	usage, err := s.footprint(candidate, addons, plan)
+	if err != nil {
+		return err
+	}
+	total.add(usage)
+
+	return exceedsPlan(total, name, plan)
+}
+
+// lockQuota - Cek quota lalu simpan harus atomic per user, kalau tidak dua request paralel
+// lolos dengan total yang sama. Row users di-lock sampai unlock dipanggil.
+func (s Service) lockQuota(c context.Context, userId int) (func(), error) {
+	return s.repo.LockUser(c, userId)
+}
+
+// checkSpecQuota - checkQuota untuk deployment yang sudah ada dengan spec baru
+func (s Service) checkSpecQuota(c context.Context, data *Deployment, spec DeploymentSpec) error {
+	candidate := *data
+	candidate.applySpec(spec)
+
+	addons, err := s.repo.ListAddons(c, data.Id)
+	if err != nil {
+		return err
+	}
+
+	return s.checkQuota(c, &candidate, addons)
+}
+
+// reservedFootprint - Total semua workspace user kecuali excludeId, ditambah build yang
+// masih queued / running. Workspace failed & deleting tetap dihitung: Deployment dan pod-nya
+// masih ada di namespace sampai teardown selesai.
+func (s Service) reservedFootprint(c context.Context, userId, excludeId int, plan config.PlanConfig) (footprint, error) {
+	var total footprint
+
+	deployments, err := s.repo.ListByUser(c, userId)
+	if err != nil {
+		return total, err
+	}
+	addons, err := s.repo.ListAddonsByUser(c, userId)
+	if err != nil {
+		return total, err
+	}
+	addonsByDeployment := map[int][]Addon{}
+	for _, addon := range addons {
+		addonsByDeployment[addon.DeploymentId] = append(addonsByDeployment[addon.DeploymentId], addon)
+	}
+
+	for i := range deployments {
+		data := &deployments[i]
+		if data.Id == excludeId {
+			continue
+		}
+		if data.Status == StatusDeleted {
+			continue
+		}
+		usage, err := s.footprint(data, addonsByDeployment[data.Id], plan)
+		if err != nil {
+			return total, fmt.Errorf("deployment %s: %w", data.Name, err)
+		}
+		total.add(usage)
+	}
+
+	builds, err := s.repo.CountActiveBuildsByUser(c, userId)
+	if err != nil {
+		return total, err
+	}
+	usage, err := s.buildFootprint(int64(builds), plan)
+	if err != nil {
+		return total, err
+	}
+	total.add(usage)
+
+	return total, nil
+}
+
+// buildFootprint - Pod build jalan di namespace build (di luar ResourceQuota user),
+// jadi dihitung ke plan pemilik workspace di sini
+func (s Service) buildFootprint(count int64, plan config.PlanConfig) (footprint, error) {
+	var result footprint
+	result.pods = count
+	if err := addQuantity(&result.cpu, s.cfg.Builds.CPULimit, plan.DefaultCPULimit, count); err != nil {
+		return result, err
+	}
+	if err := addQuantity(&result.memory, s.cfg.Builds.MemoryLimit, plan.DefaultMemoryLimit, count); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// checkBuildQuota - Satu build baru ditambah total yang sudah dipesan.
+// Caller harus memegang lockQuota sampai build tersimpan.
+func (s Service) checkBuildQuota(c context.Context, userId int) error {
+	name, plan, ok, err := s.userPlan(c, userId)
+	if err != nil || !ok {
+		return err
+	}
+
+	total, err := s.reservedFootprint(c, userId, 0, plan)
+	if err != nil {
+		return err
+	}
+	usage, err := s.buildFootprint(1, plan)
+	if err != nil {
+		return err
+	}
+	total.add(usage)
+
+	return exceedsPlan(total, name, plan)
+}
+
+// footprint - App (maxReplicas kalau autoscaling, ditambah pod surge saat rolling update),
+// database, add-on, PVC, dan Service.
+// Workspace yang stopped tidak memesan pod app. Database & add-on ikut di-stop tapi tetap
+// dipesan supaya resume tidak ditolak quota.
+func (s Service) footprint(data *Deployment, addons []Addon, plan config.PlanConfig) (footprint, error) {
+	var result footprint
+
+	if data.Status != StatusStopped {
+		replicas := int64(data.Spec().maxReplicas()) + surgePods(data)
+		cpu, memory, err := podLimits(data, plan)
+		if err != nil {
+			return result, err
+		}
+		result.pods += replicas
+		for i := int64(0); i < replicas; i++ {
+			result.cpu.Add(cpu)
+			result.memory.Add(memory)
+		}
+	}
+
+	result.services++
+	if data.IdleTimeoutMinutes > 0 {
+		result.services++ // wake service saat di-park
+	}
+
+	for _, volume := range data.Volumes {
+		if err := addQuantity(&result.storage, volume.Size, "", 1); err != nil {
+			return result, err
+		}
+	}
+
+	if data.DatabaseType != nil {
+		database := s.cfg.Addons.Postgres
+		result.pods++
+		result.services++
+		if err := addQuantity(&result.cpu, database.CPULimit, plan.DefaultCPULimit, 1); err != nil {
+			return result, err
+		}
+		if err := addQuantity(&result.memory, database.MemoryLimit, plan.DefaultMemoryLimit, 1); err != nil {
+			return result, err
+		}
+		if err := addQuantity(&result.storage, database.StorageSize, defaultDatabaseStorageSize, 1); err != nil {
+			return result, err
+		}
+	}
+
+	for _, addon := range addons {
+		cfg := s.addonConfig(addon.Type)
+		result.pods++
+		result.services++
+		// Add-on dibuat lewat k8s.CreateDeployment, default limits-nya sama dengan app
+		if err := addQuantity(&result.cpu, cfg.CPULimit, defaultAppCPULimit, 1); err != nil {
+			return result, err
+		}
+		if err := addQuantity(&result.memory, cfg.MemoryLimit, defaultAppMemoryLimit, 1); err != nil {
+			return result, err
+		}
+		if addon.Persistent {
+			if err := addQuantity(&result.storage, cfg.StorageSize, defaultAddonStorage, 1); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// surgePods - Pod tambahan selama rolling update (maxSurge default 25%, dibulatkan ke atas).
+// Deployment dengan volume ReadWriteOnce memakai Recreate, tidak ada surge.
+func surgePods(data *Deployment) int64 {
+	for _, volume := range data.Volumes {
+		switch volume.AccessMode {
+		case "", templates.AccessReadWriteOnce, templates.AccessReadWriteOncePod:
+			return 0
+		}
+	}
+	return (int64(data.Spec().maxReplicas()) + 3) / 4
+}
+
+// podLimits - Limits satu pod app: app + sidecar, atau init container terbesar kalau
+// lebih besar (init container jalan satu per satu sebelum app)
+func podLimits(data *Deployment, plan config.PlanConfig) (resource.Quantity, resource.Quantity, error) {
+	var cpu, memory resource.Quantity
+	if err := addQuantity(&cpu, data.CPULimit, defaultAppCPULimit, 1); err != nil {
+		return cpu, memory, err
+	}
+	if err := addQuantity(&memory, data.MemoryLimit, defaultAppMemoryLimit, 1); err != nil {
+		return cpu, memory, err
+	}
+
+	// Container tambahan tanpa limits memakai default LimitRange
+	for _, container := range data.Sidecars {
+		if err := addQuantity(&cpu, container.CPULimit, plan.DefaultCPULimit, 1); err != nil {
+			return cpu, memory, err
+		}
+		if err := addQuantity(&memory, container.MemoryLimit, plan.DefaultMemoryLimit, 1); err != nil {
+			return cpu, memory, err
+		}
+	}
+
+	for _, container := range data.InitContainers {
+		var initCPU, initMemory resource.Quantity
+		if err := addQuantity(&initCPU, container.CPULimit, plan.DefaultCPULimit, 1); err != nil {
+			return cpu, memory, err
+		}
+		if err := addQuantity(&initMemory, container.MemoryLimit, plan.DefaultMemoryLimit, 1); err != nil {
+			return cpu, memory, err
+		}
+		if initCPU.Cmp(cpu) > 0 {
+			cpu = initCPU
+		}
+		if initMemory.Cmp(memory) > 0 {
+			memory = initMemory
+		}
+	}
+
+	return cpu, memory, nil
+}
+
+// containerLimits - Limits satu container setelah default diterapkan
+type containerLimits struct {
+	name   string
+	cpu    string
+	memory string
+}
+
+// checkContainerLimits - LimitRange menolak pod yang limit salah satu container-nya di atas
+// max plan, ReplicaSet lalu gagal membuat pod sampai progress deadline. Dicek di API untuk
+// app, sidecar, init container, database, dan add-on.
+func (s Service) checkContainerLimits(data *Deployment, addons []Addon, name string, plan config.PlanConfig) error {
+	if plan.MaxCPU == "" && plan.MaxMemory == "" {
+		return nil
+	}
+
+	containers := []containerLimits{{
+		name:   "app",
+		cpu:    valueOr(data.CPULimit, defaultAppCPULimit),
+		memory: valueOr(data.MemoryLimit, defaultAppMemoryLimit),
+	}}
+	for _, list := range [][]templates.Container{data.Sidecars, data.InitContainers} {
+		for _, container := range list {
+			containers = append(containers, containerLimits{
+				name:   container.Name,
+				cpu:    valueOr(container.CPULimit, plan.DefaultCPULimit),
+				memory: valueOr(container.MemoryLimit, plan.DefaultMemoryLimit),
+			})
+		}
+	}
+	if data.DatabaseType != nil {
+		database := s.cfg.Addons.Postgres
+		containers = append(containers, containerLimits{
+			name:   "database",
+			cpu:    valueOr(database.CPULimit, plan.DefaultCPULimit),
+			memory: valueOr(database.MemoryLimit, plan.DefaultMemoryLimit),
+		})
+	}
+	for _, addon := range addons {
+		cfg := s.addonConfig(addon.Type)
+		containers = append(containers, containerLimits{
+			name:   addon.Type,
+			cpu:    valueOr(cfg.CPULimit, defaultAppCPULimit),
+			memory: valueOr(cfg.MemoryLimit, defaultAppMemoryLimit),
+		})
+	}
+
+	for _, container := range containers {
+		if err := exceedsMax(container.name, "cpu", container.cpu, plan.MaxCPU, name); err != nil {
+			return err
+		}
+		if err := exceedsMax(container.name, "memory", container.memory, plan.MaxMemory, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exceedsMax(container, kind, value, max, name string) error {
+	if value == "" || max == "" {
+		return nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return fmt.Errorf("%s: %q: %w", ErrInvalidResource, value, err)
+	}
+	limit, err := resource.ParseQuantity(max)
+	if err != nil {
+		return fmt.Errorf("invalid max %s %q in plan %s: %w", kind, max, name, err)
+	}
+	if quantity.Cmp(limit) > 0 {
+		return fmt.Errorf("%s: container %s %s limit %s exceeds max %s (plan %s)", ErrQuotaExceeded, container, kind, value, max, name)
+	}
+	return nil
+}
+
+func exceedsPlan(total footprint, name string, plan config.PlanConfig) error {
+	quantities := []struct {
+		kind  string
+		used  resource.Quantity
+		limit string
+	}{
+		{"cpu", total.cpu, plan.CPU},
+		{"memory", total.memory, plan.Memory},
+		{"storage", total.storage, plan.Storage},
+	}
+	for _, q := range quantities {
+		if q.limit == "" {
+			continue
+		}
+		limit, err := resource.ParseQuantity(q.limit)
+		if err != nil {
+			return fmt.Errorf("invalid %s quota %q in plan %s: %w", q.kind, q.limit, name, err)
+		}
+		if q.used.Cmp(limit) > 0 {
+			return fmt.Errorf("%s: %s %s of %s (plan %s)", ErrQuotaExceeded, q.kind, q.used.String(), q.limit, name)
+		}
+	}
+
+	counts := []struct {
+		kind  string
+		used  int64
+		limit int
+	}{
+		{"pods", total.pods, plan.Pods},
+		{"services", total.services, plan.Services},
+	}
+	for _, q := range counts {
+		if q.limit > 0 && q.used > int64(q.limit) {
+			return fmt.Errorf("%s: %s %d of %d (plan %s)", ErrQuotaExceeded, q.kind, q.used, q.limit, name)
+		}
+	}
+
+	return nil
+}
+
+// userPlan - Plan user dari DB; ok false kalau quota tidak dikonfigurasi
+func (s Service) userPlan(c context.Context, userId int) (string, config.PlanConfig, bool, error) {
+	name, err := s.repo.GetUserPlan(c, userId)
+	if err != nil {
+		return "", config.PlanConfig{}, false, err
+	}
+
+	name, plan, ok := s.resolvePlan(name)
+	return name, plan, ok, nil
+}
+
+// resolvePlan - Plan yang tidak ada di config memakai plan default
+func (s Service) resolvePlan(name string) (string, config.PlanConfig, bool) {
+	name = strings.ToLower(name)
+	if plan, ok := s.cfg.Plans.Tiers[name]; ok {
+		return name, plan, true
+	}
+
+	fallback := strings.ToLower(s.cfg.Plans.Default)
+	if plan, ok := s.cfg.Plans.Tiers[fallback]; ok {
+		return fallback, plan, true
+	}
+	return name, config.PlanConfig{}, false
+}
+
+// ensureQuota - Apply ResourceQuota & LimitRange kalau plan / config plan berubah sejak apply terakhir
+func (s Service) ensureQuota(c context.Context, namespace *corev1.Namespace, userId int, planName string) error {
+	name, plan, ok := s.resolvePlan(planName)
+	if !ok {
+		return nil
+	}
+
+	hash := quotaHash(plan)
+	if namespace.Labels[planLabel] == name && namespace.Labels[quotaHashLabel] == hash {
+		return nil
+	}
+
+	if err := s.k8s.ApplyResourceQuota(c, namespace.Name, quotaConfig(plan)); err != nil {
+		return err
+	}
+
+	log.Printf("applied plan %s quota to namespace %s (user %d)", name, namespace.Name, userId)
+	return s.k8s.UpdateNamespaceLabels(c, namespace.Name, map[string]string{
+		planLabel:      name,
+		quotaHashLabel: hash,
+	})
+}
+
+// ensureUserQuota - Dipanggil saat provision, setelah namespace user ada
+func (s Service) ensureUserQuota(c context.Context, userId int) error {
+	planName, err := s.repo.GetUserPlan(c, userId)
+	if err != nil {
+		return err
+	}
+
+	namespace, err := s.k8s.GetNamespace(c, userNamespace(userId))
+	if err != nil {
+		return err
+	}
+
+	return s.ensureQuota(c, namespace, userId, planName)
+}
+
+// syncQuotas - Periodic task: quota namespace mengikuti plan user di DB
+func (s Service) syncQuotas(c context.Context) error {
+	if len(s.cfg.Plans.Tiers) == 0 {
+		return nil
+	}
+
+	plans, err := s.repo.ListUserPlans(c)
+	if err != nil {
+		return err
+	}
+
+	namespaces, err := s.k8s.ListNamespaces(c, fmt.Sprintf("managed-by=%s", managedBy))
+	if err != nil {
+		return err
+	}
+
+	for i := range namespaces {
+		namespace := &namespaces[i]
+
+		var userId int
+		if _, err := fmt.Sscanf(namespace.Name, "user%d-workspaces", &userId); err != nil {
+			continue
+		}
+		planName, ok := plans[userId]
+		if !ok {
+			continue
+		}
+
+		if err := s.ensureQuota(c, namespace, userId, planName); err != nil {
+			log.Printf("failed to apply quota to namespace %s: %s", namespace.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s Service) quotaSyncInterval() time.Duration {
+	if s.cfg.Plans.SyncIntervalSeconds <= 0 {
+		return defaultQuotaSyncInterval
+	}
+	return time.Duration(s.cfg.Plans.SyncIntervalSeconds) * time.Second
+}
+
+func quotaConfig(plan config.PlanConfig) k8s.QuotaConfig {
+	return k8s.QuotaConfig{
+		CPU:                  plan.CPU,
+		Memory:               plan.Memory,
+		Storage:              plan.Storage,
+		Pods:                 plan.Pods,
+		Services:             plan.Services,
+		DefaultCPURequest:    plan.DefaultCPURequest,
+		DefaultCPULimit:      plan.DefaultCPULimit,
+		DefaultMemoryRequest: plan.DefaultMemoryRequest,
+		DefaultMemoryLimit:   plan.DefaultMemoryLimit,
+		MaxCPU:               plan.MaxCPU,
+		MaxMemory:            plan.MaxMemory,
+	}
+}
+
+// quotaHash - Label value pendek yang berubah kalau config plan berubah
+func quotaHash(plan config.PlanConfig) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v", plan)))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,251 @@
+package deployments
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+var testPlan = config.PlanConfig{
+	CPU:                "4",
+	Memory:             "2Gi",
+	Storage:            "10Gi",
+	Pods:               10,
+	Services:           6,
+	DefaultCPULimit:    "250m",
+	DefaultMemoryLimit: "256Mi",
+}
+
+// fakeQuotaRepo - Workspace lain milik user yang ikut dihitung di reservedFootprint
+type fakeQuotaRepo struct {
+	DeploymentRepository
+	deployments []Deployment
+	builds      int
+}
+
+func (r *fakeQuotaRepo) GetUserPlan(c context.Context, userId int) (string, error) {
+	return "free", nil
+}
+
+func (r *fakeQuotaRepo) ListByUser(c context.Context, userId int) ([]Deployment, error) {
+	return r.deployments, nil
+}
+
+func (r *fakeQuotaRepo) ListAddonsByUser(c context.Context, userId int) ([]Addon, error) {
+	return nil, nil
+}
+
+func (r *fakeQuotaRepo) CountActiveBuildsByUser(c context.Context, userId int) (int, error) {
+	return r.builds, nil
+}
+
+func testQuotaService(repo DeploymentRepository) Service {
+	cfg := config.Config{}
+	cfg.Plans.Tiers = map[string]config.PlanConfig{"free": testPlan}
+	return NewService(repo, nil, jobs.Service{}, nil, cfg)
+}
+
+func TestFootprint(t *testing.T) {
+	postgres := "postgres"
+
+	tests := []struct {
+		name       string
+		data       Deployment
+		addons     []Addon
+		wantPods   int64
+		wantCPU    string
+		wantMemory string
+		wantSvc    int64
+	}{
+		{
+			name:       "running with surge pod",
+			data:       Deployment{Status: StatusRunning, Replicas: 2, CPULimit: "500m", MemoryLimit: "512Mi"},
+			wantPods:   3,
+			wantCPU:    "1500m",
+			wantMemory: "1536Mi",
+			wantSvc:    1,
+		},
+		{
+			name:       "autoscaling reserves max replicas",
+			data:       Deployment{Status: StatusRunning, Replicas: 1, Autoscaling: &templates.Autoscaling{MinReplicas: 1, MaxReplicas: 4}},
+			wantPods:   5,
+			wantCPU:    "2500m",
+			wantMemory: "2560Mi",
+			wantSvc:    1,
+		},
+		{
+			name: "read write once volume has no surge",
+			data: Deployment{Status: StatusRunning, Replicas: 1, Volumes: []templates.Volume{
+				{Name: "data", Size: "1Gi", AccessMode: templates.AccessReadWriteOnce},
+			}},
+			wantPods:   1,
+			wantCPU:    "500m",
+			wantMemory: "512Mi",
+			wantSvc:    1,
+		},
+		{
+			name:       "stopped keeps database and addons reserved",
+			data:       Deployment{Status: StatusStopped, Replicas: 2, DatabaseType: &postgres, IdleTimeoutMinutes: 30},
+			addons:     []Addon{{Type: AddonRedis}},
+			wantPods:   2,
+			wantCPU:    "750m",
+			wantMemory: "768Mi",
+			wantSvc:    4,
+		},
+	}
+
+	service := testQuotaService(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage, err := service.footprint(&tt.data, tt.addons, testPlan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := usage.toMap()
+			if usage.pods != tt.wantPods || usage.services != tt.wantSvc {
+				t.Fatalf("expected %d pods / %d services, got %d / %d", tt.wantPods, tt.wantSvc, usage.pods, usage.services)
+			}
+			if got["cpu"] != tt.wantCPU || got["memory"] != tt.wantMemory {
+				t.Fatalf("expected cpu %s memory %s, got %s %s", tt.wantCPU, tt.wantMemory, got["cpu"], got["memory"])
+			}
+		})
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	existing := Deployment{Id: 1, UserId: 1, Name: "api", Status: StatusRunning, Replicas: 1, CPULimit: "1"}
+
+	tests := []struct {
+		name      string
+		others    []Deployment
+		builds    int
+		candidate Deployment
+		wantKind  string
+	}{
+		{
+			name:      "fits the plan",
+			others:    []Deployment{existing},
+			candidate: Deployment{Id: 2, UserId: 1, Status: StatusPending, Replicas: 1, CPULimit: "500m"},
+		},
+		{
+			name:      "cpu exceeded",
+			others:    []Deployment{existing},
+			candidate: Deployment{Id: 2, UserId: 1, Status: StatusPending, Replicas: 4, CPULimit: "500m"},
+			wantKind:  "cpu",
+		},
+		{
+			name:      "running builds count towards the plan",
+			others:    []Deployment{existing},
+			builds:    5,
+			candidate: Deployment{Id: 2, UserId: 1, Status: StatusPending, Replicas: 1, CPULimit: "500m"},
+			wantKind:  "cpu",
+		},
+		{
+			name:      "failed workspaces still count",
+			others:    []Deployment{{Id: 3, UserId: 1, Status: StatusFailed, Replicas: 3, CPULimit: "1"}},
+			candidate: Deployment{Id: 2, UserId: 1, Status: StatusPending, Replicas: 2, CPULimit: "500m"},
+			wantKind:  "cpu",
+		},
+		{
+			name:      "deleting workspaces still count",
+			others:    []Deployment{{Id: 4, UserId: 1, Status: StatusDeleting, Replicas: 3, CPULimit: "1"}},
+			candidate: Deployment{Id: 2, UserId: 1, Status: StatusPending, Replicas: 2, CPULimit: "500m"},
+			wantKind:  "cpu",
+		},
+		{
+			name:      "deleted workspaces are not counted",
+			others:    []Deployment{{Id: 5, UserId: 1, Status: StatusDeleted, Replicas: 3, CPULimit: "1"}},
+			candidate: Deployment{Id: 2, UserId: 1, Status: StatusPending, Replicas: 2, CPULimit: "500m"},
+		},
+		{
+			name:      "candidate itself is not counted twice",
+			others:    []Deployment{existing},
+			candidate: Deployment{Id: 1, UserId: 1, Status: StatusRunning, Replicas: 1, CPULimit: "1500m"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testQuotaService(&fakeQuotaRepo{deployments: tt.others, builds: tt.builds})
+
+			err := service.checkQuota(context.Background(), &tt.candidate, nil)
+			if tt.wantKind == "" {
+				if err != nil {
+					t.Fatalf("expected quota to fit, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), string(ErrQuotaExceeded)+": "+tt.wantKind) {
+				t.Fatalf("expected %s on %s, got %v", ErrQuotaExceeded, tt.wantKind, err)
+			}
+		})
+	}
+}
+
+func TestCheckContainerLimits(t *testing.T) {
+	plan := testPlan
+	plan.MaxCPU = "1"
+	plan.MaxMemory = "1Gi"
+	postgres := "postgres"
+
+	tests := []struct {
+		name          string
+		data          Deployment
+		addons        []Addon
+		addonCPULimit string
+		wantContainer string
+	}{
+		{
+			name: "within the max",
+			data: Deployment{CPULimit: "1", MemoryLimit: "1Gi", DatabaseType: &postgres},
+		},
+		{
+			name:          "app above max cpu",
+			data:          Deployment{CPULimit: "1500m"},
+			wantContainer: "app cpu",
+		},
+		{
+			name:          "app above max memory",
+			data:          Deployment{MemoryLimit: "2Gi"},
+			wantContainer: "app memory",
+		},
+		{
+			name:          "sidecar above max",
+			data:          Deployment{Sidecars: []templates.Container{{Name: "proxy", CPULimit: "2"}}},
+			wantContainer: "proxy cpu",
+		},
+		{
+			name:          "init container above max",
+			data:          Deployment{InitContainers: []templates.Container{{Name: "migrate", MemoryLimit: "4Gi"}}},
+			wantContainer: "migrate memory",
+		},
+		{
+			name:          "add-on above max",
+			addons:        []Addon{{Type: AddonRedis}},
+			addonCPULimit: "2",
+			wantContainer: AddonRedis + " cpu",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := testQuotaService(nil)
+			service.cfg.Addons.Redis.CPULimit = tt.addonCPULimit
+
+			err := service.checkContainerLimits(&tt.data, tt.addons, "free", plan)
+			if tt.wantContainer == "" {
+				if err != nil {
+					t.Fatalf("expected limits to fit, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), string(ErrQuotaExceeded)+": container "+tt.wantContainer) {
+				t.Fatalf("expected %s for %s, got %v", ErrQuotaExceeded, tt.wantContainer, err)
+			}
+		})
+	}
+}
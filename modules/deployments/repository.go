@@ -0,0 +1,1236 @@
+package deployments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(DB *sql.DB) DeploymentRepository {
+	return &Repository{DB: DB}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryRower - *sql.DB atau *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (r *Repository) Create(c context.Context, req Deployment, actor string) (*Deployment, error) {
+	envVars := req.EnvVars
+	if envVars == nil {
+		envVars = map[string]string{}
+	}
+
+	envVarsJSON, err := json.Marshal(envVars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal env vars: %w", err)
+	}
+
+	autoscalingJSON, err := nullableJSON(req.Autoscaling)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal autoscaling: %w", err)
+	}
+
+	probesJSON, err := nullableJSON(req.Probes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal probes: %w", err)
+	}
+
+	volumes := req.Volumes
+	if volumes == nil {
+		volumes = []templates.Volume{}
+	}
+	volumesJSON, err := json.Marshal(volumes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal volumes: %w", err)
+	}
+
+	annotations := req.IngressAnnotations
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotationsJSON, err := json.Marshal(annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ingress annotations: %w", err)
+	}
+
+	sidecars := req.Sidecars
+	if sidecars == nil {
+		sidecars = []templates.Container{}
+	}
+	sidecarsJSON, err := json.Marshal(sidecars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sidecars: %w", err)
+	}
+
+	initContainers := req.InitContainers
+	if initContainers == nil {
+		initContainers = []templates.Container{}
+	}
+	initContainersJSON, err := json.Marshal(initContainers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal init containers: %w", err)
+	}
+
+	tx, err := r.DB.BeginTx(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(
+		c,
+		queryCreate,
+		req.UserId,
+		req.TemplateId,
+		req.Name,
+		req.Namespace,
+		req.AppName,
+		req.Image,
+		req.Replicas,
+		req.ContainerPort,
+		req.Host,
+		req.CPURequest,
+		req.CPULimit,
+		req.MemoryRequest,
+		req.MemoryLimit,
+		envVarsJSON,
+		req.ConfigMapName,
+		req.SecretName,
+		req.DeploymentName,
+		req.ServiceName,
+		req.IngressName,
+		req.Status,
+		req.IdleTimeoutMinutes,
+		autoscalingJSON,
+		probesJSON,
+		volumesJSON,
+		req.DatabaseType,
+		annotationsJSON,
+		req.Subdomain,
+		sidecarsJSON,
+		initContainersJSON,
+		req.ParentId,
+		req.PreviewBranch,
+		req.PreviewPullRequest,
+		req.ExpiresAt,
+	).Scan(&req.Id, &req.CreatedAt, &req.UpdatedAt, &req.LastActivityAt)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_deployments_user_name") {
+			return nil, fmt.Errorf("%s: %s", ErrDeploymentExists, req.Name)
+		}
+		if strings.Contains(err.Error(), "idx_deployments_preview_branch") {
+			return nil, fmt.Errorf("%s: %s", ErrPreviewExists, *req.PreviewBranch)
+		}
+		if strings.Contains(err.Error(), "idx_deployments_subdomain") {
+			return nil, fmt.Errorf("%s: %s", ErrSubdomainTaken, *req.Subdomain)
+		}
+		if strings.Contains(err.Error(), "idx_deployments_host") {
+			return nil, fmt.Errorf("%s: %s", ErrSubdomainTaken, req.Host)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.ExecContext(c, queryInsertHistory, req.Id, "", req.Status, actor, "deployment created")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	req.EnvVars = envVars
+	req.CurrentRevision = 1
+	if _, err := insertRevision(c, tx, req.Id, 1, req.Spec(), actor, "initial deployment", nil); err != nil {
+		return nil, err
+	}
+
+	for i := range req.Addons {
+		addon := &req.Addons[i]
+		addon.DeploymentId = req.Id
+		if err := insertAddon(c, tx, addon); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &req, nil
+}
+
+func (r *Repository) FindById(c context.Context, id, userId int) (*Deployment, error) {
+	data, err := scanDeployment(r.DB.QueryRowContext(c, queryGetByID, id, userId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrDeploymentMissing, id)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return data, nil
+}
+
+func (r *Repository) FindByHost(c context.Context, host string) (*Deployment, error) {
+	data, err := scanDeployment(r.DB.QueryRowContext(c, queryGetByHost, host))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: host %s", ErrDeploymentMissing, host)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return data, nil
+}
+
+func (r *Repository) ListByUser(c context.Context, userId int) ([]Deployment, error) {
+	return r.list(c, queryListByUser, userId)
+}
+
+// ListPreviews - Preview environment aktif milik workspace parent
+func (r *Repository) ListPreviews(c context.Context, parentId int) ([]Deployment, error) {
+	return r.list(c, queryListPreviews, parentId)
+}
+
+func (r *Repository) ListExpiredPreviews(c context.Context) ([]Deployment, error) {
+	return r.list(c, queryListExpiredPreviews)
+}
+
+func (r *Repository) ListOrphanedPreviews(c context.Context) ([]Deployment, error) {
+	return r.list(c, queryListOrphanedPreviews)
+}
+
+func (r *Repository) ListIdle(c context.Context) ([]Deployment, error) {
+	return r.list(c, queryListIdle)
+}
+
+func (r *Repository) TouchActivity(c context.Context, id int) error {
+	if _, err := r.DB.ExecContext(c, queryTouchActivity, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) SetSleeping(c context.Context, id int, sleeping bool) error {
+	if _, err := r.DB.ExecContext(c, querySetSleeping, sleeping, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) SetIdleTimeout(c context.Context, id, minutes int) error {
+	if _, err := r.DB.ExecContext(c, querySetIdleTimeout, minutes, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) list(c context.Context, query string, args ...interface{}) ([]Deployment, error) {
+	rows, err := r.DB.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []Deployment{}
+	for rows.Next() {
+		data, err := scanDeployment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deployment: %w", err)
+		}
+		results = append(results, *data)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deployments: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) SumReplicasByUser(c context.Context, userId, excludeId int) (int, error) {
+	var total int
+	if err := r.DB.QueryRowContext(c, querySumReplicasByUser, userId, excludeId).Scan(&total); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return total, nil
+}
+
+// Transition - Update status dan catat history dalam satu transaction.
+// Gagal dengan ErrStatusConflict kalau status saat ini bukan `from`.
+func (r *Repository) Transition(c context.Context, id int, from, to, actor, reason string) error {
+	tx, err := r.DB.BeginTx(c, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer tx.Rollback()
+
+	if err := transitionTx(c, tx, id, from, to, actor, reason); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+// transitionTx - Compare-and-swap status lalu catat history di transaksi yang sama
+func transitionTx(c context.Context, tx *sql.Tx, id int, from, to, actor, reason string) error {
+	result, err := tx.ExecContext(c, queryTransition, to, reason, id, from)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: deployment %d is no longer %s", ErrStatusConflict, id, from)
+	}
+
+	if _, err := tx.ExecContext(c, queryInsertHistory, id, from, to, actor, reason); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListHistory(c context.Context, id int) ([]StatusTransition, error) {
+	rows, err := r.DB.QueryContext(c, queryListHistory, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []StatusTransition{}
+	for rows.Next() {
+		var data StatusTransition
+		err := rows.Scan(
+			&data.Id,
+			&data.DeploymentId,
+			&data.FromStatus,
+			&data.ToStatus,
+			&data.Actor,
+			&data.Reason,
+			&data.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		results = append(results, data)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating status history: %w", err)
+	}
+
+	return results, nil
+}
+
+// SaveRevision - Simpan spec sebagai revision baru (nomor = current + 1), jadikan spec aktif
+// di tabel deployments, dan transition from → updating. Semuanya satu transaksi: kalau status
+// sudah berubah (ErrStatusConflict) revision tidak ikut tersimpan.
+func (r *Repository) SaveRevision(c context.Context, id int, from string, spec DeploymentSpec, actor, changeCause string, rollbackOf *int) (*Revision, error) {
+	tx, err := r.DB.BeginTx(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer tx.Rollback()
+
+	// Lock row deployment supaya nomor revision tidak bentrok
+	var current int
+	if err := tx.QueryRowContext(c, queryGetByIDForUpdate, id).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrDeploymentMissing, id)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	revision, err := insertRevision(c, tx, id, current+1, spec, actor, changeCause, rollbackOf)
+	if err != nil {
+		return nil, err
+	}
+
+	envVarsJSON, err := json.Marshal(spec.EnvVars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal env vars: %w", err)
+	}
+
+	autoscalingJSON, err := nullableJSON(spec.Autoscaling)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal autoscaling: %w", err)
+	}
+
+	_, err = tx.ExecContext(c, queryUpdateSpec,
+		spec.Image,
+		spec.Replicas,
+		spec.CPURequest,
+		spec.CPULimit,
+		spec.MemoryRequest,
+		spec.MemoryLimit,
+		envVarsJSON,
+		revision.Revision,
+		autoscalingJSON,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	if err := transitionTx(c, tx, id, from, StatusUpdating, actor, revisionReason(revision.Revision, changeCause)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return revision, nil
+}
+
+func (r *Repository) ListRevisions(c context.Context, id int) ([]Revision, error) {
+	rows, err := r.DB.QueryContext(c, queryListRevisions, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []Revision{}
+	for rows.Next() {
+		data, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan revision: %w", err)
+		}
+		results = append(results, *data)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating revisions: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) GetRevision(c context.Context, id, revision int) (*Revision, error) {
+	data, err := scanRevision(r.DB.QueryRowContext(c, queryGetRevision, id, revision))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: revision %d", ErrRevisionNotFound, revision)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return data, nil
+}
+
+func insertRevision(c context.Context, tx *sql.Tx, id, number int, spec DeploymentSpec, actor, changeCause string, rollbackOf *int) (*Revision, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal revision spec: %w", err)
+	}
+
+	revision := Revision{
+		DeploymentId: id,
+		Revision:     number,
+		Spec:         spec,
+		RollbackOf:   rollbackOf,
+		CreatedBy:    actor,
+	}
+	if changeCause != "" {
+		revision.ChangeCause = &changeCause
+	}
+
+	err = tx.QueryRowContext(c, queryInsertRevision, id, number, specJSON, changeCause, rollbackOf, actor).
+		Scan(&revision.Id, &revision.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &revision, nil
+}
+
+func scanRevision(row rowScanner) (*Revision, error) {
+	var data Revision
+	var specJSON []byte
+
+	err := row.Scan(
+		&data.Id,
+		&data.DeploymentId,
+		&data.Revision,
+		&specJSON,
+		&data.ChangeCause,
+		&data.RollbackOf,
+		&data.CreatedBy,
+		&data.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(specJSON, &data.Spec); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revision spec: %w", err)
+	}
+
+	return &data, nil
+}
+
+func scanDeployment(row rowScanner) (*Deployment, error) {
+	var data Deployment
+	var envVarsJSON, autoscalingJSON, probesJSON, volumesJSON, annotationsJSON []byte
+	var sidecarsJSON, initContainersJSON []byte
+	var cpuRequest, cpuLimit, memoryRequest, memoryLimit sql.NullString
+
+	err := row.Scan(
+		&data.Id,
+		&data.UserId,
+		&data.TemplateId,
+		&data.Name,
+		&data.Namespace,
+		&data.AppName,
+		&data.Image,
+		&data.Replicas,
+		&data.ContainerPort,
+		&data.Host,
+		&cpuRequest,
+		&cpuLimit,
+		&memoryRequest,
+		&memoryLimit,
+		&envVarsJSON,
+		&data.ConfigMapName,
+		&data.SecretName,
+		&data.DeploymentName,
+		&data.ServiceName,
+		&data.IngressName,
+		&data.Status,
+		&data.StatusMessage,
+		&data.CurrentRevision,
+		&data.IdleTimeoutMinutes,
+		&data.Sleeping,
+		&data.LastActivityAt,
+		&autoscalingJSON,
+		&probesJSON,
+		&volumesJSON,
+		&data.DatabaseType,
+		&annotationsJSON,
+		&data.Subdomain,
+		&sidecarsJSON,
+		&initContainersJSON,
+		&data.ParentId,
+		&data.PreviewBranch,
+		&data.PreviewPullRequest,
+		&data.ExpiresAt,
+		&data.CreatedAt,
+		&data.UpdatedAt,
+		&data.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	data.CPURequest = cpuRequest.String
+	data.CPULimit = cpuLimit.String
+	data.MemoryRequest = memoryRequest.String
+	data.MemoryLimit = memoryLimit.String
+
+	if len(envVarsJSON) > 0 {
+		if err := json.Unmarshal(envVarsJSON, &data.EnvVars); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal env_vars: %w", err)
+		}
+	}
+	if len(autoscalingJSON) > 0 {
+		if err := json.Unmarshal(autoscalingJSON, &data.Autoscaling); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal autoscaling: %w", err)
+		}
+	}
+	if len(probesJSON) > 0 {
+		if err := json.Unmarshal(probesJSON, &data.Probes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal probes: %w", err)
+		}
+	}
+	if len(volumesJSON) > 0 {
+		if err := json.Unmarshal(volumesJSON, &data.Volumes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal volumes: %w", err)
+		}
+	}
+	if len(annotationsJSON) > 0 {
+		if err := json.Unmarshal(annotationsJSON, &data.IngressAnnotations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ingress_annotations: %w", err)
+		}
+	}
+	if len(sidecarsJSON) > 0 {
+		if err := json.Unmarshal(sidecarsJSON, &data.Sidecars); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sidecars: %w", err)
+		}
+	}
+	if len(initContainersJSON) > 0 {
+		if err := json.Unmarshal(initContainersJSON, &data.InitContainers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal init_containers: %w", err)
+		}
+	}
+
+	return &data, nil
+}
+
+// nullableJSON - Marshal value ke jsonb, nil pointer disimpan sebagai NULL
+func nullableJSON(v interface{}) ([]byte, error) {
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func (r *Repository) CreateAddon(c context.Context, addon Addon) (*Addon, error) {
+	if err := insertAddon(c, r.DB, &addon); err != nil {
+		return nil, err
+	}
+
+	return &addon, nil
+}
+
+func insertAddon(c context.Context, db queryRower, addon *Addon) error {
+	err := db.QueryRowContext(c, queryInsertAddon, addon.DeploymentId, addon.Type, addon.Persistent, addon.Status).
+		Scan(&addon.Id, &addon.CreatedAt, &addon.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_deployment_addons_type") {
+			return fmt.Errorf("%s: %s", ErrAddonExists, addon.Type)
+		}
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListAddons(c context.Context, deploymentId int) ([]Addon, error) {
+	return r.listAddons(c, queryListAddons, deploymentId)
+}
+
+// ListAddonsByUser - Add-on dari semua deployment user yang belum dihapus
+func (r *Repository) ListAddonsByUser(c context.Context, userId int) ([]Addon, error) {
+	return r.listAddons(c, queryListAddonsByUser, userId)
+}
+
+func (r *Repository) listAddons(c context.Context, query string, args ...interface{}) ([]Addon, error) {
+	rows, err := r.DB.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []Addon{}
+	for rows.Next() {
+		addon, err := scanAddon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan addon: %w", err)
+		}
+		results = append(results, *addon)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating addons: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) GetAddon(c context.Context, deploymentId int, addonType string) (*Addon, error) {
+	addon, err := scanAddon(r.DB.QueryRowContext(c, queryGetAddon, deploymentId, addonType))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %s", ErrAddonMissing, addonType)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return addon, nil
+}
+
+func (r *Repository) SetAddonStatus(c context.Context, id int, status, message string) error {
+	if _, err := r.DB.ExecContext(c, querySetAddonStatus, status, message, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteAddon(c context.Context, id int) error {
+	if _, err := r.DB.ExecContext(c, queryDeleteAddon, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func scanAddon(row rowScanner) (*Addon, error) {
+	var addon Addon
+	err := row.Scan(
+		&addon.Id,
+		&addon.DeploymentId,
+		&addon.Type,
+		&addon.Persistent,
+		&addon.Status,
+		&addon.StatusMessage,
+		&addon.CreatedAt,
+		&addon.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &addon, nil
+}
+
+func (r *Repository) SubdomainExists(c context.Context, subdomain string) (bool, error) {
+	var exists bool
+	if err := r.DB.QueryRowContext(c, querySubdomainExists, subdomain).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return exists, nil
+}
+
+// UpdateSubdomain - Subdomain & host diganti bersamaan, unique index menolak subdomain yang sudah dipakai
+func (r *Repository) UpdateSubdomain(c context.Context, id int, subdomain, host string) error {
+	if _, err := r.DB.ExecContext(c, queryUpdateSubdomain, subdomain, host, id); err != nil {
+		if strings.Contains(err.Error(), "idx_deployments_subdomain") || strings.Contains(err.Error(), "idx_deployments_host") {
+			return fmt.Errorf("%s: %s", ErrSubdomainTaken, subdomain)
+		}
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) GetUsername(c context.Context, userId int) (string, error) {
+	var username string
+	if err := r.DB.QueryRowContext(c, queryGetUsername, userId).Scan(&username); err != nil {
+		return "", fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return username, nil
+}
+
+func (r *Repository) GetUserPlan(c context.Context, userId int) (string, error) {
+	var plan string
+	if err := r.DB.QueryRowContext(c, queryGetUserPlan, userId).Scan(&plan); err != nil {
+		return "", fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return plan, nil
+}
+
+// LockUser - Lock row user sampai fungsi yang dikembalikan dipanggil
+func (r *Repository) LockUser(c context.Context, userId int) (func(), error) {
+	tx, err := r.DB.BeginTx(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var id int
+	if err := tx.QueryRowContext(c, queryLockUser, userId).Scan(&id); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return func() { tx.Rollback() }, nil
+}
+
+func (r *Repository) ListUserPlans(c context.Context) (map[int]string, error) {
+	rows, err := r.DB.QueryContext(c, queryListUserPlans)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	plans := map[int]string{}
+	for rows.Next() {
+		var (
+			userId int
+			plan   string
+		)
+		if err := rows.Scan(&userId, &plan); err != nil {
+			return nil, fmt.Errorf("failed to scan plan: %w", err)
+		}
+		plans[userId] = plan
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plans: %w", err)
+	}
+
+	return plans, nil
+}
+
+func (r *Repository) CreateEgressRule(c context.Context, rule EgressRule) (*EgressRule, error) {
+	description := ""
+	if rule.Description != nil {
+		description = *rule.Description
+	}
+
+	err := r.DB.QueryRowContext(c, queryInsertEgressRule, rule.UserId, rule.CIDR, rule.Port, rule.Protocol, description).
+		Scan(&rule.Id, &rule.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_namespace_egress_rules_destination") {
+			return nil, fmt.Errorf("%s: %s", ErrEgressExists, rule.CIDR)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &rule, nil
+}
+
+func (r *Repository) ListEgressRules(c context.Context, userId int) ([]EgressRule, error) {
+	rows, err := r.DB.QueryContext(c, queryListEgressRules, userId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []EgressRule{}
+	for rows.Next() {
+		var rule EgressRule
+		err := rows.Scan(
+			&rule.Id,
+			&rule.UserId,
+			&rule.CIDR,
+			&rule.Port,
+			&rule.Protocol,
+			&rule.Description,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan egress rule: %w", err)
+		}
+		results = append(results, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating egress rules: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) DeleteEgressRule(c context.Context, id, userId int) error {
+	result, err := r.DB.ExecContext(c, queryDeleteEgressRule, id, userId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%s: id %d", ErrEgressMissing, id)
+	}
+
+	return nil
+}
+
+func (r *Repository) CreateDomain(c context.Context, domain Domain) (*Domain, error) {
+	err := r.DB.QueryRowContext(c, queryInsertDomain, domain.DeploymentId, domain.Domain, domain.VerificationToken, domain.Status).
+		Scan(&domain.Id, &domain.CreatedAt, &domain.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_deployment_domains_domain") {
+			return nil, fmt.Errorf("%s: %s", ErrDomainExists, domain.Domain)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &domain, nil
+}
+
+func (r *Repository) ListDomains(c context.Context, deploymentId int) ([]Domain, error) {
+	return r.listDomains(c, queryListDomains, deploymentId)
+}
+
+// ListPendingDomains - Domain pending yang dibuat setelah since, untuk re-check periodik
+func (r *Repository) ListPendingDomains(c context.Context, since time.Time) ([]Domain, error) {
+	return r.listDomains(c, queryListPendingDomains, since)
+}
+
+func (r *Repository) listDomains(c context.Context, query string, args ...interface{}) ([]Domain, error) {
+	rows, err := r.DB.QueryContext(c, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []Domain{}
+	for rows.Next() {
+		domain, err := scanDomain(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan domain: %w", err)
+		}
+		results = append(results, *domain)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating domains: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) GetDomain(c context.Context, deploymentId, id int) (*Domain, error) {
+	domain, err := scanDomain(r.DB.QueryRowContext(c, queryGetDomain, deploymentId, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrDomainMissing, id)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return domain, nil
+}
+
+// SetDomainStatus - Status verified ditolak (ErrDomainExists) kalau domain sudah
+// diverifikasi oleh workspace lain
+func (r *Repository) SetDomainStatus(c context.Context, id int, status, message string) error {
+	if _, err := r.DB.ExecContext(c, querySetDomainStatus, status, message, id); err != nil {
+		if strings.Contains(err.Error(), "idx_deployment_domains_verified") {
+			return fmt.Errorf("%s: verified by another workspace", ErrDomainExists)
+		}
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteDomain(c context.Context, id int) error {
+	if _, err := r.DB.ExecContext(c, queryDeleteDomain, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+// DeleteDomainsByDeployment - Lepas semua domain saat workspace dihapus
+func (r *Repository) DeleteDomainsByDeployment(c context.Context, deploymentId int) error {
+	if _, err := r.DB.ExecContext(c, queryDeleteDomainsByDeployment, deploymentId); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) CreateBuild(c context.Context, build Build) (*Build, error) {
+	var commit string
+	if build.GitCommit != nil {
+		commit = *build.GitCommit
+	}
+
+	err := r.DB.QueryRowContext(c, queryInsertBuild,
+		build.DeploymentId,
+		build.Status,
+		build.Trigger,
+		build.GitRepoURL,
+		build.GitBranch,
+		commit,
+		build.DockerfilePath,
+		build.Image,
+		build.Deploy,
+		build.CreatedBy,
+	).Scan(&build.Id, &build.CreatedAt, &build.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &build, nil
+}
+
+func (r *Repository) ListBuilds(c context.Context, deploymentId int) ([]Build, error) {
+	rows, err := r.DB.QueryContext(c, queryListBuilds, deploymentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []Build{}
+	for rows.Next() {
+		build, err := scanBuild(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan build: %w", err)
+		}
+		results = append(results, *build)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating builds: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) GetBuild(c context.Context, deploymentId, id int) (*Build, error) {
+	build, err := scanBuild(r.DB.QueryRowContext(c, queryGetBuild, deploymentId, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrBuildMissing, id)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return build, nil
+}
+
+// HasActiveBuild - Masih ada build queued/running untuk deployment
+func (r *Repository) HasActiveBuild(c context.Context, deploymentId int) (bool, error) {
+	var exists bool
+	if err := r.DB.QueryRowContext(c, queryHasActiveBuild, deploymentId).Scan(&exists); err != nil {
+		return false, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return exists, nil
+}
+
+// CountActiveBuildsByUser - Build queued/running dari semua workspace user
+func (r *Repository) CountActiveBuildsByUser(c context.Context, userId int) (int, error) {
+	var count int
+	if err := r.DB.QueryRowContext(c, queryCountActiveBuildsByUser, userId).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return count, nil
+}
+
+func (r *Repository) StartBuild(c context.Context, id int, jobName string) error {
+	if _, err := r.DB.ExecContext(c, queryStartBuild, jobName, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) FinishBuild(c context.Context, id int, status, message, digest string) error {
+	if _, err := r.DB.ExecContext(c, queryFinishBuild, status, message, digest, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+// SetBuildDeployed - Revision nil = image tidak di-apply, alasannya di message
+func (r *Repository) SetBuildDeployed(c context.Context, id int, revision *int, message string) error {
+	if _, err := r.DB.ExecContext(c, querySetBuildDeployed, revision, message, id); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) SaveBuildLog(c context.Context, log BuildLog) error {
+	if _, err := r.DB.ExecContext(c, queryUpsertBuildLog, log.BuildId, log.Content, log.SizeBytes, log.Truncated); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+// GetBuildLog - nil kalau log belum disimpan (build belum selesai / gagal sebelum pod jalan)
+func (r *Repository) GetBuildLog(c context.Context, buildId int) (*BuildLog, error) {
+	var log BuildLog
+	err := r.DB.QueryRowContext(c, queryGetBuildLog, buildId).
+		Scan(&log.BuildId, &log.Content, &log.SizeBytes, &log.Truncated, &log.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &log, nil
+}
+
+func scanBuild(row rowScanner) (*Build, error) {
+	var build Build
+	err := row.Scan(
+		&build.Id,
+		&build.DeploymentId,
+		&build.Status,
+		&build.StatusMessage,
+		&build.Trigger,
+		&build.GitRepoURL,
+		&build.GitBranch,
+		&build.GitCommit,
+		&build.DockerfilePath,
+		&build.Image,
+		&build.ImageDigest,
+		&build.JobName,
+		&build.Deploy,
+		&build.DeployedRevision,
+		&build.CreatedBy,
+		&build.StartedAt,
+		&build.FinishedAt,
+		&build.CreatedAt,
+		&build.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &build, nil
+}
+
+func (r *Repository) GetWebhook(c context.Context, deploymentId int) (*Webhook, error) {
+	var webhook Webhook
+	err := r.DB.QueryRowContext(c, queryGetWebhook, deploymentId).
+		Scan(&webhook.DeploymentId, &webhook.UserId, &webhook.Secret, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: deployment %d", ErrWebhookMissing, deploymentId)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &webhook, nil
+}
+
+// SaveWebhook - Buat webhook atau rotasi secret yang sudah ada
+func (r *Repository) SaveWebhook(c context.Context, deploymentId int, secret string) (*Webhook, error) {
+	webhook := Webhook{DeploymentId: deploymentId, Secret: secret}
+	err := r.DB.QueryRowContext(c, queryUpsertWebhook, deploymentId, secret).
+		Scan(&webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &webhook, nil
+}
+
+func (r *Repository) DeleteWebhook(c context.Context, deploymentId int) error {
+	if _, err := r.DB.ExecContext(c, queryDeleteWebhook, deploymentId); err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) CreateDelivery(c context.Context, delivery WebhookDelivery) (*WebhookDelivery, error) {
+	err := r.DB.QueryRowContext(c, queryInsertDelivery,
+		delivery.DeploymentId,
+		delivery.Provider,
+		delivery.Event,
+		delivery.DeliveryId,
+		delivery.Status,
+		delivery.StatusMessage,
+		delivery.Ref,
+		delivery.CommitSha,
+		delivery.BuildId,
+		delivery.RedeliveryOf,
+		delivery.Payload,
+		delivery.PayloadDigest,
+	).Scan(&delivery.Id, &delivery.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_deployment_webhook_deliveries_delivery_id") ||
+			strings.Contains(err.Error(), "idx_deployment_webhook_deliveries_payload") {
+			return nil, fmt.Errorf("%s", ErrDeliveryReplayed)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return &delivery, nil
+}
+
+// UpdateDeliveryResult - Hasil proses delivery yang sudah dicatat CreateDelivery
+func (r *Repository) UpdateDeliveryResult(c context.Context, delivery WebhookDelivery) error {
+	_, err := r.DB.ExecContext(c, queryUpdateDeliveryResult,
+		delivery.Status,
+		delivery.StatusMessage,
+		delivery.Ref,
+		delivery.CommitSha,
+		delivery.BuildId,
+		delivery.Id,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+func (r *Repository) ListDeliveries(c context.Context, deploymentId int) ([]WebhookDelivery, error) {
+	rows, err := r.DB.QueryContext(c, queryListDeliveries, deploymentId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	results := []WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		results = append(results, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) GetDelivery(c context.Context, deploymentId, id int) (*WebhookDelivery, error) {
+	delivery, err := scanDelivery(r.DB.QueryRowContext(c, queryGetDelivery, deploymentId, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrDeliveryMissing, id)
+		}
+		return nil, fmt.Errorf("%s: %w", ErrQueryFailed, err)
+	}
+
+	return delivery, nil
+}
+
+func scanDelivery(row rowScanner) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := row.Scan(
+		&delivery.Id,
+		&delivery.DeploymentId,
+		&delivery.Provider,
+		&delivery.Event,
+		&delivery.DeliveryId,
+		&delivery.Status,
+		&delivery.StatusMessage,
+		&delivery.Ref,
+		&delivery.CommitSha,
+		&delivery.BuildId,
+		&delivery.RedeliveryOf,
+		&delivery.Payload,
+		&delivery.PayloadDigest,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func scanDomain(row rowScanner) (*Domain, error) {
+	var domain Domain
+	err := row.Scan(
+		&domain.Id,
+		&domain.DeploymentId,
+		&domain.UserId,
+		&domain.Domain,
+		&domain.VerificationToken,
+		&domain.Status,
+		&domain.StatusMessage,
+		&domain.VerifiedAt,
+		&domain.LastCheckedAt,
+		&domain.CreatedAt,
+		&domain.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain, nil
+}
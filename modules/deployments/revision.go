@@ -0,0 +1,239 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Update - Simpan spec baru sebagai revision lalu enqueue job untuk apply ke cluster
+func (s Service) Update(c context.Context, id, userId int, req UpdateDeploymentRequest) (*DeploymentJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNotStopped(data); err != nil {
+		return nil, err
+	}
+
+	spec := data.Spec()
+	if req.Image != nil {
+		spec.Image = *req.Image
+	}
+	if req.Autoscaling != nil {
+		spec.Autoscaling = req.Autoscaling
+		if req.Autoscaling.MaxReplicas == 0 {
+			spec.Autoscaling = nil
+		}
+	}
+	if req.Replicas != nil {
+		if spec.Autoscaling != nil {
+			return nil, fmt.Errorf("%s: update autoscaling min/max replicas instead", ErrAutoscaleEnabled)
+		}
+		spec.Replicas = *req.Replicas
+	}
+	if spec.Autoscaling != nil {
+		spec.Replicas = spec.Autoscaling.MinReplicas
+	}
+	if req.CPURequest != nil {
+		spec.CPURequest = *req.CPURequest
+	}
+	if req.CPULimit != nil {
+		spec.CPULimit = *req.CPULimit
+	}
+	if req.MemoryRequest != nil {
+		spec.MemoryRequest = *req.MemoryRequest
+	}
+	if req.MemoryLimit != nil {
+		spec.MemoryLimit = *req.MemoryLimit
+	}
+
+	if req.EnvVars != nil {
+		tmpl, err := s.templates.FindById(c, data.TemplateId)
+		if err != nil {
+			return nil, err
+		}
+
+		// Secret hanya disimpan di K8s Secret, tidak boleh masuk ke revision
+		for key := range req.EnvVars {
+			if prop, ok := tmpl.EnvVarsSchema[key]; ok && prop.Secret {
+				return nil, fmt.Errorf("%s: %s", ErrSecretEnvVar, key)
+			}
+		}
+		configData, err := resolveConfigEnvVars(tmpl.EnvVarsSchema, req.EnvVars)
+		if err != nil {
+			return nil, err
+		}
+		spec.EnvVars = configData
+	}
+
+	if err := validateSpec(spec); err != nil {
+		return nil, err
+	}
+
+	unlock, err := s.lockQuota(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if spec.maxReplicas() != data.Spec().maxReplicas() {
+		if err := s.checkReplicaLimit(c, data, spec.maxReplicas()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkSpecQuota(c, data, spec); err != nil {
+		return nil, err
+	}
+
+	changeCause := req.ChangeCause
+	if changeCause == "" {
+		changeCause = "update deployment"
+	}
+
+	return s.applyRevision(c, data, spec, userActor(userId), changeCause, nil)
+}
+
+// Rollback - Re-apply spec dari revision lama sebagai revision baru
+func (s Service) Rollback(c context.Context, id, userId int, req RollbackRequest) (*DeploymentJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.repo.GetRevision(c, data.Id, req.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Revision == data.CurrentRevision {
+		return nil, fmt.Errorf("%s: %d", ErrAlreadyAtRevision, target.Revision)
+	}
+	if err := checkNotStopped(data); err != nil {
+		return nil, err
+	}
+
+	unlock, err := s.lockQuota(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.checkReplicaLimit(c, data, target.Spec.maxReplicas()); err != nil {
+		return nil, err
+	}
+	if err := s.checkSpecQuota(c, data, target.Spec); err != nil {
+		return nil, err
+	}
+
+	changeCause := fmt.Sprintf("rollback to revision %d", target.Revision)
+	return s.applyRevision(c, data, target.Spec, userActor(userId), changeCause, &target.Revision)
+}
+
+func (s Service) ListRevisions(c context.Context, id, userId int) ([]Revision, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListRevisions(c, id)
+}
+
+func (s Service) applyRevision(c context.Context, data *Deployment, spec DeploymentSpec, actor, changeCause string, rollbackOf *int) (*DeploymentJob, error) {
+	if err := checkNotStopped(data); err != nil {
+		return nil, err
+	}
+
+	// Check dulu sebelum revision disimpan, supaya tidak ada revision yang tidak pernah di-apply
+	if err := validateTransition(data.Status, StatusUpdating); err != nil {
+		return nil, err
+	}
+
+	// Revision & transition ke updating disimpan dalam satu transaksi
+	revision, err := s.repo.SaveRevision(c, data.Id, data.Status, spec, actor, changeCause, rollbackOf)
+	if err != nil {
+		return nil, err
+	}
+	data.applySpec(spec)
+	data.CurrentRevision = revision.Revision
+
+	reason := revisionReason(revision.Revision, changeCause)
+	data.Status = StatusUpdating
+	data.StatusMessage = &reason
+
+	job, err := s.enqueue(c, JobUpdate, data, updatePayload{Revision: revision.Revision})
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	return &DeploymentJob{Deployment: data, Job: job}, nil
+}
+
+// revisionReason - Reason di status history saat revision di-apply
+func revisionReason(revision int, changeCause string) string {
+	return fmt.Sprintf("revision %d: %s", revision, changeCause)
+}
+
+// checkNotStopped - Apply di workspace yang di-pause / sleep akan menyalakan app tanpa
+// database & add-on, dan pod app-nya tidak ikut dihitung di quota. Harus resume dulu.
+func checkNotStopped(data *Deployment) error {
+	if data.Status == StatusStopped {
+		return fmt.Errorf("%s: deployment is %s, resume it first", ErrInvalidTransition, data.Status)
+	}
+	return nil
+}
+
+func validateSpec(spec DeploymentSpec) error {
+	if spec.Image == "" {
+		return fmt.Errorf("%s: image cannot be empty", ErrInvalidImage)
+	}
+
+	if spec.Replicas < 1 {
+		return fmt.Errorf("%s: replicas must be at least 1", ErrInvalidReplicas)
+	}
+
+	quantities := map[string]string{
+		"cpuRequest":    spec.CPURequest,
+		"cpuLimit":      spec.CPULimit,
+		"memoryRequest": spec.MemoryRequest,
+		"memoryLimit":   spec.MemoryLimit,
+	}
+	for field, value := range quantities {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("%s: %s %q", ErrInvalidResource, field, value)
+		}
+	}
+
+	return validateAutoscaling(spec.Autoscaling)
+}
+
+func validateAutoscaling(scaling *templates.Autoscaling) error {
+	if scaling == nil {
+		return nil
+	}
+
+	if scaling.MinReplicas < 1 {
+		return fmt.Errorf("%s: minReplicas must be at least 1", ErrInvalidAutoscale)
+	}
+	if scaling.MaxReplicas < scaling.MinReplicas {
+		return fmt.Errorf("%s: maxReplicas must be at least minReplicas", ErrInvalidAutoscale)
+	}
+	if scaling.TargetCPUUtilization == nil && scaling.TargetMemoryUtilization == nil {
+		return fmt.Errorf("%s: targetCpuUtilization or targetMemoryUtilization is required", ErrInvalidAutoscale)
+	}
+
+	targets := map[string]*int{
+		"targetCpuUtilization":    scaling.TargetCPUUtilization,
+		"targetMemoryUtilization": scaling.TargetMemoryUtilization,
+	}
+	for field, value := range targets {
+		if value != nil && (*value < 1 || *value > 100) {
+			return fmt.Errorf("%s: %s must be between 1 and 100", ErrInvalidAutoscale, field)
+		}
+	}
+
+	return nil
+}
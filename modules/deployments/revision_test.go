@@ -0,0 +1,108 @@
+package deployments
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestApplyRevision(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		concurrent   string
+		wantErr      ErrorMessage
+		wantRevision int
+		wantStatus   string
+	}{
+		{
+			name:         "running",
+			status:       StatusRunning,
+			wantRevision: 2,
+			wantStatus:   StatusUpdating,
+		},
+		{
+			name:         "degraded",
+			status:       StatusDegraded,
+			wantRevision: 2,
+			wantStatus:   StatusUpdating,
+		},
+		{
+			name:         "status changed concurrently",
+			status:       StatusRunning,
+			concurrent:   StatusPausing,
+			wantErr:      ErrStatusConflict,
+			wantRevision: 1,
+			wantStatus:   StatusPausing,
+		},
+		{
+			name:         "stopped",
+			status:       StatusStopped,
+			wantErr:      ErrInvalidTransition,
+			wantRevision: 1,
+			wantStatus:   StatusStopped,
+		},
+		{
+			name:         "pausing",
+			status:       StatusPausing,
+			wantErr:      ErrInvalidTransition,
+			wantRevision: 1,
+			wantStatus:   StatusPausing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Image: "nginx:1.0", Replicas: 1, CurrentRevision: 1, Status: tt.status}}
+			repo.revisions = []Revision{{DeploymentId: 1, Revision: 1, Spec: repo.deployment.Spec()}}
+			jobRepo := &fakeJobRepo{}
+			service := testStateService(repo, jobRepo)
+
+			data, _ := repo.FindById(context.Background(), 1, 1)
+			if tt.concurrent != "" {
+				repo.deployment.Status = tt.concurrent
+			}
+
+			spec := data.Spec()
+			spec.Image = "nginx:2.0"
+			result, err := service.applyRevision(context.Background(), data, spec, userActor(1), "update image", nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), string(tt.wantErr)) {
+					t.Fatalf("expected %s, got %v", tt.wantErr, err)
+				}
+				if len(jobRepo.enqueued) != 0 {
+					t.Fatalf("expected no job, got %v", jobRepo.enqueued)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("expected revision to be applied, got %v", err)
+				}
+				if result.Deployment.Status != StatusUpdating || result.Deployment.Image != "nginx:2.0" {
+					t.Fatalf("unexpected deployment %s / %s", result.Deployment.Status, result.Deployment.Image)
+				}
+				if len(jobRepo.enqueued) != 1 || jobRepo.enqueued[0] != JobUpdate {
+					t.Fatalf("expected one %s job, got %v", JobUpdate, jobRepo.enqueued)
+				}
+			}
+
+			// Revision yang tidak pernah di-apply tidak boleh tersimpan
+			if len(repo.revisions) != tt.wantRevision {
+				t.Fatalf("expected %d revisions, got %d", tt.wantRevision, len(repo.revisions))
+			}
+			if repo.deployment.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s", tt.wantStatus, repo.deployment.Status)
+			}
+		})
+	}
+}
+
+func TestRollbackToCurrentRevision(t *testing.T) {
+	repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Image: "nginx:1.0", Replicas: 1, CurrentRevision: 1, Status: StatusRunning}}
+	repo.revisions = []Revision{{DeploymentId: 1, Revision: 1, Spec: repo.deployment.Spec()}}
+	service := testStateService(repo, &fakeJobRepo{})
+
+	_, err := service.Rollback(context.Background(), 1, 1, RollbackRequest{Revision: 1})
+	if err == nil || !strings.Contains(err.Error(), string(ErrAlreadyAtRevision)) {
+		t.Fatalf("expected %s, got %v", ErrAlreadyAtRevision, err)
+	}
+}
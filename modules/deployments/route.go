@@ -0,0 +1,77 @@
+package deployments
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wafi11/backend-workspaces/modules/auth"
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+func NewRoute(db *sql.DB, cfg config.Config, k8sClient *k8s.K8sClient, pool *jobs.WorkerPool, app fiber.Router) {
+	repo := NewRepository(db)
+	templatesRepo := templates.NewRepository(db)
+	jobsService := jobs.NewService(jobs.NewRepository(db))
+	service := NewService(repo, templatesRepo, jobsService, k8sClient, cfg)
+	handler := NewHandler(service)
+
+	service.RegisterJobs(pool)
+
+	// Tanpa auth user: diakses oleh Ingress workspace yang sedang di-park, dijaga wake token
+	wake := app.Group("/wake", handler.WakeAuth)
+	wake.Get("/activity", handler.Activity)
+	wake.Post("/activity", handler.Activity)
+	wake.Get("", handler.Wake)
+	wake.Post("", handler.Wake)
+	wake.Get("/*", handler.Wake)
+	wake.Post("/*", handler.Wake)
+
+	// Tanpa auth: diverifikasi dengan signature / token webhook per workspace
+	app.Post("/webhooks/git/:id", handler.ReceiveWebhook)
+
+	api := app.Group("/deployments", auth.Protected(cfg))
+	api.Post("", handler.Create)
+	api.Get("", handler.List)
+	api.Get("/quota", handler.Quota)
+	api.Get("/egress", handler.ListEgressRules)
+	api.Post("/egress", handler.AddEgressRule)
+	api.Delete("/egress/:ruleId", handler.DeleteEgressRule)
+	api.Get("/:id", handler.FindById)
+	api.Patch("/:id", handler.Update)
+	api.Delete("/:id", handler.Delete)
+	api.Get("/:id/status", handler.Status)
+	api.Get("/:id/jobs", handler.ListJobs)
+	api.Get("/:id/history", handler.History)
+	api.Get("/:id/revisions", handler.ListRevisions)
+	api.Post("/:id/rollback", handler.Rollback)
+	api.Post("/:id/scale", handler.Scale)
+	api.Post("/:id/pause", handler.Pause)
+	api.Post("/:id/resume", handler.Resume)
+	api.Put("/:id/idle-timeout", handler.SetIdleTimeout)
+	api.Get("/:id/addons", handler.ListAddons)
+	api.Post("/:id/addons", handler.AttachAddon)
+	api.Delete("/:id/addons/:type", handler.DetachAddon)
+	api.Get("/:id/tls", handler.TLSStatus)
+	api.Put("/:id/subdomain", handler.RenameSubdomain)
+	api.Get("/:id/domains", handler.ListDomains)
+	api.Post("/:id/domains", handler.AddDomain)
+	api.Post("/:id/domains/:domainId/verify", handler.VerifyDomain)
+	api.Delete("/:id/domains/:domainId", handler.DeleteDomain)
+	api.Get("/:id/builds", handler.ListBuilds)
+	api.Post("/:id/builds", handler.StartBuild)
+	api.Get("/:id/builds/:buildId", handler.GetBuild)
+	api.Get("/:id/builds/:buildId/logs", handler.BuildLogs)
+	api.Get("/:id/webhook", handler.GetWebhook)
+	api.Post("/:id/webhook", handler.RotateWebhook)
+	api.Delete("/:id/webhook", handler.DeleteWebhook)
+	api.Get("/:id/webhook/deliveries", handler.ListDeliveries)
+	api.Post("/:id/webhook/deliveries/:deliveryId/redeliver", handler.Redeliver)
+	api.Get("/:id/previews", handler.ListPreviews)
+	api.Post("/:id/previews", handler.CreatePreview)
+	api.Delete("/:id/previews/:previewId", handler.DeletePreview)
+	api.Get("/:id/pods", handler.ListPods)
+	api.Get("/:id/logs", handler.PodLogs)
+}
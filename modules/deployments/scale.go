@@ -0,0 +1,160 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+const (
+	defaultMaxReplicasPerUser       = 10
+	defaultMaxReplicasPerDeployment = 5
+)
+
+// Scale - Ubah jumlah replica. Disimpan sebagai revision baru supaya bisa di-rollback.
+func (s Service) Scale(c context.Context, id, userId int, req ScaleRequest) (*DeploymentJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Autoscaling != nil {
+		return nil, fmt.Errorf("%s: update autoscaling min/max replicas instead", ErrAutoscaleEnabled)
+	}
+	if err := checkNotStopped(data); err != nil {
+		return nil, err
+	}
+
+	unlock, err := s.lockQuota(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.checkReplicaLimit(c, data, req.Replicas); err != nil {
+		return nil, err
+	}
+
+	spec := data.Spec()
+	spec.Replicas = req.Replicas
+	if err := s.checkSpecQuota(c, data, spec); err != nil {
+		return nil, err
+	}
+
+	return s.applyRevision(c, data, spec, userActor(userId), fmt.Sprintf("scale to %d replicas", req.Replicas), nil)
+}
+
+// Pause - Scale ke 0 tanpa menghapus config, Service, dan Ingress.
+// Replica di spec tidak berubah, jadi Resume tinggal kembali ke spec.Replicas.
+// Status pausing mencegah update / pause lain masuk sebelum job selesai.
+func (s Service) Pause(c context.Context, id, userId int) (*DeploymentJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateTransition(data.Status, StatusPausing); err != nil {
+		return nil, err
+	}
+
+	// Rollout yang masih jalan akan scale Deployment kembali ke spec.Replicas
+	if err := s.checkNoActiveJob(c, data, JobUpdate, JobResume, JobSleep); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(c, data, StatusPausing, userActor(userId), "pause requested"); err != nil {
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobPause, data, nil)
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	return &DeploymentJob{Deployment: data, Job: job}, nil
+}
+
+// Resume - Kembalikan replica ke jumlah sebelum di-pause
+func (s Service) Resume(c context.Context, id, userId int) (*DeploymentJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Status != StatusStopped {
+		return nil, fmt.Errorf("%s: deployment is %s, not %s", ErrInvalidTransition, data.Status, StatusStopped)
+	}
+
+	unlock, err := s.lockQuota(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.checkReplicaLimit(c, data, data.Spec().maxReplicas()); err != nil {
+		return nil, err
+	}
+
+	// Deployment yang stopped tidak memesan pod app, jadi dicek sebagai running
+	resumed := *data
+	resumed.Status = StatusUpdating
+	if err := s.checkSpecQuota(c, &resumed, resumed.Spec()); err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("resume to %d replicas", data.Replicas)
+	if err := s.transition(c, data, StatusUpdating, userActor(userId), reason); err != nil {
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobResume, data, nil)
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	return &DeploymentJob{Deployment: data, Job: job}, nil
+}
+
+// checkReplicaLimit - Limit per deployment (minimal DefaultReplicas template)
+// dan total replica semua deployment milik user
+func (s Service) checkReplicaLimit(c context.Context, data *Deployment, replicas int) error {
+	tmpl, err := s.templates.FindById(c, data.TemplateId)
+	if err != nil {
+		return err
+	}
+
+	return s.checkReplicaLimitFor(c, data.UserId, data.Id, tmpl, replicas)
+}
+
+func (s Service) checkReplicaLimitFor(c context.Context, userId, deploymentId int, tmpl *templates.Template, replicas int) error {
+	if replicas < 1 {
+		return fmt.Errorf("%s: replicas must be at least 1", ErrInvalidReplicas)
+	}
+
+	maxPerDeployment := s.cfg.Workspace.MaxReplicasPerDeployment
+	if maxPerDeployment <= 0 {
+		maxPerDeployment = defaultMaxReplicasPerDeployment
+	}
+	if tmpl.DefaultReplicas > maxPerDeployment {
+		maxPerDeployment = tmpl.DefaultReplicas
+	}
+	if replicas > maxPerDeployment {
+		return fmt.Errorf("%s: max %d replicas per deployment for template %s", ErrReplicaLimit, maxPerDeployment, tmpl.Name)
+	}
+
+	maxPerUser := s.cfg.Workspace.MaxReplicasPerUser
+	if maxPerUser <= 0 {
+		maxPerUser = defaultMaxReplicasPerUser
+	}
+
+	used, err := s.repo.SumReplicasByUser(c, userId, deploymentId)
+	if err != nil {
+		return err
+	}
+	if used+replicas > maxPerUser {
+		return fmt.Errorf("%s: %d of %d replicas already in use", ErrReplicaLimit, used, maxPerUser)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package deployments
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		activeJob  string
+		wantErr    ErrorMessage
+		wantStatus string
+	}{
+		{name: "running", status: StatusRunning, wantStatus: StatusPausing},
+		{name: "degraded", status: StatusDegraded, wantStatus: StatusPausing},
+		{name: "already stopped", status: StatusStopped, wantErr: ErrInvalidTransition, wantStatus: StatusStopped},
+		{name: "already pausing", status: StatusPausing, wantErr: ErrInvalidTransition, wantStatus: StatusPausing},
+		{name: "rollout in flight", status: StatusRunning, activeJob: JobUpdate, wantErr: ErrJobInProgress, wantStatus: StatusRunning},
+		{name: "sleep in flight", status: StatusRunning, activeJob: JobSleep, wantErr: ErrJobInProgress, wantStatus: StatusRunning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Replicas: 2, Status: tt.status}}
+			jobRepo := &fakeJobRepo{active: map[string]bool{tt.activeJob: true}}
+			service := testStateService(repo, jobRepo)
+
+			_, err := service.Pause(context.Background(), 1, 1)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), string(tt.wantErr)) {
+					t.Fatalf("expected %s, got %v", tt.wantErr, err)
+				}
+				if len(jobRepo.enqueued) != 0 {
+					t.Fatalf("expected no job, got %v", jobRepo.enqueued)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("expected pause to be enqueued, got %v", err)
+				}
+				if len(jobRepo.enqueued) != 1 || jobRepo.enqueued[0] != JobPause {
+					t.Fatalf("expected one %s job, got %v", JobPause, jobRepo.enqueued)
+				}
+			}
+
+			if repo.deployment.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s", tt.wantStatus, repo.deployment.Status)
+			}
+		})
+	}
+}
+
+func TestScaleWhileStopped(t *testing.T) {
+	repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Replicas: 2, Status: StatusStopped}}
+	service := testStateService(repo, &fakeJobRepo{})
+
+	// Scale di-reject sebelum quota dicek, jadi tidak butuh repo template / plan
+	_, err := service.Scale(context.Background(), 1, 1, ScaleRequest{Replicas: 3})
+	if err == nil || !strings.Contains(err.Error(), string(ErrInvalidTransition)) {
+		t.Fatalf("expected %s, got %v", ErrInvalidTransition, err)
+	}
+}
@@ -0,0 +1,767 @@
+package deployments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type Service struct {
+	repo      DeploymentRepository
+	templates templates.TemplatesRepository
+	jobs      jobs.Service
+	k8s       *k8s.K8sClient
+	cfg       config.Config
+	verifier  DomainVerifier
+}
+
+func NewService(repo DeploymentRepository, templates templates.TemplatesRepository, jobs jobs.Service, k8sClient *k8s.K8sClient, cfg config.Config) Service {
+	verifier := NewDomainVerifier(net.DefaultResolver, cfg.Ingress.CustomDomains.VerificationPrefix)
+	return Service{repo: repo, templates: templates, jobs: jobs, k8s: k8sClient, cfg: cfg, verifier: verifier}
+}
+
+// WithResolver - Ganti resolver DNS untuk verifikasi custom domain (e.g., resolver palsu di test)
+func (s Service) WithResolver(resolver TXTResolver) Service {
+	s.verifier = NewDomainVerifier(resolver, s.cfg.Ingress.CustomDomains.VerificationPrefix)
+	return s
+}
+
+// Create - Simpan deployment (status pending) lalu enqueue job provision.
+// Resource K8s dibuat oleh worker, progress bisa di-poll lewat job.
+func (s Service) Create(c context.Context, userId int, req CreateDeploymentRequest) (*DeploymentJob, error) {
+	if err := validateUserName(req.Name); err != nil {
+		return nil, err
+	}
+	// Host lain di luar base domain harus lewat custom domain (verifikasi TXT)
+	if strings.TrimSpace(req.Host) != "" {
+		return nil, fmt.Errorf("%s: host cannot be set directly, use subdomain or add a custom domain", ErrInvalidHost)
+	}
+	subdomain, err := s.allocateSubdomain(c, userId, req)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = s.subdomainHost(subdomain)
+	if err := validateHost(req.Host); err != nil {
+		return nil, err
+	}
+
+	tmpl, err := s.templates.FindById(c, req.TemplateId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrTemplateNotFound, req.TemplateId)
+		}
+		return nil, err
+	}
+	if !tmpl.IsActive {
+		return nil, fmt.Errorf("%s: %s", ErrTemplateInactive, tmpl.Name)
+	}
+	if err := checkContainerNames(req.Name, tmpl); err != nil {
+		return nil, err
+	}
+
+	configData, secretData, err := resolveEnvVars(tmpl.EnvVarsSchema, req.EnvVars)
+	if err != nil {
+		return nil, err
+	}
+
+	databaseType, err := resolveDatabaseType(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := buildDeployment(userId, tmpl, req, configData, s.cfg)
+	if databaseType != "" {
+		deployment.DatabaseType = &databaseType
+	}
+	deployment.Addons = templateAddons(tmpl, s.cfg)
+	deployment.Subdomain = &subdomain
+	if err := validateIdleTimeout(deployment.IdleTimeoutMinutes); err != nil {
+		return nil, err
+	}
+	if err := validateAutoscaling(deployment.Autoscaling); err != nil {
+		return nil, err
+	}
+	unlock, err := s.lockQuota(c, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := s.checkReplicaLimitFor(c, userId, 0, tmpl, deployment.Spec().maxReplicas()); err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(c, &deployment, deployment.Addons); err != nil {
+		return nil, err
+	}
+
+	data, err := s.repo.Create(c, deployment, userActor(userId))
+	if err != nil {
+		return nil, err
+	}
+
+	secretName, err := s.stageSecrets(c, data, secretData)
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobProvision, data, provisionPayload{SecretName: secretName})
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	// Workspace jalan dulu dengan image default, image dari git repo di-roll out setelah build selesai
+	if req.Image == "" && tmpl.GitRepoURL != "" && s.cfg.Builds.Enabled {
+		if _, err := s.startBuild(c, data, tmpl, BuildRequest{}, BuildTriggerCreate, userActor(userId)); err != nil {
+			log.Printf("failed to start initial build for deployment %d: %s", data.Id, err.Error())
+		}
+	}
+
+	return &DeploymentJob{Deployment: data, Job: job}, nil
+}
+
+func (s Service) FindById(c context.Context, id, userId int) (*Deployment, error) {
+	return s.repo.FindById(c, id, userId)
+}
+
+func (s Service) List(c context.Context, userId int) ([]Deployment, error) {
+	return s.repo.ListByUser(c, userId)
+}
+
+// Status - Replica di spec vs yang sedang berjalan di cluster, termasuk status HPA
+func (s Service) Status(c context.Context, id, userId int) (*DeploymentStatus, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DeploymentStatus{
+		Status:   data.Status,
+		Replicas: data.Replicas,
+	}
+
+	// Resource belum dibuat / sudah dihapus. Saat provisioning, failed, atau deleting
+	// sebagian resource bisa belum ada, dilaporkan sebagai 0 replica.
+	if data.Status == StatusPending || data.Status == StatusDeleted {
+		return result, nil
+	}
+
+	status, err := s.k8s.GetDeploymentStatus(c, data.Namespace, data.DeploymentName)
+	if k8s.IsNotFound(err) {
+		status, err = &k8s.DeploymentStatus{Name: data.DeploymentName, Namespace: data.Namespace}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.DesiredReplicas = status.DesiredReplicas
+	result.CurrentReplicas = status.Replicas
+	result.ReadyReplicas = status.ReadyReplicas
+	result.UpdatedReplicas = status.UpdatedReplicas
+	result.IsReady = status.IsReady
+
+	if data.Autoscaling != nil {
+		autoscaler, err := s.k8s.GetHPAStatus(c, data.Namespace, data.DeploymentName)
+		if err != nil {
+			return nil, err
+		}
+		result.Autoscaler = autoscaler
+	}
+
+	if data.DatabaseType != nil {
+		database, err := s.k8s.GetStatefulSetStatus(c, data.Namespace, databaseName(data))
+		if k8s.IsNotFound(err) {
+			database, err = &k8s.StatefulSetStatus{Name: databaseName(data)}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Database = database
+	}
+
+	addons, err := s.addonHealth(c, data)
+	if err != nil {
+		return nil, err
+	}
+	result.Addons = addons
+
+	return result, nil
+}
+
+func (s Service) History(c context.Context, id, userId int) ([]StatusTransition, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListHistory(c, id)
+}
+
+func (s Service) ListJobs(c context.Context, id, userId int) ([]jobs.Job, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.jobs.ListByDeployment(c, id, userId)
+}
+
+// Delete - Enqueue job untuk menghapus semua resource K8s milik deployment.
+// PVC hanya dihapus kalau DeleteVolumes di-set dan dikonfirmasi dengan nama deployment.
+func (s Service) Delete(c context.Context, id, userId int, req DeleteDeploymentRequest) (*DeploymentJob, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DeleteVolumes && req.Confirm != data.Name {
+		return nil, fmt.Errorf("%s: confirm=%s", ErrDeleteConfirm, data.Name)
+	}
+
+	// Job yang masih jalan akan gagal atau membuat ulang resource yang sudah di-teardown
+	if err := s.checkNoActiveJob(c, data, mutatingJobs...); err != nil {
+		return nil, err
+	}
+
+	if err := s.transition(c, data, StatusDeleting, userActor(userId), "delete requested"); err != nil {
+		return nil, err
+	}
+
+	job, err := s.enqueue(c, JobDelete, data, deletePayload{DeleteVolumes: req.DeleteVolumes})
+	if err != nil {
+		s.markFailed(c, data, err)
+		return nil, err
+	}
+
+	// Preview environment tidak berguna tanpa parent
+	s.destroyPreviews(c, data, userActor(userId))
+
+	return &DeploymentJob{Deployment: data, Job: job}, nil
+}
+
+func (s Service) enqueue(c context.Context, jobType string, data *Deployment, payload interface{}) (*jobs.Job, error) {
+	if payload == nil {
+		payload = struct{}{}
+	}
+
+	job, err := s.jobs.Enqueue(c, jobs.EnqueueRequest{
+		Type:         jobType,
+		UserId:       data.UserId,
+		DeploymentId: data.Id,
+		Payload:      payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ErrEnqueueFailed, err)
+	}
+
+	return job, nil
+}
+
+// checkNoActiveJob - Tolak perubahan selama job dengan tipe ini masih queued / running
+func (s Service) checkNoActiveJob(c context.Context, data *Deployment, jobTypes ...string) error {
+	active, err := s.jobs.HasActive(c, data.Id, jobTypes...)
+	if err != nil {
+		return err
+	}
+	if active {
+		return fmt.Errorf("%s: %s", ErrJobInProgress, strings.Join(jobTypes, ", "))
+	}
+	return nil
+}
+
+// transition - Validasi state machine lalu simpan status baru beserta history-nya
+func (s Service) transition(c context.Context, data *Deployment, to, actor, reason string) error {
+	if data.Status == to {
+		return nil
+	}
+	if err := validateTransition(data.Status, to); err != nil {
+		return err
+	}
+
+	if err := s.repo.Transition(c, data.Id, data.Status, to, actor, reason); err != nil {
+		return err
+	}
+
+	data.Status = to
+	if reason != "" {
+		data.StatusMessage = &reason
+	} else {
+		data.StatusMessage = nil
+	}
+	return nil
+}
+
+// markFailed - Transition ke failed, error hanya di-log karena dipanggil di jalur error
+func (s Service) markFailed(c context.Context, data *Deployment, cause error) {
+	err := s.transition(context.WithoutCancel(c), data, StatusFailed, actorSystem, cause.Error())
+	if err != nil {
+		log.Printf("failed to mark deployment %d failed: %s", data.Id, err.Error())
+	}
+}
+
+type teardownStep struct {
+	kind string
+	fn   func() error
+}
+
+// teardown - Hapus resource dengan urutan kebalikan dari provision.
+// Namespace tidak dihapus karena dipakai bersama oleh semua workspace user.
+// PVC add-on hanya dihapus kalau deleteVolumes.
+func (s Service) teardown(c context.Context, data *Deployment, deleteVolumes bool) {
+	steps := []teardownStep{
+		{"ingress", func() error { return s.k8s.DeleteIngress(c, data.Namespace, data.IngressName) }},
+		{"domains", func() error { return s.teardownDomains(c, data) }},
+		{"builds", func() error { return s.teardownBuilds(c, data) }},
+		{"webhook", func() error { return s.teardownWebhook(c, data) }},
+		{"service", func() error { return s.k8s.DeleteService(c, data.Namespace, data.ServiceName) }},
+		{"wake service", func() error { return s.deleteWakeService(c, data) }},
+		{"deployment", func() error { return s.k8s.DeleteDeployment(c, data.Namespace, data.DeploymentName) }},
+		{"secret", func() error { return s.k8s.DeleteSecret(c, data.Namespace, data.SecretName) }},
+		{"configmap", func() error { return s.k8s.DeleteConfigMap(c, data.Namespace, data.ConfigMapName) }},
+		{"staged secret", func() error { return s.deleteStagedSecrets(c, data) }},
+	}
+	if data.DatabaseType != nil {
+		steps = append(steps, s.teardownDatabase(c, data)...)
+	}
+
+	addons, err := s.repo.ListAddons(c, data.Id)
+	if err != nil {
+		log.Printf("failed to list addons for deployment %d: %s", data.Id, err.Error())
+	}
+	for _, addon := range addons {
+		steps = append(steps, s.teardownAddon(c, data, addon, deleteVolumes)...)
+	}
+
+	s.runTeardown(data, steps)
+}
+
+// runTeardown - Jalankan semua step, error hanya di-log supaya step lain tetap jalan
+func (s Service) runTeardown(data *Deployment, steps []teardownStep) {
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			log.Printf("failed to delete %s for deployment %d: %s", step.kind, data.Id, err.Error())
+		}
+	}
+}
+
+// stageSecrets - Secret env disimpan di K8s Secret sementara, payload job hanya berisi namanya.
+// Provision membuat Secret workspace dari sini lalu menghapusnya. "" kalau tidak ada secret.
+func (s Service) stageSecrets(c context.Context, data *Deployment, secretData map[string]string) (string, error) {
+	if len(secretData) == 0 {
+		return "", nil
+	}
+
+	if err := s.ensureNamespace(c, data); err != nil {
+		return "", err
+	}
+
+	name := stagedSecretName(data)
+	exists, err := s.k8s.SecretExists(c, data.Namespace, name)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		err = s.k8s.UpdateSecret(c, data.Namespace, name, secretData)
+	} else {
+		err = s.k8s.CreateSecretFromStringData(c, data.Namespace, name, secretData)
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (s Service) deleteStagedSecrets(c context.Context, data *Deployment) error {
+	name := stagedSecretName(data)
+	exists, err := s.k8s.SecretExists(c, data.Namespace, name)
+	if err != nil || !exists {
+		return err
+	}
+	return s.k8s.DeleteSecret(c, data.Namespace, name)
+}
+
+// ensureNamespace - Buat namespace user kalau belum ada. "already exists" diabaikan karena
+// provision workspace lain milik user yang sama bisa membuatnya bersamaan.
+func (s Service) ensureNamespace(c context.Context, data *Deployment) error {
+	exists, err := s.k8s.NamespaceExists(c, data.Namespace)
+	if err != nil || exists {
+		return err
+	}
+
+	err = s.k8s.CreateNamespace(c, data.Namespace, namespaceLabels(data.UserId))
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return err
+	}
+	return nil
+}
+
+func stagedSecretName(data *Deployment) string {
+	return fmt.Sprintf("%s-staged-secrets", data.Name)
+}
+
+func namespaceLabels(userId int) map[string]string {
+	return map[string]string{
+		"user":       fmt.Sprintf("user%d", userId),
+		"managed-by": managedBy,
+	}
+}
+
+// provision - Create namespace (quota & NetworkPolicy) → ConfigMap → Secret → Deployment → Service → Ingress.
+// Kalau salah satu step gagal, resource workspace yang sudah dibuat di-rollback (namespace tetap).
+func (s Service) provision(c context.Context, data *Deployment, secretData map[string]string) error {
+	// Namespace di-share semua workspace milik user, tidak ikut di-rollback
+	if err := s.ensureNamespace(c, data); err != nil {
+		return err
+	}
+
+	tx := s.k8s.BeginTransaction()
+
+	// Quota dipasang sebelum ada pod di namespace
+	if err := s.ensureUserQuota(c, data.UserId); err != nil {
+		return tx.Rollback(c, err)
+	}
+	if err := s.ensureNetworkIsolation(c, data.UserId); err != nil {
+		return tx.Rollback(c, err)
+	}
+
+	if err := tx.CreateConfigMap(c, data.Namespace, data.ConfigMapName, data.EnvVars); err != nil {
+		return tx.Rollback(c, err)
+	}
+
+	// Connection string add-on ikut disimpan di Secret workspace
+	addons, err := s.repo.ListAddons(c, data.Id)
+	if err != nil {
+		return tx.Rollback(c, err)
+	}
+	secretData = copyMap(secretData)
+	for _, addon := range addons {
+		password, err := generatePassword()
+		if err != nil {
+			return tx.Rollback(c, err)
+		}
+		for key, value := range addonSpecs[addon.Type].connection(addonHost(data, addon.Type), password) {
+			secretData[key] = value
+		}
+	}
+
+	if err := tx.CreateSecretFromStringData(c, data.Namespace, data.SecretName, secretData); err != nil {
+		return tx.Rollback(c, err)
+	}
+
+	if data.DatabaseType != nil {
+		if err := s.provisionDatabase(c, tx, data); err != nil {
+			return tx.Rollback(c, err)
+		}
+	}
+
+	// Add-on dibuat sebelum app supaya app tidak crash loop saat connect
+	for _, addon := range addons {
+		if err := s.provisionAddon(c, tx, data, addon); err != nil {
+			return tx.Rollback(c, err)
+		}
+	}
+
+	// PVC dari deployment sebelumnya (nama sama) dipakai lagi, datanya tidak hilang
+	for _, volume := range data.Volumes {
+		claimName := volumeClaimName(data, volume)
+		exists, err := s.k8s.PersistentVolumeClaimExists(c, data.Namespace, claimName)
+		if err != nil {
+			return tx.Rollback(c, err)
+		}
+		if exists {
+			continue
+		}
+
+		err = tx.CreatePersistentVolumeClaim(c, data.Namespace, volumeConfig(data, volume), map[string]string{
+			"app":        data.AppName,
+			"managed-by": managedBy,
+		})
+		if err != nil {
+			return tx.Rollback(c, err)
+		}
+	}
+
+	if err := s.ensurePullSecret(c, data.Namespace); err != nil {
+		return tx.Rollback(c, err)
+	}
+	if err := tx.CreateDeployment(c, s.deploymentConfig(data)); err != nil {
+		return tx.Rollback(c, err)
+	}
+
+	if err := tx.CreateService(c, data.Namespace, data.ServiceName, data.AppName, servicePort, data.ContainerPort); err != nil {
+		return tx.Rollback(c, err)
+	}
+
+	if err := tx.CreateIngress(c, s.ingressConfig(data)); err != nil {
+		return tx.Rollback(c, err)
+	}
+
+	tx.Commit()
+
+	for _, addon := range addons {
+		if err := s.repo.SetAddonStatus(c, addon.Id, AddonStatusReady, ""); err != nil {
+			log.Printf("failed to mark addon %d ready: %s", addon.Id, err.Error())
+		}
+	}
+
+	// Tidak fatal: workspace tetap jalan, hanya activity-nya tidak tercatat
+	if err := s.syncActivityMirror(c, data); err != nil {
+		log.Printf("failed to set activity mirror for deployment %d: %s", data.Id, err.Error())
+	}
+	return nil
+}
+
+const revisionAnnotation = "workspaces/revision"
+
+// servicePort - Port yang di-expose Service ke Ingress
+const servicePort = 80
+
+func buildDeployment(userId int, tmpl *templates.Template, req CreateDeploymentRequest, configData map[string]string, cfg config.Config) Deployment {
+	image := req.Image
+	if image == "" {
+		image = cfg.Docker.DefaultImage
+	}
+
+	replicas := req.Replicas
+	if replicas <= 0 {
+		replicas = tmpl.DefaultReplicas
+	}
+	if replicas <= 0 {
+		replicas = 1
+	}
+
+	autoscaling := tmpl.DefaultAutoscaling
+	if req.Autoscaling != nil {
+		autoscaling = req.Autoscaling
+	}
+	if autoscaling != nil {
+		replicas = autoscaling.MinReplicas
+	}
+
+	probes := tmpl.ResolvedProbes()
+
+	idleTimeout := cfg.Workspace.DefaultIdleTimeoutMinutes
+	if req.IdleTimeoutMinutes != nil {
+		idleTimeout = *req.IdleTimeoutMinutes
+	}
+
+	containerPort := tmpl.DefaultPort
+	if containerPort <= 0 {
+		containerPort = 8080
+	}
+
+	data := Deployment{
+		UserId:             userId,
+		TemplateId:         tmpl.Id,
+		Namespace:          userNamespace(userId),
+		Image:              image,
+		Replicas:           replicas,
+		ContainerPort:      containerPort,
+		Host:               req.Host,
+		CPURequest:         tmpl.DefaultCPURequest,
+		CPULimit:           tmpl.DefaultCPULimit,
+		MemoryRequest:      tmpl.DefaultMemoryRequest,
+		MemoryLimit:        tmpl.DefaultMemoryLimit,
+		EnvVars:            configData,
+		Autoscaling:        autoscaling,
+		Probes:             &probes,
+		Volumes:            tmpl.Volumes,
+		IngressAnnotations: tmpl.IngressAnnotations,
+		Sidecars:           tmpl.Sidecars,
+		InitContainers:     tmpl.InitContainers,
+		Status:             StatusPending,
+
+		IdleTimeoutMinutes: idleTimeout,
+	}
+	setResourceNames(&data, req.Name)
+	return data
+}
+
+// setResourceNames - Semua resource K8s workspace diberi prefix nama workspace
+func setResourceNames(data *Deployment, name string) {
+	data.Name = name
+	data.AppName = name
+	data.ConfigMapName = fmt.Sprintf("%s-config", name)
+	data.SecretName = fmt.Sprintf("%s-secrets", name)
+	data.DeploymentName = fmt.Sprintf("%s-deployment", name)
+	data.ServiceName = fmt.Sprintf("%s-service", name)
+	data.IngressName = fmt.Sprintf("%s-ingress", name)
+}
+
+func (s Service) deploymentConfig(data *Deployment) *k8s.DeploymentConfig {
+	config := &k8s.DeploymentConfig{
+		Name:          data.DeploymentName,
+		Namespace:     data.Namespace,
+		AppName:       data.AppName,
+		Image:         data.Image,
+		Replicas:      int32(data.Replicas),
+		ContainerPort: int32(data.ContainerPort),
+		CPURequest:    data.CPURequest,
+		CPULimit:      data.CPULimit,
+		MemoryRequest: data.MemoryRequest,
+		MemoryLimit:   data.MemoryLimit,
+		ConfigMapName: data.ConfigMapName,
+		SecretName:    data.SecretName,
+		EnvVars: []corev1.EnvVar{
+			{Name: "PORT", Value: fmt.Sprintf("%d", data.ContainerPort)},
+		},
+		// Revision berubah → pod di-restart, termasuk kalau hanya ConfigMap yang berubah
+		PodAnnotations: map[string]string{
+			revisionAnnotation: fmt.Sprintf("%d", data.CurrentRevision),
+		},
+		Autoscaling: autoscalingConfig(data.Autoscaling),
+	}
+	if s.cfg.Builds.PullSecret != "" {
+		config.ImagePullSecrets = []string{s.cfg.Builds.PullSecret}
+	}
+
+	// Deployment lama (sebelum ada probes) memakai default
+	probes := templates.DefaultProbes(data.ContainerPort)
+	if data.Probes != nil {
+		probes = *data.Probes
+	}
+	for _, volume := range data.Volumes {
+		config.Volumes = append(config.Volumes, volumeConfig(data, volume))
+	}
+
+	if data.DatabaseType != nil {
+		config.EnvFromSecrets = append(config.EnvFromSecrets, databaseSecretName(data))
+	}
+
+	for _, container := range data.Sidecars {
+		config.Sidecars = append(config.Sidecars, containerConfig(container))
+	}
+	for _, container := range data.InitContainers {
+		config.InitContainers = append(config.InitContainers, containerConfig(container))
+	}
+
+	config.LivenessProbe = probeConfig(probes.Liveness)
+	config.ReadinessProbe = probeConfig(probes.Readiness)
+	config.StartupProbe = probeConfig(probes.Startup)
+
+	return config
+}
+
+// checkContainerNames - Container app memakai nama workspace, tidak boleh sama dengan
+// nama sidecar / init container dari template
+func checkContainerNames(name string, tmpl *templates.Template) error {
+	for _, container := range append(append([]templates.Container{}, tmpl.Sidecars...), tmpl.InitContainers...) {
+		if container.Name == name {
+			return fmt.Errorf("%s: %s is used by a container in template %s", ErrInvalidName, name, tmpl.Name)
+		}
+	}
+	return nil
+}
+
+func containerConfig(container templates.Container) k8s.ContainerConfig {
+	config := k8s.ContainerConfig{
+		Name:          container.Name,
+		Image:         container.Image,
+		Command:       container.Command,
+		Args:          container.Args,
+		InheritEnv:    container.InheritEnv,
+		CPURequest:    container.CPURequest,
+		CPULimit:      container.CPULimit,
+		MemoryRequest: container.MemoryRequest,
+		MemoryLimit:   container.MemoryLimit,
+	}
+	for key, value := range container.Env {
+		config.EnvVars = append(config.EnvVars, corev1.EnvVar{Name: key, Value: value})
+	}
+	// Map tidak berurutan, env di-sort supaya pod template tidak berubah setiap apply
+	sort.Slice(config.EnvVars, func(i, j int) bool { return config.EnvVars[i].Name < config.EnvVars[j].Name })
+
+	for _, mount := range container.VolumeMounts {
+		config.VolumeMounts = append(config.VolumeMounts, k8s.VolumeMountConfig{
+			Volume:    mount.Volume,
+			MountPath: mount.MountPath,
+			ReadOnly:  mount.ReadOnly,
+		})
+	}
+	return config
+}
+
+// volumeClaimName - Nama PVC tetap per workspace supaya bisa dipakai lagi saat redeploy
+func volumeClaimName(data *Deployment, volume templates.Volume) string {
+	return fmt.Sprintf("%s-%s-pvc", data.Name, volume.Name)
+}
+
+func volumeConfig(data *Deployment, volume templates.Volume) k8s.VolumeConfig {
+	return k8s.VolumeConfig{
+		Name:         volume.Name,
+		ClaimName:    volumeClaimName(data, volume),
+		MountPath:    volume.MountPath,
+		Size:         volume.Size,
+		AccessMode:   volume.AccessMode,
+		StorageClass: volume.StorageClass,
+	}
+}
+
+// deleteVolumes - Hapus semua PVC deployment, error hanya di-log
+func (s Service) deleteVolumes(c context.Context, data *Deployment) {
+	for _, volume := range data.Volumes {
+		claimName := volumeClaimName(data, volume)
+		if err := s.k8s.DeletePersistentVolumeClaim(c, data.Namespace, claimName); err != nil {
+			log.Printf("failed to delete pvc %s for deployment %d: %s", claimName, data.Id, err.Error())
+		}
+	}
+}
+
+func probeConfig(probe *templates.Probe) *k8s.ProbeConfig {
+	if probe == nil {
+		return nil
+	}
+
+	return &k8s.ProbeConfig{
+		Type:                probe.Type,
+		Path:                probe.Path,
+		Port:                int32(probe.Port),
+		Command:             probe.Command,
+		InitialDelaySeconds: int32(probe.InitialDelaySeconds),
+		PeriodSeconds:       int32(probe.PeriodSeconds),
+		TimeoutSeconds:      int32(probe.TimeoutSeconds),
+		SuccessThreshold:    int32(probe.SuccessThreshold),
+		FailureThreshold:    int32(probe.FailureThreshold),
+	}
+}
+
+func autoscalingConfig(scaling *templates.Autoscaling) *k8s.AutoscalingConfig {
+	if scaling == nil {
+		return nil
+	}
+
+	config := &k8s.AutoscalingConfig{
+		MinReplicas: int32(scaling.MinReplicas),
+		MaxReplicas: int32(scaling.MaxReplicas),
+	}
+	if scaling.TargetCPUUtilization != nil {
+		target := int32(*scaling.TargetCPUUtilization)
+		config.TargetCPUUtilization = &target
+	}
+	if scaling.TargetMemoryUtilization != nil {
+		target := int32(*scaling.TargetMemoryUtilization)
+		config.TargetMemoryUtilization = &target
+	}
+	return config
+}
+
+func copyMap(source map[string]string) map[string]string {
+	result := make(map[string]string, len(source)+4)
+	for key, value := range source {
+		result[key] = value
+	}
+	return result
+}
+
+// userNamespace - Semua workspace milik satu user berada di namespace yang sama
+func userNamespace(userId int) string {
+	return fmt.Sprintf("user%d-workspaces", userId)
+}
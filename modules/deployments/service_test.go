@@ -0,0 +1,52 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeProvisionRepo - Provision gagal setelah namespace dibuat
+type fakeProvisionRepo struct {
+	DeploymentRepository
+}
+
+func (r *fakeProvisionRepo) GetUserPlan(c context.Context, userId int) (string, error) {
+	return "", errors.New("database unavailable")
+}
+
+func TestProvisionRollbackKeepsNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+	}{
+		{name: "namespace created by the failed provision"},
+		{name: "namespace shared with other workspaces", existing: []runtime.Object{
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewClientset(tt.existing...)
+			service := NewService(&fakeProvisionRepo{}, nil, jobs.Service{}, k8s.NewK8sClientFromClientset(clientset), config.Config{})
+
+			data := &Deployment{Id: 1, UserId: 1, Name: "shop", Namespace: testNamespace}
+			if err := service.provision(context.Background(), data, nil); err == nil {
+				t.Fatal("expected provision to fail")
+			}
+
+			if _, err := clientset.CoreV1().Namespaces().Get(context.Background(), testNamespace, metav1.GetOptions{}); err != nil {
+				t.Fatalf("expected namespace to survive the rollback, got %v", err)
+			}
+		})
+	}
+}
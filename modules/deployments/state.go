@@ -0,0 +1,61 @@
+package deployments
+
+import (
+	"fmt"
+)
+
+// Lifecycle deployment:
+//
+//	pending → provisioning → running ⇄ updating
+//	                           ↕          ↓
+//	                        degraded → pausing → stopped
+//	semua state aktif → deleting → deleted
+//	provisioning/updating/pausing/deleting → failed
+const (
+	StatusPending      = "pending"
+	StatusProvisioning = "provisioning"
+	StatusRunning      = "running"
+	StatusUpdating     = "updating"
+	StatusDegraded     = "degraded"
+	StatusPausing      = "pausing"
+	StatusStopped      = "stopped"
+	StatusDeleting     = "deleting"
+	StatusDeleted      = "deleted"
+	StatusFailed       = "failed"
+)
+
+var transitions = map[string][]string{
+	StatusPending:      {StatusProvisioning, StatusFailed, StatusDeleting},
+	StatusProvisioning: {StatusRunning, StatusDegraded, StatusFailed, StatusDeleting},
+	StatusRunning:      {StatusUpdating, StatusDegraded, StatusPausing, StatusStopped, StatusDeleting, StatusFailed},
+	StatusUpdating:     {StatusRunning, StatusDegraded, StatusStopped, StatusFailed, StatusDeleting},
+	StatusDegraded:     {StatusRunning, StatusUpdating, StatusPausing, StatusStopped, StatusFailed, StatusDeleting},
+	StatusPausing:      {StatusStopped, StatusFailed, StatusDeleting},
+	StatusStopped:      {StatusUpdating, StatusRunning, StatusDeleting},
+	StatusDeleting:     {StatusDeleted, StatusFailed},
+	StatusFailed:       {StatusProvisioning, StatusUpdating, StatusDeleting},
+	StatusDeleted:      {},
+}
+
+// actorSystem - Actor untuk transition yang dilakukan worker / background process
+const actorSystem = "system"
+
+func userActor(userId int) string {
+	return fmt.Sprintf("user:%d", userId)
+}
+
+func canTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTransition(from, to string) error {
+	if !canTransition(from, to) {
+		return fmt.Errorf("%s: %s → %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
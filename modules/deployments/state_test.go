@@ -0,0 +1,257 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+// fakeStateRepo - Deployment di memory; Transition compare-and-swap seperti query aslinya
+type fakeStateRepo struct {
+	DeploymentRepository
+	deployment Deployment
+	history    []StatusTransition
+	revisions  []Revision
+	previews   []Deployment
+}
+
+func (r *fakeStateRepo) FindById(c context.Context, id, userId int) (*Deployment, error) {
+	data := r.deployment
+	return &data, nil
+}
+
+func (r *fakeStateRepo) Transition(c context.Context, id int, from, to, actor, reason string) error {
+	if r.deployment.Status != from {
+		return fmt.Errorf("%s: deployment %d is no longer %s", ErrStatusConflict, id, from)
+	}
+	r.deployment.Status = to
+	r.history = append(r.history, StatusTransition{DeploymentId: id, FromStatus: &from, ToStatus: to, Actor: actor})
+	return nil
+}
+
+// SaveRevision - Revision, spec, dan transition ke updating tersimpan bersama atau tidak sama sekali
+func (r *fakeStateRepo) SaveRevision(c context.Context, id int, from string, spec DeploymentSpec, actor, changeCause string, rollbackOf *int) (*Revision, error) {
+	number := r.deployment.CurrentRevision + 1
+	if err := r.Transition(c, id, from, StatusUpdating, actor, revisionReason(number, changeCause)); err != nil {
+		return nil, err
+	}
+
+	revision := Revision{DeploymentId: id, Revision: number, Spec: spec, ChangeCause: &changeCause, RollbackOf: rollbackOf, CreatedBy: actor}
+	r.revisions = append(r.revisions, revision)
+	r.deployment.applySpec(spec)
+	r.deployment.CurrentRevision = number
+	return &revision, nil
+}
+
+func (r *fakeStateRepo) GetRevision(c context.Context, id, number int) (*Revision, error) {
+	for i := range r.revisions {
+		if r.revisions[i].Revision == number {
+			return &r.revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %d", ErrRevisionNotFound, number)
+}
+
+func (r *fakeStateRepo) ListPreviews(c context.Context, parentId int) ([]Deployment, error) {
+	return r.previews, nil
+}
+
+// fakeJobRepo - Job queue di memory, active = tipe job yang masih queued / running
+type fakeJobRepo struct {
+	jobs.JobRepository
+	active   map[string]bool
+	enqueued []string
+}
+
+func (r *fakeJobRepo) HasActive(c context.Context, deploymentId int, types []string) (bool, error) {
+	for _, jobType := range types {
+		if r.active[jobType] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeJobRepo) Enqueue(c context.Context, req jobs.EnqueueRequest) (*jobs.Job, error) {
+	r.enqueued = append(r.enqueued, req.Type)
+	return &jobs.Job{Id: len(r.enqueued), Type: req.Type, Status: jobs.StatusQueued}, nil
+}
+
+func testStateService(repo *fakeStateRepo, jobRepo *fakeJobRepo) Service {
+	return NewService(repo, nil, jobs.NewService(jobRepo), nil, config.Config{})
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		valid    bool
+	}{
+		{StatusPending, StatusProvisioning, true},
+		{StatusProvisioning, StatusRunning, true},
+		{StatusRunning, StatusUpdating, true},
+		{StatusUpdating, StatusRunning, true},
+		{StatusUpdating, StatusDegraded, true},
+		{StatusDegraded, StatusUpdating, true},
+		{StatusRunning, StatusPausing, true},
+		{StatusPausing, StatusStopped, true},
+		{StatusStopped, StatusUpdating, true},
+		{StatusRunning, StatusDeleting, true},
+		{StatusDeleting, StatusDeleted, true},
+		{StatusFailed, StatusProvisioning, true},
+		{StatusPending, StatusRunning, false},
+		{StatusStopped, StatusPausing, false},
+		{StatusPausing, StatusUpdating, false},
+		{StatusDeleting, StatusRunning, false},
+		{StatusDeleted, StatusProvisioning, false},
+		{StatusDeleted, StatusDeleting, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"→"+tt.to, func(t *testing.T) {
+			err := validateTransition(tt.from, tt.to)
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid transition, got %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), string(ErrInvalidTransition))) {
+				t.Fatalf("expected %s, got %v", ErrInvalidTransition, err)
+			}
+		})
+	}
+}
+
+func TestTransitionConflict(t *testing.T) {
+	repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Status: StatusRunning}}
+	service := testStateService(repo, &fakeJobRepo{})
+
+	// Status di DB sudah berubah sejak deployment dibaca
+	stale := repo.deployment
+	repo.deployment.Status = StatusPausing
+
+	err := service.transition(context.Background(), &stale, StatusUpdating, actorSystem, "")
+	if err == nil || !strings.Contains(err.Error(), string(ErrStatusConflict)) {
+		t.Fatalf("expected %s, got %v", ErrStatusConflict, err)
+	}
+	if stale.Status != StatusRunning {
+		t.Fatalf("expected in-memory status to stay %s, got %s", StatusRunning, stale.Status)
+	}
+	if len(repo.history) != 0 {
+		t.Fatalf("expected no history, got %d entries", len(repo.history))
+	}
+}
+
+func TestDeleteWaitsForMutatingJobs(t *testing.T) {
+	tests := []struct {
+		name      string
+		activeJob string
+		wantErr   bool
+	}{
+		{name: "no active job"},
+		{name: "provision", activeJob: JobProvision, wantErr: true},
+		{name: "update", activeJob: JobUpdate, wantErr: true},
+		{name: "resume", activeJob: JobResume, wantErr: true},
+		{name: "attach addon", activeJob: JobAttachAddon, wantErr: true},
+		{name: "build", activeJob: JobBuild},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Name: "shop", Status: StatusRunning}}
+			jobRepo := &fakeJobRepo{active: map[string]bool{tt.activeJob: true}}
+			service := testStateService(repo, jobRepo)
+
+			_, err := service.Delete(context.Background(), 1, 1, DeleteDeploymentRequest{})
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), string(ErrJobInProgress)) {
+					t.Fatalf("expected %s, got %v", ErrJobInProgress, err)
+				}
+				if repo.deployment.Status != StatusRunning {
+					t.Fatalf("expected status %s, got %s", StatusRunning, repo.deployment.Status)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected delete to be enqueued, got %v", err)
+			}
+			if repo.deployment.Status != StatusDeleting {
+				t.Fatalf("expected status %s, got %s", StatusDeleting, repo.deployment.Status)
+			}
+			if len(jobRepo.enqueued) != 1 || jobRepo.enqueued[0] != JobDelete {
+				t.Fatalf("expected one %s job, got %v", JobDelete, jobRepo.enqueued)
+			}
+		})
+	}
+}
+
+func TestJobDeploymentSkipsDeleting(t *testing.T) {
+	tests := []struct {
+		status  string
+		jobType string
+		wantErr bool
+	}{
+		{status: StatusRunning, jobType: JobUpdate},
+		{status: StatusDeleting, jobType: JobUpdate, wantErr: true},
+		{status: StatusDeleting, jobType: JobBuild, wantErr: true},
+		{status: StatusDeleted, jobType: JobAttachAddon, wantErr: true},
+		{status: StatusDeleting, jobType: JobDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jobType+" while "+tt.status, func(t *testing.T) {
+			repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Status: tt.status}}
+			service := testStateService(repo, &fakeJobRepo{})
+
+			id, userId := 1, 1
+			_, err := service.jobDeployment(context.Background(), &jobs.Job{Id: 9, Type: tt.jobType, DeploymentId: &id, UserId: &userId})
+			if tt.wantErr && err == nil {
+				t.Fatal("expected job to be rejected")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected job to run, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHandleExpiredJob(t *testing.T) {
+	lastError := "lease expired on the last attempt"
+
+	tests := []struct {
+		name       string
+		jobType    string
+		status     string
+		activeJob  string
+		wantStatus string
+	}{
+		{name: "provision stuck in provisioning", jobType: JobProvision, status: StatusProvisioning, wantStatus: StatusFailed},
+		{name: "delete stuck in deleting", jobType: JobDelete, status: StatusDeleting, wantStatus: StatusFailed},
+		{name: "pause stuck in pausing", jobType: JobPause, status: StatusPausing, wantStatus: StatusFailed},
+		{name: "update already finished", jobType: JobUpdate, status: StatusRunning, wantStatus: StatusRunning},
+		{name: "newer update still running", jobType: JobUpdate, status: StatusUpdating, activeJob: JobUpdate, wantStatus: StatusUpdating},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStateRepo{deployment: Deployment{Id: 1, UserId: 1, Status: tt.status}}
+			service := testStateService(repo, &fakeJobRepo{active: map[string]bool{tt.activeJob: true}})
+
+			id, userId := 1, 1
+			job := &jobs.Job{Id: 9, Type: tt.jobType, DeploymentId: &id, UserId: &userId, Status: jobs.StatusFailed, LastError: &lastError}
+			if err := service.handleExpiredJob(context.Background(), job); err != nil {
+				t.Fatalf("expected expired job to be handled, got %v", err)
+			}
+			if repo.deployment.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s", tt.wantStatus, repo.deployment.Status)
+			}
+		})
+	}
+
+	// Deployment failed bisa dihapus lagi
+	if err := validateTransition(StatusFailed, StatusDeleting); err != nil {
+		t.Fatalf("expected failed deployment to be deletable, got %v", err)
+	}
+}
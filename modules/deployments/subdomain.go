@@ -0,0 +1,205 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Sisa label DNS (63) untuk suffix "-<n>" kalau slug sudah dipakai
+	maxSubdomainSlug      = 56
+	maxSubdomainAttempts  = 20
+	subdomainDigitsPrefix = "w-"
+)
+
+// reservedSubdomains - Dipakai platform, tidak bisa diklaim workspace.
+// Bisa ditambah lewat ingress.reserved_subdomains.
+var reservedSubdomains = []string{
+	"api", "admin", "www", "app", "auth", "login", "dashboard", "console",
+	"mail", "smtp", "imap", "pop", "ftp", "ns1", "ns2", "dns",
+	"static", "assets", "cdn", "docs", "blog", "status", "support", "help",
+	"billing", "wake", "registry", "git", "webhooks",
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SubdomainSlug - Slug "<workspace>-<username>" yang valid sebagai label DNS:
+// lowercase, karakter lain jadi "-", diawali huruf, maksimal 56 karakter
+func SubdomainSlug(workspace, username string) string {
+	slug := strings.ToLower(workspace)
+	if username != "" {
+		slug += "-" + strings.ToLower(username)
+	}
+	slug = strings.Trim(nonSlugChars.ReplaceAllString(slug, "-"), "-")
+
+	if slug == "" {
+		slug = "workspace"
+	}
+	if slug[0] >= '0' && slug[0] <= '9' {
+		slug = subdomainDigitsPrefix + slug
+	}
+	if len(slug) > maxSubdomainSlug {
+		slug = strings.TrimRight(slug[:maxSubdomainSlug], "-")
+	}
+
+	return slug
+}
+
+// RenameSubdomain - Ganti subdomain workspace, Ingress & mirror-host activity di-update in place.
+// Kalau salah satu gagal, subdomain & host di DB dan Ingress dikembalikan.
+func (s Service) RenameSubdomain(c context.Context, id, userId int, req SubdomainRequest) (*Deployment, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDomainChange(data); err != nil {
+		return nil, err
+	}
+
+	subdomain, err := s.validateSubdomain(req.Subdomain)
+	if err != nil {
+		return nil, err
+	}
+	if data.Subdomain != nil && *data.Subdomain == subdomain {
+		return data, nil
+	}
+
+	oldHost, oldSubdomain := data.Host, data.Subdomain
+	newHost := s.subdomainHost(subdomain)
+	if err := validateHost(newHost); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateSubdomain(c, data.Id, subdomain, newHost); err != nil {
+		return nil, err
+	}
+
+	if err := s.k8s.UpdateIngressHost(c, data.Namespace, data.IngressName, oldHost, newHost); err != nil {
+		s.revertRename(c, data, oldSubdomain, oldHost, "")
+		return nil, err
+	}
+
+	// Tanpa ini traffic ke host baru tidak tercatat dan workspace di-park walaupun dipakai
+	data.Host = newHost
+	if err := s.syncActivityMirror(c, data); err != nil {
+		s.revertRename(c, data, oldSubdomain, oldHost, newHost)
+		return nil, err
+	}
+
+	return s.repo.FindById(c, data.Id, userId)
+}
+
+// revertRename - Kembalikan subdomain di DB, host Ingress (kalau sudah diganti ke newHost),
+// dan mirror-host. Error hanya di-log karena dipanggil di jalur error.
+func (s Service) revertRename(c context.Context, data *Deployment, oldSubdomain *string, oldHost, newHost string) {
+	c = context.WithoutCancel(c)
+
+	if newHost != "" {
+		if err := s.k8s.UpdateIngressHost(c, data.Namespace, data.IngressName, newHost, oldHost); err != nil {
+			log.Printf("failed to revert ingress host for deployment %d: %s", data.Id, err.Error())
+		}
+		data.Host = oldHost
+		if err := s.syncActivityMirror(c, data); err != nil {
+			log.Printf("failed to revert activity mirror for deployment %d: %s", data.Id, err.Error())
+		}
+	}
+
+	if err := s.revertSubdomain(c, data.Id, oldSubdomain, oldHost); err != nil {
+		log.Printf("failed to revert subdomain for deployment %d: %s", data.Id, err.Error())
+	}
+}
+
+func (s Service) revertSubdomain(c context.Context, id int, subdomain *string, host string) error {
+	previous := ""
+	if subdomain != nil {
+		previous = *subdomain
+	}
+	return s.repo.UpdateSubdomain(c, id, previous, host)
+}
+
+// allocateSubdomain - Subdomain untuk workspace baru. Slug otomatis diberi suffix -2, -3, ...
+// kalau reserved / sudah dipakai; unique index tetap jadi penjaga terakhir.
+func (s Service) allocateSubdomain(c context.Context, userId int, req CreateDeploymentRequest) (string, error) {
+	if strings.TrimSpace(req.Subdomain) != "" {
+		subdomain, err := s.validateSubdomain(req.Subdomain)
+		if err != nil {
+			return "", err
+		}
+		taken, err := s.repo.SubdomainExists(c, subdomain)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", fmt.Errorf("%s: %s", ErrSubdomainTaken, subdomain)
+		}
+		return subdomain, nil
+	}
+
+	if s.cfg.Ingress.BaseDomain == "" {
+		return "", fmt.Errorf("%s: ingress base domain is not configured", ErrInvalidSubdomain)
+	}
+
+	username, err := s.repo.GetUsername(c, userId)
+	if err != nil {
+		return "", err
+	}
+	slug := SubdomainSlug(req.Name, username)
+
+	for attempt := 1; attempt <= maxSubdomainAttempts; attempt++ {
+		candidate := slug
+		if attempt > 1 {
+			candidate = fmt.Sprintf("%s-%d", slug, attempt)
+		}
+		if s.isReservedSubdomain(candidate) {
+			continue
+		}
+
+		taken, err := s.repo.SubdomainExists(c, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: no free subdomain for %s", ErrSubdomainTaken, slug)
+}
+
+// validateSubdomain - Satu label DNS (RFC 1123, diawali huruf) yang tidak reserved
+func (s Service) validateSubdomain(subdomain string) (string, error) {
+	if s.cfg.Ingress.BaseDomain == "" {
+		return "", fmt.Errorf("%s: ingress base domain is not configured", ErrInvalidSubdomain)
+	}
+
+	subdomain = strings.ToLower(strings.TrimSpace(subdomain))
+	if len(subdomain) > 63 || !dnsLabelRegex.MatchString(subdomain) {
+		return "", fmt.Errorf("%s: %q must be a DNS label (lowercase letters, numbers, '-', max 63 characters)", ErrInvalidSubdomain, subdomain)
+	}
+	if s.isReservedSubdomain(subdomain) {
+		return "", fmt.Errorf("%s: %s", ErrSubdomainReserved, subdomain)
+	}
+
+	return subdomain, nil
+}
+
+func (s Service) isReservedSubdomain(subdomain string) bool {
+	for _, reserved := range reservedSubdomains {
+		if subdomain == reserved {
+			return true
+		}
+	}
+	for _, reserved := range s.cfg.Ingress.ReservedSubdomains {
+		if subdomain == strings.ToLower(reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Service) subdomainHost(subdomain string) string {
+	return fmt.Sprintf("%s.%s", subdomain, s.cfg.Ingress.BaseDomain)
+}
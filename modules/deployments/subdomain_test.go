@@ -0,0 +1,108 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeSubdomainRepo - Hanya method yang dipakai RenameSubdomain
+type fakeSubdomainRepo struct {
+	DeploymentRepository
+	deployment Deployment
+}
+
+func (r *fakeSubdomainRepo) FindById(c context.Context, id, userId int) (*Deployment, error) {
+	data := r.deployment
+	return &data, nil
+}
+
+func (r *fakeSubdomainRepo) UpdateSubdomain(c context.Context, id int, subdomain, host string) error {
+	r.deployment.Subdomain = &subdomain
+	r.deployment.Host = host
+	return nil
+}
+
+func TestRenameSubdomain(t *testing.T) {
+	const oldHost = "shop.workspaces.test"
+	const newHost = "store.workspaces.test"
+
+	tests := []struct {
+		name string
+		// failUpdate - Update Ingress ke-n yang gagal (0 = tidak ada)
+		failUpdate int
+		wantErr    bool
+		wantHost   string
+	}{
+		{name: "renamed", wantHost: newHost},
+		{name: "ingress host update fails", failUpdate: 1, wantErr: true, wantHost: oldHost},
+		{name: "mirror update fails", failUpdate: 2, wantErr: true, wantHost: oldHost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subdomain := "shop"
+			repo := &fakeSubdomainRepo{deployment: Deployment{
+				Id: 1, UserId: 1, Namespace: testNamespace, IngressName: "shop-ingress",
+				Host: oldHost, Subdomain: &subdomain, Status: StatusRunning, IdleTimeoutMinutes: 30,
+			}}
+
+			ingress := &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "shop-ingress",
+					Namespace:   testNamespace,
+					Annotations: map[string]string{mirrorHostAnnotation: oldHost},
+				},
+				Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: oldHost}}},
+			}
+			clientset := fake.NewClientset(ingress)
+			updates := 0
+			clientset.PrependReactor("update", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updates++
+				if updates == tt.failUpdate {
+					return true, nil, errors.New("apiserver unavailable")
+				}
+				return false, nil, nil
+			})
+
+			cfg := config.Config{}
+			cfg.Ingress.BaseDomain = "workspaces.test"
+			cfg.Workspace.WakeServiceHost = "wake.workspaces.svc"
+			cfg.Workspace.WakeToken = "wake-token"
+			service := NewService(repo, nil, jobs.Service{}, k8s.NewK8sClientFromClientset(clientset), cfg)
+
+			_, err := service.RenameSubdomain(context.Background(), 1, 1, SubdomainRequest{Subdomain: "store"})
+			if tt.wantErr && err == nil {
+				t.Fatal("expected rename to fail")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected rename, got %v", err)
+			}
+
+			if repo.deployment.Host != tt.wantHost {
+				t.Fatalf("expected host %s in db, got %s", tt.wantHost, repo.deployment.Host)
+			}
+
+			current, err := clientset.NetworkingV1().Ingresses(testNamespace).Get(context.Background(), "shop-ingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if current.Spec.Rules[0].Host != tt.wantHost {
+				t.Fatalf("expected ingress host %s, got %s", tt.wantHost, current.Spec.Rules[0].Host)
+			}
+			// Mirror activity harus selalu mengikuti host Ingress
+			if current.Annotations[mirrorHostAnnotation] != tt.wantHost {
+				t.Fatalf("expected mirror host %s, got %s", tt.wantHost, current.Annotations[mirrorHostAnnotation])
+			}
+		})
+	}
+}
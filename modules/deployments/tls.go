@@ -0,0 +1,136 @@
+package deployments
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+const (
+	TLSStatusPending  = "pending" // secret belum ada, certificate belum di-issue
+	TLSStatusValid    = "valid"
+	TLSStatusExpiring = "expiring"
+	TLSStatusExpired  = "expired"
+	TLSStatusInvalid  = "invalid" // tls.crt rusak, belum berlaku, atau host tidak ada di SAN
+)
+
+const defaultCertExpiryWarningDays = 14
+
+// TLSStatus - Certificate untuk host utama & custom domain, dibaca dari secret TLS
+// di Ingress dan Certificate cert-manager kalau CRD-nya terpasang
+func (s Service) TLSStatus(c context.Context, id, userId int) (*TLSStatus, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TLSStatus{Certificates: []CertificateStatus{}}
+
+	// Ingress belum dibuat / sudah dihapus
+	switch data.Status {
+	case StatusPending, StatusProvisioning, StatusDeleted, StatusFailed:
+		return result, nil
+	}
+
+	ingress, err := s.k8s.GetIngress(c, data.Namespace, data.IngressName)
+	if err != nil {
+		return nil, err
+	}
+	result.Enabled = len(ingress.Spec.TLS) > 0
+	if !result.Enabled {
+		return result, nil
+	}
+
+	result.CertManager, err = s.k8s.CertManagerInstalled(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range ingress.Spec.TLS {
+		status, err := s.certificateStatus(c, data.Namespace, entry, result.CertManager)
+		if err != nil {
+			return nil, err
+		}
+		result.Certificates = append(result.Certificates, *status)
+	}
+
+	return result, nil
+}
+
+func (s Service) certificateStatus(c context.Context, namespace string, entry networkingv1.IngressTLS, certManager bool) (*CertificateStatus, error) {
+	certificate, err := s.k8s.GetTLSCertificate(c, namespace, entry.SecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CertificateStatus{
+		Hosts:       entry.Hosts,
+		Certificate: certificate,
+	}
+
+	// ingress-shim cert-manager memberi nama Certificate sama dengan secret
+	if certManager {
+		status.Issuance, err = s.k8s.GetCertManagerCertificate(c, namespace, entry.SecretName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case !certificate.Present:
+		status.Status = TLSStatusPending
+		return status, nil
+	case certificate.Error != "":
+		status.Status = TLSStatusInvalid
+		return status, nil
+	}
+
+	for _, host := range entry.Hosts {
+		if !certificateCovers(certificate.DNSNames, host) {
+			status.MissingHosts = append(status.MissingHosts, host)
+		}
+	}
+
+	now := time.Now()
+	days := int(certificate.NotAfter.Sub(now).Hours() / 24)
+	status.ExpiresInDays = &days
+
+	switch {
+	case now.After(*certificate.NotAfter):
+		status.Status = TLSStatusExpired
+	case now.Before(*certificate.NotBefore) || len(status.MissingHosts) > 0:
+		status.Status = TLSStatusInvalid
+	case days <= s.certExpiryWarningDays():
+		status.Status = TLSStatusExpiring
+	default:
+		status.Status = TLSStatusValid
+	}
+
+	return status, nil
+}
+
+// certificateCovers - host ada di SAN, termasuk wildcard satu label (*.example.com)
+func certificateCovers(dnsNames []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, name := range dnsNames {
+		name = strings.ToLower(name)
+		if name == host {
+			return true
+		}
+		if strings.HasPrefix(name, "*.") {
+			if i := strings.Index(host, "."); i > 0 && host[i:] == name[1:] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s Service) certExpiryWarningDays() int {
+	if s.cfg.Ingress.CertExpiryWarningDays <= 0 {
+		return defaultCertExpiryWarningDays
+	}
+	return s.cfg.Ingress.CertExpiryWarningDays
+}
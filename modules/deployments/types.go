@@ -0,0 +1,445 @@
+package deployments
+
+import (
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
+	"github.com/wafi11/backend-workspaces/modules/templates"
+)
+
+type Deployment struct {
+	Id            int               `json:"id"`
+	UserId        int               `json:"userId" db:"user_id"`
+	TemplateId    int               `json:"templateId" db:"template_id"`
+	Name          string            `json:"name" db:"name"`
+	Namespace     string            `json:"namespace" db:"namespace"`
+	AppName       string            `json:"appName" db:"app_name"`
+	Image         string            `json:"image" db:"image"`
+	Replicas      int               `json:"replicas" db:"replicas"`
+	ContainerPort int               `json:"containerPort" db:"container_port"`
+	Host          string            `json:"host" db:"host"`
+	Subdomain     *string           `json:"subdomain,omitempty" db:"subdomain"` // nil = host diisi manual
+	CPURequest    string            `json:"cpuRequest" db:"cpu_request"`
+	CPULimit      string            `json:"cpuLimit" db:"cpu_limit"`
+	MemoryRequest string            `json:"memoryRequest" db:"memory_request"`
+	MemoryLimit   string            `json:"memoryLimit" db:"memory_limit"`
+	EnvVars       map[string]string `json:"envVars" db:"env_vars"` // non-secret env, secret values hanya disimpan di K8s Secret
+
+	// Kalau di-set, replicas diatur HPA antara min dan max (Replicas = MinReplicas)
+	Autoscaling *templates.Autoscaling `json:"autoscaling,omitempty" db:"autoscaling"`
+
+	// Snapshot probes dari template saat deployment dibuat
+	Probes *templates.Probes `json:"probes,omitempty" db:"probes"`
+
+	// PVC tetap ada walaupun deployment di-update / dihapus tanpa deleteVolumes
+	Volumes []templates.Volume `json:"volumes" db:"volumes"`
+
+	// Snapshot sidecar & init container dari template
+	Sidecars       []templates.Container `json:"sidecars,omitempty" db:"sidecars"`
+	InitContainers []templates.Container `json:"initContainers,omitempty" db:"init_containers"`
+
+	// Managed database (nil = template tidak butuh database)
+	DatabaseType *string `json:"databaseType,omitempty" db:"database_type"`
+
+	// Snapshot annotations Ingress dari template (override default config)
+	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty" db:"ingress_annotations"`
+
+	// Preview environment: salinan workspace parent untuk satu branch / pull request
+	ParentId           *int       `json:"parentId,omitempty" db:"parent_id"`
+	PreviewBranch      *string    `json:"previewBranch,omitempty" db:"preview_branch"`
+	PreviewPullRequest *int       `json:"previewPullRequest,omitempty" db:"preview_pull_request"`
+	ExpiresAt          *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+
+	// Add-on yang dibuat bersama deployment (hanya diisi saat Create)
+	Addons []Addon `json:"addons,omitempty"`
+
+	// Kubernetes resources yang dibuat untuk deployment ini
+	ConfigMapName  string `json:"configMapName" db:"config_map_name"`
+	SecretName     string `json:"secretName" db:"secret_name"`
+	DeploymentName string `json:"deploymentName" db:"deployment_name"`
+	ServiceName    string `json:"serviceName" db:"service_name"`
+	IngressName    string `json:"ingressName" db:"ingress_name"`
+
+	Status          string  `json:"status" db:"status"`
+	StatusMessage   *string `json:"statusMessage,omitempty" db:"status_message"`
+	CurrentRevision int     `json:"currentRevision" db:"current_revision"`
+
+	// Scale-to-zero
+	IdleTimeoutMinutes int        `json:"idleTimeoutMinutes" db:"idle_timeout_minutes"`
+	Sleeping           bool       `json:"sleeping" db:"sleeping"`
+	LastActivityAt     *time.Time `json:"lastActivityAt,omitempty" db:"last_activity_at"`
+
+	// Audit
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+}
+
+// DeploymentSpec - Bagian deployment yang bisa diubah user, disimpan per revision
+type DeploymentSpec struct {
+	Image         string            `json:"image"`
+	Replicas      int               `json:"replicas"`
+	CPURequest    string            `json:"cpuRequest"`
+	CPULimit      string            `json:"cpuLimit"`
+	MemoryRequest string            `json:"memoryRequest"`
+	MemoryLimit   string            `json:"memoryLimit"`
+	EnvVars       map[string]string `json:"envVars"`
+
+	Autoscaling *templates.Autoscaling `json:"autoscaling,omitempty"`
+}
+
+// Spec - Snapshot spec yang sedang aktif
+func (d *Deployment) Spec() DeploymentSpec {
+	return DeploymentSpec{
+		Image:         d.Image,
+		Replicas:      d.Replicas,
+		CPURequest:    d.CPURequest,
+		CPULimit:      d.CPULimit,
+		MemoryRequest: d.MemoryRequest,
+		MemoryLimit:   d.MemoryLimit,
+		EnvVars:       d.EnvVars,
+		Autoscaling:   d.Autoscaling,
+	}
+}
+
+func (d *Deployment) applySpec(spec DeploymentSpec) {
+	d.Image = spec.Image
+	d.Replicas = spec.Replicas
+	d.CPURequest = spec.CPURequest
+	d.CPULimit = spec.CPULimit
+	d.MemoryRequest = spec.MemoryRequest
+	d.MemoryLimit = spec.MemoryLimit
+	d.EnvVars = spec.EnvVars
+	d.Autoscaling = spec.Autoscaling
+}
+
+// maxReplicas - Jumlah replica terbanyak yang bisa dipakai (dipakai untuk limit)
+func (spec DeploymentSpec) maxReplicas() int {
+	if spec.Autoscaling != nil {
+		return spec.Autoscaling.MaxReplicas
+	}
+	return spec.Replicas
+}
+
+// Revision - Snapshot immutable dari spec deployment
+type Revision struct {
+	Id           int            `json:"id"`
+	DeploymentId int            `json:"deploymentId" db:"deployment_id"`
+	Revision     int            `json:"revision" db:"revision"`
+	Spec         DeploymentSpec `json:"spec" db:"spec"`
+	ChangeCause  *string        `json:"changeCause,omitempty" db:"change_cause"`
+	RollbackOf   *int           `json:"rollbackOf,omitempty" db:"rollback_of"`
+	CreatedBy    string         `json:"createdBy" db:"created_by"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at"`
+}
+
+// StatusTransition - Satu baris di timeline status deployment
+type StatusTransition struct {
+	Id           int       `json:"id"`
+	DeploymentId int       `json:"deploymentId" db:"deployment_id"`
+	FromStatus   *string   `json:"fromStatus" db:"from_status"`
+	ToStatus     string    `json:"toStatus" db:"to_status"`
+	Actor        string    `json:"actor" db:"actor"`
+	Reason       *string   `json:"reason,omitempty" db:"reason"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+type CreateDeploymentRequest struct {
+	TemplateId int               `json:"templateId" validate:"required"`
+	Name       string            `json:"name" validate:"required,max=40"`
+	Host       string            `json:"host"`      // tidak bisa diisi lagi, host lain lewat custom domain
+	Subdomain  string            `json:"subdomain"` // kosong = slug dari nama workspace & username
+	Image      string            `json:"image"`
+	Replicas   int               `json:"replicas"`
+	EnvVars    map[string]string `json:"envVars"`
+
+	// nil = pakai DefaultAutoscaling dari template
+	Autoscaling *templates.Autoscaling `json:"autoscaling"`
+
+	// nil = pakai default dari config, 0 = tidak pernah di-park
+	IdleTimeoutMinutes *int `json:"idleTimeoutMinutes"`
+}
+
+// UpdateDeploymentRequest - Field kosong (nil) tidak diubah
+type UpdateDeploymentRequest struct {
+	Image         *string           `json:"image"`
+	Replicas      *int              `json:"replicas"`
+	CPURequest    *string           `json:"cpuRequest"`
+	CPULimit      *string           `json:"cpuLimit"`
+	MemoryRequest *string           `json:"memoryRequest"`
+	MemoryLimit   *string           `json:"memoryLimit"`
+	EnvVars       map[string]string `json:"envVars"` // replace semua non-secret env
+	ChangeCause   string            `json:"changeCause"`
+
+	// Autoscaling baru; maxReplicas 0 = matikan autoscaling (kembali ke replicas tetap)
+	Autoscaling *templates.Autoscaling `json:"autoscaling"`
+}
+
+// DeleteDeploymentRequest - Volume hanya ikut dihapus kalau Confirm sama dengan nama deployment
+type DeleteDeploymentRequest struct {
+	DeleteVolumes bool   `query:"deleteVolumes"`
+	Confirm       string `query:"confirm"`
+}
+
+// PodLogRequest - Query params stream log, Pod kosong = semua replica,
+// Container kosong = container utama workspace
+type PodLogRequest struct {
+	Pod          string `query:"pod"`
+	Container    string `query:"container"`
+	Follow       bool   `query:"follow"`
+	TailLines    int64  `query:"tailLines"`
+	SinceSeconds int64  `query:"sinceSeconds"`
+	Previous     bool   `query:"previous"`
+}
+
+type ScaleRequest struct {
+	Replicas int `json:"replicas" validate:"required"`
+}
+
+// DeploymentStatus - Status runtime deployment di cluster
+type DeploymentStatus struct {
+	Status          string `json:"status"`
+	Replicas        int    `json:"replicas"`        // replicas di spec (min replicas kalau autoscaling)
+	DesiredReplicas int32  `json:"desiredReplicas"` // spec.replicas Deployment (diubah oleh HPA)
+	CurrentReplicas int32  `json:"currentReplicas"`
+	ReadyReplicas   int32  `json:"readyReplicas"`
+	UpdatedReplicas int32  `json:"updatedReplicas"`
+	IsReady         bool   `json:"isReady"`
+
+	Autoscaler *k8s.AutoscalerStatus  `json:"autoscaler,omitempty"`
+	Database   *k8s.StatefulSetStatus `json:"database,omitempty"`
+	Addons     []AddonHealth          `json:"addons,omitempty"`
+}
+
+// TLSStatus - Certificate setiap entry TLS di Ingress workspace
+type TLSStatus struct {
+	Enabled      bool                `json:"enabled"`
+	CertManager  bool                `json:"certManager"` // CRD cert-manager terpasang di cluster
+	Certificates []CertificateStatus `json:"certificates"`
+}
+
+type CertificateStatus struct {
+	Hosts         []string                    `json:"hosts"`
+	Status        string                      `json:"status"`
+	ExpiresInDays *int                        `json:"expiresInDays,omitempty"`
+	MissingHosts  []string                    `json:"missingHosts,omitempty"` // host Ingress yang tidak ada di SAN
+	Certificate   *k8s.TLSCertificate         `json:"certificate"`
+	Issuance      *k8s.CertManagerCertificate `json:"issuance,omitempty"`
+}
+
+// Build - Build image dari git repo template. Image hasil build (by digest) di-apply
+// sebagai revision baru kalau Deploy true.
+type Build struct {
+	Id               int        `json:"id"`
+	DeploymentId     int        `json:"deploymentId" db:"deployment_id"`
+	Status           string     `json:"status" db:"status"`
+	StatusMessage    *string    `json:"statusMessage,omitempty" db:"status_message"`
+	Trigger          string     `json:"trigger" db:"trigger"`
+	GitRepoURL       string     `json:"gitRepoUrl" db:"git_repo_url"`
+	GitBranch        string     `json:"gitBranch" db:"git_branch"`
+	GitCommit        *string    `json:"gitCommit,omitempty" db:"git_commit"`
+	DockerfilePath   string     `json:"dockerfilePath" db:"dockerfile_path"`
+	Image            string     `json:"image" db:"image"` // repository, tag build-<id>
+	ImageDigest      *string    `json:"imageDigest,omitempty" db:"image_digest"`
+	JobName          *string    `json:"jobName,omitempty" db:"job_name"`
+	Deploy           bool       `json:"deploy" db:"deploy"`
+	DeployedRevision *int       `json:"deployedRevision,omitempty" db:"deployed_revision"`
+	CreatedBy        string     `json:"createdBy" db:"created_by"`
+	StartedAt        *time.Time `json:"startedAt,omitempty" db:"started_at"`
+	FinishedAt       *time.Time `json:"finishedAt,omitempty" db:"finished_at"`
+	CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// BuildLog - Log build yang sudah selesai, Content di-compress gzip
+type BuildLog struct {
+	BuildId   int       `json:"buildId" db:"build_id"`
+	Content   []byte    `json:"-" db:"content"`
+	SizeBytes int       `json:"sizeBytes" db:"size_bytes"`
+	Truncated bool      `json:"truncated" db:"truncated"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// BuildRequest - Branch kosong = branch template, Deploy nil = true
+type BuildRequest struct {
+	Branch string `json:"branch"`
+	Commit string `json:"commit"`
+	Deploy *bool  `json:"deploy"`
+}
+
+// BuildJob - Response build: record build beserta job yang memprosesnya
+type BuildJob struct {
+	Build *Build    `json:"build"`
+	Job   *jobs.Job `json:"job"`
+}
+
+// PreviewRequest - TTLHours 0 = previews.default_ttl_hours
+type PreviewRequest struct {
+	Branch      string `json:"branch" validate:"required"`
+	PullRequest int    `json:"pullRequest"`
+	TTLHours    int    `json:"ttlHours"`
+}
+
+// PreviewJob - Response create preview: deployment, job provision dan build branch
+type PreviewJob struct {
+	Deployment *Deployment `json:"deployment"`
+	Job        *jobs.Job   `json:"job"`
+	Build      *Build      `json:"build,omitempty"`
+}
+
+// Webhook - Endpoint push git per workspace. Secret hanya dikembalikan saat dibuat / dirotasi.
+type Webhook struct {
+	DeploymentId int       `json:"deploymentId" db:"deployment_id"`
+	UserId       int       `json:"-" db:"user_id"`
+	URL          string    `json:"url"`
+	Secret       string    `json:"secret,omitempty" db:"secret"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// WebhookDelivery - Satu request webhook beserta hasilnya. Payload disimpan untuk redeliver.
+type WebhookDelivery struct {
+	Id            int       `json:"id"`
+	DeploymentId  int       `json:"deploymentId" db:"deployment_id"`
+	Provider      string    `json:"provider" db:"provider"`
+	Event         *string   `json:"event,omitempty" db:"event"`
+	DeliveryId    *string   `json:"deliveryId,omitempty" db:"delivery_id"` // id dari provider
+	Status        string    `json:"status" db:"status"`
+	StatusMessage *string   `json:"statusMessage,omitempty" db:"status_message"`
+	Ref           *string   `json:"ref,omitempty" db:"ref"`
+	CommitSha     *string   `json:"commitSha,omitempty" db:"commit_sha"`
+	BuildId       *int      `json:"buildId,omitempty" db:"build_id"`
+	RedeliveryOf  *int      `json:"redeliveryOf,omitempty" db:"redelivery_of"`
+	Payload       *string   `json:"-" db:"payload"`
+	PayloadDigest *string   `json:"-" db:"payload_sha256"` // sha256 body, replay protection
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// WebhookReceipt - Response ke provider, detail delivery hanya untuk pemilik workspace
+type WebhookReceipt struct {
+	Id     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+// WebhookRequest - Header & body webhook yang sudah dibaca handler
+type WebhookRequest struct {
+	Provider   string
+	Event      string
+	DeliveryId string
+	Signature  string // GitHub/Gitea: HMAC-SHA256 body, GitLab: token
+	Body       []byte
+}
+
+// Addon - Service pendukung (redis, rabbitmq) di namespace workspace
+type Addon struct {
+	Id            int       `json:"id"`
+	DeploymentId  int       `json:"deploymentId" db:"deployment_id"`
+	Type          string    `json:"type" db:"type"`
+	Persistent    bool      `json:"persistent" db:"persistent"`
+	Status        string    `json:"status" db:"status"`
+	StatusMessage *string   `json:"statusMessage,omitempty" db:"status_message"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AddonHealth - Status add-on di DB beserta replica yang ready di cluster
+type AddonHealth struct {
+	Addon
+	ReadyReplicas int32 `json:"readyReplicas"`
+	IsReady       bool  `json:"isReady"`
+}
+
+// AddonJob - Response attach/detach: add-on beserta job yang memprosesnya
+type AddonJob struct {
+	Addon *Addon    `json:"addon"`
+	Job   *jobs.Job `json:"job"`
+}
+
+// QuotaStatus - Limits dari plan, Reserved dari spec semua workspace, Used dari ResourceQuota di cluster
+type QuotaStatus struct {
+	Plan     string            `json:"plan"`
+	Limits   map[string]string `json:"limits,omitempty"`
+	Reserved map[string]string `json:"reserved"`
+	Used     map[string]string `json:"used,omitempty"`
+}
+
+// Domain - Custom domain workspace, masuk ke Ingress setelah record TXT terverifikasi
+type Domain struct {
+	Id                int        `json:"id"`
+	DeploymentId      int        `json:"deploymentId" db:"deployment_id"`
+	UserId            int        `json:"userId" db:"user_id"`
+	Domain            string     `json:"domain" db:"domain"`
+	VerificationToken string     `json:"-" db:"verification_token"`
+	Status            string     `json:"status" db:"status"`
+	StatusMessage     *string    `json:"statusMessage,omitempty" db:"status_message"`
+	VerifiedAt        *time.Time `json:"verifiedAt,omitempty" db:"verified_at"`
+	LastCheckedAt     *time.Time `json:"lastCheckedAt,omitempty" db:"last_checked_at"`
+	CreatedAt         time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updatedAt" db:"updated_at"`
+
+	// Record DNS yang harus dibuat user
+	Verification *DomainVerification `json:"verification,omitempty"`
+}
+
+type DomainVerification struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type SubdomainRequest struct {
+	Subdomain string `json:"subdomain" validate:"required"`
+}
+
+type DomainRequest struct {
+	Domain string `json:"domain" validate:"required"`
+}
+
+// EgressRule - Tujuan di luar cluster yang boleh diakses semua workspace user
+type EgressRule struct {
+	Id          int       `json:"id"`
+	UserId      int       `json:"userId" db:"user_id"`
+	CIDR        string    `json:"cidr" db:"cidr"`
+	Port        *int      `json:"port,omitempty" db:"port"` // nil = semua port
+	Protocol    string    `json:"protocol" db:"protocol"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+type EgressRuleRequest struct {
+	CIDR        string `json:"cidr" validate:"required"`
+	Port        *int   `json:"port"`
+	Protocol    string `json:"protocol"`
+	Description string `json:"description"`
+}
+
+// AttachAddonRequest - Persistent nil = default dari config
+type AttachAddonRequest struct {
+	Type       string `json:"type" validate:"required"`
+	Persistent *bool  `json:"persistent"`
+}
+
+type IdleTimeoutRequest struct {
+	Minutes int `json:"minutes"`
+}
+
+// WakeStatus - Data untuk halaman "starting" workspace yang sedang dibangunkan
+type WakeStatus struct {
+	Name          string
+	Status        string
+	Paused        bool
+	ReadyReplicas int32
+	Replicas      int32
+	IsReady       bool
+}
+
+type RollbackRequest struct {
+	Revision int `json:"revision" validate:"required"`
+}
+
+// DeploymentJob - Response untuk operasi async, job bisa di-poll di /jobs/:id
+type DeploymentJob struct {
+	Deployment *Deployment `json:"deployment"`
+	Job        *jobs.Job   `json:"job"`
+}
@@ -0,0 +1,49 @@
+package deployments
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// wakePage - Ditampilkan selama workspace dibangunkan; browser refresh otomatis
+// sampai Ingress kembali mengarah ke workspace.
+var wakePage = template.Must(template.New("wake").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>{{if .Name}}{{.Name}}{{else}}Workspace{{end}}</title>
+	{{if not .Paused}}<meta http-equiv="refresh" content="5">{{end}}
+	<style>
+		body { font-family: sans-serif; display: flex; align-items: center; justify-content: center; height: 100vh; margin: 0; color: #333; }
+		.box { text-align: center; }
+	</style>
+</head>
+<body>
+	<div class="box">
+		{{if .Paused}}
+		<h1>{{.Name}} is paused</h1>
+		<p>Resume the workspace from the dashboard to access it again.</p>
+		{{else if .Name}}
+		<h1>Starting {{.Name}}…</h1>
+		<p>The workspace was idle and is waking up ({{.ReadyReplicas}}/{{.Replicas}} replicas ready).</p>
+		<p>This page refreshes automatically.</p>
+		{{else}}
+		<h1>{{.Status}}</h1>
+		{{end}}
+	</div>
+</body>
+</html>
+`))
+
+func renderWakePage(c *fiber.Ctx, data *WakeStatus) error {
+	var buf bytes.Buffer
+	if err := wakePage.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,609 @@
+package deployments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	WebhookGitHub = "github"
+	WebhookGitLab = "gitlab"
+	WebhookGitea  = "gitea"
+)
+
+const (
+	DeliveryReceived = "received" // dicatat sebelum diproses, mengunci delivery id
+	DeliveryAccepted = "accepted" // build di-enqueue / preview dibuat atau dihapus
+	DeliveryIgnored  = "ignored"  // event, repo atau branch tidak relevan untuk workspace
+	DeliveryFailed   = "failed"   // build tidak bisa dimulai, bisa di-redeliver
+)
+
+const BuildTriggerWebhook = "webhook"
+
+// zeroCommit - "after" saat branch dihapus
+const zeroCommit = "0000000000000000000000000000000000000000"
+
+const (
+	// webhookMaxAge - Delivery dengan timestamp di body yang di-sign lebih lama dari ini
+	// ditolak, redeliver manual lewat API tidak dibatasi
+	webhookMaxAge = time.Hour
+
+	// webhookClockSkew - Toleransi jam provider yang lebih cepat dari server
+	webhookClockSkew = 5 * time.Minute
+)
+
+// pushEvents - Nama event push per provider
+var pushEvents = map[string]string{
+	WebhookGitHub: "push",
+	WebhookGitLab: "Push Hook",
+	WebhookGitea:  "push",
+}
+
+// pullRequestEvents - Nama event pull request / merge request per provider
+var pullRequestEvents = map[string]string{
+	WebhookGitHub: "pull_request",
+	WebhookGitLab: "Merge Request Hook",
+	WebhookGitea:  "pull_request",
+}
+
+// pushEvent - Field payload push yang dipakai, sama untuk semua provider
+type pushEvent struct {
+	Ref      string
+	After    string
+	Deleted  bool
+	RepoURLs []string
+}
+
+const (
+	pullRequestOpened = "opened"
+	pullRequestClosed = "closed"
+)
+
+// pullRequestActions - Action yang membuat / menghapus preview, "merge" hanya ada di GitLab
+var pullRequestActions = map[string]string{
+	"opened":   pullRequestOpened,
+	"reopened": pullRequestOpened,
+	"open":     pullRequestOpened,
+	"reopen":   pullRequestOpened,
+	"closed":   pullRequestClosed,
+	"close":    pullRequestClosed,
+	"merge":    pullRequestClosed,
+}
+
+// pullRequestEvent - HeadRepoURLs kosong kalau provider tidak mengirim repo asal branch
+type pullRequestEvent struct {
+	Action       string
+	Number       int
+	Branch       string
+	Commit       string
+	Fork         bool
+	RepoURLs     []string
+	HeadRepoURLs []string
+}
+
+// repositoryPayload - URL repo di payload GitHub/Gitea ("repository") dan GitLab ("project")
+type repositoryPayload struct {
+	Repository struct {
+		CloneURL   string `json:"clone_url"`
+		HTMLURL    string `json:"html_url"`
+		SSHURL     string `json:"ssh_url"`
+		GitHTTPURL string `json:"git_http_url"`
+		Homepage   string `json:"homepage"`
+	} `json:"repository"`
+	Project struct {
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		WebURL     string `json:"web_url"`
+	} `json:"project"`
+}
+
+// GetWebhook - URL webhook tanpa secret
+func (s Service) GetWebhook(c context.Context, id, userId int) (*Webhook, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.GetWebhook(c, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	webhook.URL = s.webhookURL(id)
+	return webhook, nil
+}
+
+// RotateWebhook - Buat webhook atau ganti secret. Secret lama langsung tidak berlaku.
+func (s Service) RotateWebhook(c context.Context, id, userId int) (*Webhook, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := s.templates.FindById(c, data.TemplateId)
+	if err != nil {
+		return nil, err
+	}
+	if tmpl.GitRepoURL == "" {
+		return nil, fmt.Errorf("%s: %s", ErrNoGitRepo, tmpl.Name)
+	}
+
+	secret, err := generatePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.SaveWebhook(c, data.Id, secret)
+	if err != nil {
+		return nil, err
+	}
+	webhook.UserId = data.UserId
+	webhook.URL = s.webhookURL(data.Id)
+	return webhook, nil
+}
+
+func (s Service) DeleteWebhook(c context.Context, id, userId int) error {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetWebhook(c, id); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteWebhook(c, id)
+}
+
+func (s Service) ListDeliveries(c context.Context, id, userId int) ([]WebhookDelivery, error) {
+	if _, err := s.repo.FindById(c, id, userId); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListDeliveries(c, id)
+}
+
+// ReceiveWebhook - Verifikasi signature lalu proses push. Webhook yang tidak ada dan signature
+// salah sama-sama 401 tanpa dicatat, supaya request tanpa secret tidak bisa menebak id
+// deployment atau mengisi tabel delivery. Header delivery id tidak ikut di-sign, jadi replay
+// dicek dari digest body (unique index) dan timestamp event di body kalau ada.
+// Response hanya id & status, detail delivery dibaca pemilik workspace lewat ListDeliveries.
+func (s Service) ReceiveWebhook(c context.Context, id int, req WebhookRequest) (*WebhookReceipt, error) {
+	if _, ok := pushEvents[req.Provider]; !ok {
+		return nil, fmt.Errorf("%s: unknown provider, expected GitHub, GitLab or Gitea headers", ErrInvalidWebhook)
+	}
+	if req.DeliveryId == "" {
+		return nil, fmt.Errorf("%s: missing delivery id header", ErrInvalidWebhook)
+	}
+
+	webhook, err := s.repo.GetWebhook(c, id)
+	if err != nil && !strings.Contains(err.Error(), string(ErrWebhookMissing)) {
+		return nil, err
+	}
+	if webhook == nil || !verifyWebhookSignature(req, webhook.Secret) {
+		log.Printf("rejected %s webhook for deployment %d (delivery %q)", req.Provider, id, req.DeliveryId)
+		return nil, fmt.Errorf("%s", ErrWebhookSignature)
+	}
+
+	if err := checkDeliveryAge(req, time.Now()); err != nil {
+		log.Printf("rejected %s webhook for deployment %d (delivery %q): %s", req.Provider, id, req.DeliveryId, err.Error())
+		return nil, err
+	}
+
+	data, err := s.repo.FindById(c, id, webhook.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.processDelivery(c, data, newDelivery(id, req), req.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookReceipt{Id: delivery.Id, Status: delivery.Status}, nil
+}
+
+// Redeliver - Proses ulang payload delivery sebelumnya (tanpa verifikasi signature,
+// request datang dari pemilik workspace)
+func (s Service) Redeliver(c context.Context, id, userId, deliveryId int) (*WebhookDelivery, error) {
+	data, err := s.repo.FindById(c, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := s.repo.GetDelivery(c, data.Id, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	if original.Payload == nil {
+		return nil, fmt.Errorf("%s: delivery %d has no stored payload", ErrInvalidWebhook, original.Id)
+	}
+
+	delivery := WebhookDelivery{
+		DeploymentId:  data.Id,
+		Provider:      original.Provider,
+		Event:         original.Event,
+		DeliveryId:    original.DeliveryId,
+		RedeliveryOf:  &original.Id,
+		Payload:       original.Payload,
+		PayloadDigest: original.PayloadDigest,
+	}
+	return s.processDelivery(c, data, delivery, []byte(*original.Payload))
+}
+
+// processDelivery - Push: build + roll out, pull request: buat / hapus preview.
+// Delivery dicatat lebih dulu (delivery id yang sama ditolak 409), lalu hasilnya di-update.
+func (s Service) processDelivery(c context.Context, data *Deployment, delivery WebhookDelivery, body []byte) (*WebhookDelivery, error) {
+	delivery.Status = DeliveryReceived
+	created, err := s.repo.CreateDelivery(c, delivery)
+	if err != nil {
+		return nil, err
+	}
+	delivery = *created
+
+	event := ""
+	if delivery.Event != nil {
+		event = *delivery.Event
+	}
+
+	switch event {
+	case pushEvents[delivery.Provider]:
+		delivery.Status, delivery.StatusMessage = s.handlePush(c, data, &delivery, body)
+	case pullRequestEvents[delivery.Provider]:
+		delivery.Status, delivery.StatusMessage = s.handlePullRequest(c, data, &delivery, body)
+	default:
+		delivery.Status = DeliveryIgnored
+		delivery.StatusMessage = optionalString(fmt.Sprintf("event %q is not a push or pull request", event))
+	}
+
+	if err := s.repo.UpdateDeliveryResult(context.WithoutCancel(c), delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (s Service) handlePush(c context.Context, data *Deployment, delivery *WebhookDelivery, body []byte) (string, *string) {
+	push, err := parsePushEvent(delivery.Provider, body)
+	if err != nil {
+		return DeliveryFailed, optionalString(err.Error())
+	}
+	delivery.Ref = optionalString(push.Ref)
+	delivery.CommitSha = optionalString(push.After)
+
+	branch, ok := strings.CutPrefix(push.Ref, "refs/heads/")
+	if !ok {
+		return DeliveryIgnored, optionalString(fmt.Sprintf("ref %s is not a branch", push.Ref))
+	}
+	if push.Deleted {
+		return DeliveryIgnored, optionalString(fmt.Sprintf("branch %s was deleted", branch))
+	}
+
+	tmpl, err := s.templates.FindById(c, data.TemplateId)
+	if err != nil {
+		return DeliveryFailed, optionalString(err.Error())
+	}
+	if !repoMatches(tmpl.GitRepoURL, push.RepoURLs) {
+		return DeliveryIgnored, optionalString(fmt.Sprintf("repository does not match %s", tmpl.GitRepoURL))
+	}
+
+	// Push ke branch lain hanya di-build kalau branch tersebut punya preview
+	target := data
+	if want := valueOr(tmpl.GitBranch, "main"); branch != want {
+		preview, err := s.findPreview(c, data.Id, branch)
+		if err != nil {
+			return DeliveryFailed, optionalString(err.Error())
+		}
+		if preview == nil {
+			return DeliveryIgnored, optionalString(fmt.Sprintf("branch %s does not match %s and has no preview", branch, want))
+		}
+		target = preview
+	}
+
+	unlock, err := s.lockQuota(c, target.UserId)
+	if err != nil {
+		return DeliveryFailed, optionalString(err.Error())
+	}
+	defer unlock()
+
+	deploy := true
+	build, err := s.startBuild(c, target, tmpl, BuildRequest{Branch: branch, Commit: push.After, Deploy: &deploy},
+		BuildTriggerWebhook, "webhook:"+delivery.Provider)
+	if err != nil {
+		return DeliveryFailed, optionalString(err.Error())
+	}
+
+	delivery.BuildId = &build.Build.Id
+	if target != data {
+		return DeliveryAccepted, optionalString(fmt.Sprintf("build %d queued for preview %s", build.Build.Id, target.Name))
+	}
+	return DeliveryAccepted, optionalString(fmt.Sprintf("build %d queued", build.Build.Id))
+}
+
+// handlePullRequest - Opened / reopened membuat preview untuk branch PR, closed / merged
+// menghapusnya. Update branch PR di-build lewat event push.
+func (s Service) handlePullRequest(c context.Context, data *Deployment, delivery *WebhookDelivery, body []byte) (string, *string) {
+	pr, err := parsePullRequestEvent(delivery.Provider, body)
+	if err != nil {
+		return DeliveryFailed, optionalString(err.Error())
+	}
+	delivery.Ref = optionalString("refs/heads/" + pr.Branch)
+	delivery.CommitSha = optionalString(pr.Commit)
+
+	action, ok := pullRequestActions[pr.Action]
+	if !ok {
+		return DeliveryIgnored, optionalString(fmt.Sprintf("pull request action %q is not handled", pr.Action))
+	}
+
+	tmpl, err := s.templates.FindById(c, data.TemplateId)
+	if err != nil {
+		return DeliveryFailed, optionalString(err.Error())
+	}
+	if !repoMatches(tmpl.GitRepoURL, pr.RepoURLs) {
+		return DeliveryIgnored, optionalString(fmt.Sprintf("repository does not match %s", tmpl.GitRepoURL))
+	}
+
+	actor := "webhook:" + delivery.Provider
+	if action == pullRequestClosed {
+		preview, err := s.findPreview(c, data.Id, pr.Branch)
+		if err != nil {
+			return DeliveryFailed, optionalString(err.Error())
+		}
+		if preview == nil {
+			return DeliveryIgnored, optionalString(fmt.Sprintf("branch %s has no preview", pr.Branch))
+		}
+		if _, err := s.destroyPreview(c, preview, actor, previewPullRequestDoneReason); err != nil {
+			return DeliveryFailed, optionalString(err.Error())
+		}
+		return DeliveryAccepted, optionalString(fmt.Sprintf("preview %s deleted", preview.Name))
+	}
+
+	// Branch dari fork tidak ada di repo template, jadi tidak bisa di-build
+	if pr.Fork || (len(pr.HeadRepoURLs) > 0 && !repoMatches(tmpl.GitRepoURL, pr.HeadRepoURLs)) {
+		return DeliveryIgnored, optionalString(fmt.Sprintf("pull request %d comes from a fork", pr.Number))
+	}
+
+	result, err := s.createPreview(c, data, PreviewRequest{Branch: pr.Branch, PullRequest: pr.Number}, actor)
+	if err != nil {
+		if strings.Contains(err.Error(), string(ErrPreviewExists)) {
+			return DeliveryIgnored, optionalString(err.Error())
+		}
+		return DeliveryFailed, optionalString(err.Error())
+	}
+
+	if result.Build != nil {
+		delivery.BuildId = &result.Build.Id
+	}
+	return DeliveryAccepted, optionalString(fmt.Sprintf("preview %s created", result.Deployment.Name))
+}
+
+// teardownWebhook - Webhook workspace yang dihapus tidak boleh memicu build lagi
+func (s Service) teardownWebhook(c context.Context, data *Deployment) error {
+	return s.repo.DeleteWebhook(c, data.Id)
+}
+
+func (s Service) webhookURL(id int) string {
+	return fmt.Sprintf("%s/webhooks/git/%d", strings.TrimSuffix(s.cfg.Builds.WebhookBaseURL, "/"), id)
+}
+
+func newDelivery(id int, req WebhookRequest) WebhookDelivery {
+	digest := sha256.Sum256(req.Body)
+	delivery := WebhookDelivery{
+		DeploymentId:  id,
+		Provider:      req.Provider,
+		Event:         optionalString(req.Event),
+		DeliveryId:    optionalString(req.DeliveryId),
+		PayloadDigest: optionalString(hex.EncodeToString(digest[:])),
+	}
+	if utf8.Valid(req.Body) {
+		payload := string(req.Body)
+		delivery.Payload = &payload
+	}
+	return delivery
+}
+
+// verifyWebhookSignature - GitHub: X-Hub-Signature-256 "sha256=<hex>", Gitea: X-Gitea-Signature
+// "<hex>", GitLab: X-Gitlab-Token berisi secret apa adanya. Token GitLab tidak mengikat body,
+// jadi untuk GitLab replay hanya dicegah digest body.
+func verifyWebhookSignature(req WebhookRequest, secret string) bool {
+	if req.Signature == "" || secret == "" {
+		return false
+	}
+
+	if req.Provider == WebhookGitLab {
+		return subtle.ConstantTimeCompare([]byte(req.Signature), []byte(secret)) == 1
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(req.Signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(req.Body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// checkDeliveryAge - Timestamp hanya dipercaya kalau body di-sign HMAC (GitHub & Gitea):
+// repository.pushed_at di event push (GitHub, unix atau RFC3339) dan pull_request.updated_at
+// di event pull request. Event tanpa timestamp hanya dilindungi digest body.
+func checkDeliveryAge(req WebhookRequest, now time.Time) error {
+	if req.Provider == WebhookGitLab {
+		return nil
+	}
+
+	var payload struct {
+		Repository struct {
+			PushedAt json.RawMessage `json:"pushed_at"`
+		} `json:"repository"`
+		PullRequest struct {
+			UpdatedAt string `json:"updated_at"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil
+	}
+
+	var sent time.Time
+	switch req.Event {
+	case pushEvents[req.Provider]:
+		var unix int64
+		var text string
+		if err := json.Unmarshal(payload.Repository.PushedAt, &unix); err == nil && unix > 0 {
+			sent = time.Unix(unix, 0)
+		} else if err := json.Unmarshal(payload.Repository.PushedAt, &text); err == nil {
+			sent, _ = time.Parse(time.RFC3339, text)
+		}
+	case pullRequestEvents[req.Provider]:
+		sent, _ = time.Parse(time.RFC3339, payload.PullRequest.UpdatedAt)
+	}
+	if sent.IsZero() {
+		return nil
+	}
+
+	if sent.Before(now.Add(-webhookMaxAge)) || sent.After(now.Add(webhookClockSkew)) {
+		return fmt.Errorf("%s: event time %s is outside the %s window", ErrDeliveryExpired, sent.UTC().Format(time.RFC3339), webhookMaxAge)
+	}
+	return nil
+}
+
+func parsePushEvent(provider string, body []byte) (*pushEvent, error) {
+	var payload struct {
+		repositoryPayload
+		Ref     string `json:"ref"`
+		After   string `json:"after"`
+		Deleted bool   `json:"deleted"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%s: %s payload: %s", ErrInvalidWebhook, provider, err.Error())
+	}
+	if payload.Ref == "" {
+		return nil, fmt.Errorf("%s: %s payload has no ref", ErrInvalidWebhook, provider)
+	}
+
+	return &pushEvent{
+		Ref:      payload.Ref,
+		After:    strings.ToLower(payload.After),
+		Deleted:  payload.Deleted || payload.After == zeroCommit,
+		RepoURLs: payload.urls(),
+	}, nil
+}
+
+// parsePullRequestEvent - GitHub & Gitea: action, number, pull_request.head,
+// GitLab: object_attributes (iid, source_branch, last_commit)
+func parsePullRequestEvent(provider string, body []byte) (*pullRequestEvent, error) {
+	var payload struct {
+		repositoryPayload
+		Action      string `json:"action"`
+		Number      int    `json:"number"`
+		PullRequest struct {
+			Head struct {
+				Ref  string `json:"ref"`
+				Sha  string `json:"sha"`
+				Repo struct {
+					CloneURL string `json:"clone_url"`
+					HTMLURL  string `json:"html_url"`
+					SSHURL   string `json:"ssh_url"`
+				} `json:"repo"`
+			} `json:"head"`
+		} `json:"pull_request"`
+		ObjectAttributes struct {
+			Action          string `json:"action"`
+			IID             int    `json:"iid"`
+			SourceBranch    string `json:"source_branch"`
+			SourceProjectId int    `json:"source_project_id"`
+			TargetProjectId int    `json:"target_project_id"`
+			LastCommit      struct {
+				Id string `json:"id"`
+			} `json:"last_commit"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%s: %s payload: %s", ErrInvalidWebhook, provider, err.Error())
+	}
+
+	event := &pullRequestEvent{RepoURLs: payload.urls()}
+	if provider == WebhookGitLab {
+		attrs := payload.ObjectAttributes
+		event.Action = attrs.Action
+		event.Number = attrs.IID
+		event.Branch = attrs.SourceBranch
+		event.Commit = strings.ToLower(attrs.LastCommit.Id)
+		event.Fork = attrs.SourceProjectId != attrs.TargetProjectId
+	} else {
+		head := payload.PullRequest.Head
+		event.Action = payload.Action
+		event.Number = payload.Number
+		event.Branch = head.Ref
+		event.Commit = strings.ToLower(head.Sha)
+		event.HeadRepoURLs = nonEmpty(head.Repo.CloneURL, head.Repo.HTMLURL, head.Repo.SSHURL)
+	}
+
+	if event.Branch == "" || event.Number <= 0 {
+		return nil, fmt.Errorf("%s: %s payload has no pull request branch", ErrInvalidWebhook, provider)
+	}
+	return event, nil
+}
+
+func (p repositoryPayload) urls() []string {
+	return nonEmpty(
+		p.Repository.CloneURL,
+		p.Repository.HTMLURL,
+		p.Repository.SSHURL,
+		p.Repository.GitHTTPURL,
+		p.Repository.Homepage,
+		p.Project.GitHTTPURL,
+		p.Project.GitSSHURL,
+		p.Project.WebURL,
+	)
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func repoMatches(repoURL string, candidates []string) bool {
+	want := normalizeRepoURL(repoURL)
+	for _, candidate := range candidates {
+		if normalizeRepoURL(candidate) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRepoURL - https://host/owner/repo.git, git@host:owner/repo dan ssh://git@host/owner/repo
+// jadi host/owner/repo
+func normalizeRepoURL(raw string) string {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+
+	if !strings.Contains(raw, "://") {
+		// scp-like: git@host:owner/repo.git
+		if at := strings.Index(raw, "@"); at >= 0 {
+			raw = raw[at+1:]
+		}
+		raw = strings.Replace(raw, ":", "/", 1)
+	} else if parsed, err := url.Parse(raw); err == nil {
+		raw = parsed.Hostname() + parsed.Path
+	}
+
+	raw = strings.TrimSuffix(raw, "/")
+	return strings.TrimSuffix(raw, ".git")
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,161 @@
+package deployments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+const testWebhookSecret = "webhook-secret"
+
+// fakeWebhookRepo - Delivery di memory dengan unique index yang sama seperti tabel aslinya
+type fakeWebhookRepo struct {
+	DeploymentRepository
+	deliveries []WebhookDelivery
+}
+
+func (r *fakeWebhookRepo) GetWebhook(c context.Context, deploymentId int) (*Webhook, error) {
+	return &Webhook{DeploymentId: deploymentId, UserId: 1, Secret: testWebhookSecret}, nil
+}
+
+func (r *fakeWebhookRepo) FindById(c context.Context, id, userId int) (*Deployment, error) {
+	return &Deployment{Id: id, UserId: userId, Name: "shop", Status: StatusRunning}, nil
+}
+
+func (r *fakeWebhookRepo) CreateDelivery(c context.Context, delivery WebhookDelivery) (*WebhookDelivery, error) {
+	for _, existing := range r.deliveries {
+		if existing.RedeliveryOf != nil || delivery.RedeliveryOf != nil {
+			continue
+		}
+		sameId := existing.DeliveryId != nil && delivery.DeliveryId != nil && *existing.DeliveryId == *delivery.DeliveryId
+		sameBody := existing.PayloadDigest != nil && delivery.PayloadDigest != nil && *existing.PayloadDigest == *delivery.PayloadDigest
+		if sameId || sameBody {
+			return nil, fmt.Errorf("%s", ErrDeliveryReplayed)
+		}
+	}
+	delivery.Id = len(r.deliveries) + 1
+	r.deliveries = append(r.deliveries, delivery)
+	return &delivery, nil
+}
+
+func (r *fakeWebhookRepo) UpdateDeliveryResult(c context.Context, delivery WebhookDelivery) error {
+	r.deliveries[delivery.Id-1] = delivery
+	return nil
+}
+
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+
+	tests := []struct {
+		name string
+		req  WebhookRequest
+		want bool
+	}{
+		{name: "github", req: WebhookRequest{Provider: WebhookGitHub, Signature: "sha256=" + signBody(testWebhookSecret, body), Body: body}, want: true},
+		{name: "gitea without prefix", req: WebhookRequest{Provider: WebhookGitea, Signature: signBody(testWebhookSecret, body), Body: body}, want: true},
+		{name: "wrong secret", req: WebhookRequest{Provider: WebhookGitHub, Signature: "sha256=" + signBody("other", body), Body: body}},
+		{name: "tampered body", req: WebhookRequest{Provider: WebhookGitHub, Signature: "sha256=" + signBody(testWebhookSecret, body), Body: []byte(`{"ref":"refs/heads/evil"}`)}},
+		{name: "invalid hex", req: WebhookRequest{Provider: WebhookGitea, Signature: "not-hex", Body: body}},
+		{name: "missing signature", req: WebhookRequest{Provider: WebhookGitHub, Body: body}},
+		{name: "gitlab token", req: WebhookRequest{Provider: WebhookGitLab, Signature: testWebhookSecret, Body: body}, want: true},
+		{name: "gitlab wrong token", req: WebhookRequest{Provider: WebhookGitLab, Signature: "guess", Body: body}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyWebhookSignature(tt.req, testWebhookSecret); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckDeliveryAge(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-2 * webhookMaxAge)
+
+	tests := []struct {
+		name    string
+		req     WebhookRequest
+		wantErr bool
+	}{
+		{name: "github push", req: WebhookRequest{Provider: WebhookGitHub, Event: "push", Body: fmt.Appendf(nil, `{"repository":{"pushed_at":%d}}`, now.Unix())}},
+		{name: "stale github push", req: WebhookRequest{Provider: WebhookGitHub, Event: "push", Body: fmt.Appendf(nil, `{"repository":{"pushed_at":%d}}`, old.Unix())}, wantErr: true},
+		{name: "push from the future", req: WebhookRequest{Provider: WebhookGitHub, Event: "push", Body: fmt.Appendf(nil, `{"repository":{"pushed_at":%d}}`, now.Add(time.Hour).Unix())}, wantErr: true},
+		{name: "stale pull request", req: WebhookRequest{Provider: WebhookGitea, Event: "pull_request", Body: fmt.Appendf(nil, `{"pull_request":{"updated_at":%q}}`, old.Format(time.RFC3339))}, wantErr: true},
+		{name: "pull request", req: WebhookRequest{Provider: WebhookGitea, Event: "pull_request", Body: fmt.Appendf(nil, `{"pull_request":{"updated_at":%q}}`, now.Format(time.RFC3339))}},
+		{name: "ping keeps the repository push time", req: WebhookRequest{Provider: WebhookGitHub, Event: "ping", Body: fmt.Appendf(nil, `{"repository":{"pushed_at":%q}}`, old.Format(time.RFC3339))}},
+		{name: "push without timestamp", req: WebhookRequest{Provider: WebhookGitea, Event: "push", Body: []byte(`{"ref":"refs/heads/main"}`)}},
+		{name: "gitlab body is not signed", req: WebhookRequest{Provider: WebhookGitLab, Event: "Merge Request Hook", Body: fmt.Appendf(nil, `{"pull_request":{"updated_at":%q}}`, old.Format(time.RFC3339))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDeliveryAge(tt.req, now)
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), string(ErrDeliveryExpired))) {
+				t.Fatalf("expected %s, got %v", ErrDeliveryExpired, err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected delivery to be accepted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReceiveWebhookReplay(t *testing.T) {
+	repo := &fakeWebhookRepo{}
+	service := NewService(repo, nil, jobs.Service{}, nil, config.Config{})
+
+	body := []byte(`{"zen":"keep it simple"}`)
+	req := WebhookRequest{Provider: WebhookGitHub, Event: "ping", DeliveryId: "a", Signature: "sha256=" + signBody(testWebhookSecret, body), Body: body}
+
+	receipt, err := service.ReceiveWebhook(context.Background(), 1, req)
+	if err != nil {
+		t.Fatalf("expected delivery to be received, got %v", err)
+	}
+	if receipt.Id != 1 || receipt.Status != DeliveryIgnored {
+		t.Fatalf("unexpected receipt %+v", receipt)
+	}
+
+	tests := []struct {
+		name       string
+		deliveryId string
+		body       []byte
+		wantErr    ErrorMessage
+	}{
+		{name: "same delivery id", deliveryId: "a", body: body, wantErr: ErrDeliveryReplayed},
+		{name: "same body with a new delivery id", deliveryId: "b", body: body, wantErr: ErrDeliveryReplayed},
+		{name: "new body", deliveryId: "c", body: []byte(`{"zen":"design for failure"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay := req
+			replay.DeliveryId = tt.deliveryId
+			replay.Body = tt.body
+			replay.Signature = "sha256=" + signBody(testWebhookSecret, tt.body)
+
+			_, err := service.ReceiveWebhook(context.Background(), 1, replay)
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), string(tt.wantErr))) {
+				t.Fatalf("expected %s, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("expected delivery to be received, got %v", err)
+			}
+		})
+	}
+}
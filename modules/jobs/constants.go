@@ -0,0 +1,33 @@
+package jobs
+
+import "errors"
+
+type ErrorMessage string
+
+const (
+	ErrJobNotFound ErrorMessage = "job not found"
+)
+
+// errLeaseLost - Job sudah di-claim ulang, handler di worker ini harus berhenti
+var errLeaseLost = errors.New("job lost its lease")
+
+// permanentError - Error yang tidak perlu di-retry
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent - Tandai error supaya job langsung failed tanpa retry
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
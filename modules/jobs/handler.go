@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wafi11/backend-workspaces/modules/auth"
+	"github.com/wafi11/backend-workspaces/pkg/response"
+)
+
+type Handler struct {
+	s Service
+}
+
+func NewHandler(s Service) Handler {
+	return Handler{s: s}
+}
+
+func (h Handler) FindById(c *fiber.Ctx) error {
+	userId, ok := auth.GetUserId(c)
+	if !ok {
+		return response.Error(c, http.StatusUnauthorized, "unauthorized access")
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.Error(c, http.StatusBadRequest, "id must be number")
+	}
+
+	data, err := h.s.FindById(c.Context(), id, userId)
+	if err != nil {
+		if strings.Contains(err.Error(), string(ErrJobNotFound)) {
+			return response.Error(c, http.StatusNotFound, err.Error())
+		}
+		return response.Error(c, http.StatusInternalServerError, "failed to retrieved job")
+	}
+
+	return response.Success(c, http.StatusOK, "successfully to retrieved job", data)
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+type JobRepository interface {
+	Enqueue(c context.Context, req EnqueueRequest) (*Job, error)
+	FailExpired(c context.Context) ([]Job, error)
+	Claim(c context.Context, lease time.Duration) (*Job, error)
+	Heartbeat(c context.Context, job *Job, lease time.Duration) error
+	UpdateProgress(c context.Context, id int, progress string) error
+	Complete(c context.Context, job *Job) error
+	Retry(c context.Context, job *Job, runAt time.Time, lastError string) error
+	Fail(c context.Context, job *Job, lastError string) error
+	FindById(c context.Context, id, userId int) (*Job, error)
+	ListByDeployment(c context.Context, deploymentId, userId int) ([]Job, error)
+	HasActive(c context.Context, deploymentId int, types []string) (bool, error)
+}
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+type Job struct {
+	Id           int             `json:"id"`
+	Type         string          `json:"type" db:"type"`
+	UserId       *int            `json:"userId,omitempty" db:"user_id"`
+	DeploymentId *int            `json:"deploymentId,omitempty" db:"deployment_id"`
+	Payload      json.RawMessage `json:"-" db:"payload"`
+	Status       string          `json:"status" db:"status"`
+	Progress     *string         `json:"progress,omitempty" db:"progress"`
+	Attempts     int             `json:"attempts" db:"attempts"`
+	MaxAttempts  int             `json:"maxAttempts" db:"max_attempts"`
+	LastError    *string         `json:"lastError,omitempty" db:"last_error"`
+	RunAt        time.Time       `json:"runAt" db:"run_at"`
+	StartedAt    *time.Time      `json:"startedAt,omitempty" db:"started_at"`
+	FinishedAt   *time.Time      `json:"finishedAt,omitempty" db:"finished_at"`
+	CreatedAt    time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+// IsLastAttempt - True kalau job tidak akan di-retry lagi setelah attempt ini
+func (j *Job) IsLastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// DecodePayload - Unmarshal payload ke struct milik handler
+func (j *Job) DecodePayload(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+type EnqueueRequest struct {
+	Type         string
+	UserId       int
+	DeploymentId int
+	Payload      interface{} // di-marshal ke JSON, jangan berisi data sensitif
+	MaxAttempts  int
+}
+
+// HandlerFunc - Eksekusi satu job. Return error untuk retry (sampai MaxAttempts).
+type HandlerFunc func(ctx context.Context, job *Job, report ProgressFunc) error
+
+// ExpiredFunc - Dipanggil sekali untuk job yang lease-nya habis di attempt terakhir (worker
+// mati di tengah jalan). Handler tidak sempat menulis hasil, jadi status resource yang
+// ditinggalkan job harus dibereskan di sini. Job.LastError berisi alasan gagal.
+type ExpiredFunc func(ctx context.Context, job *Job) error
+
+// ProgressFunc - Simpan progress job (e.g., "2/3 replicas ready")
+type ProgressFunc func(message string)
@@ -0,0 +1,102 @@
+package jobs
+
+var (
+	queryEnqueue = `
+		INSERT INTO jobs (type, user_id, deployment_id, payload, max_attempts)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5)
+		RETURNING id, status, attempts, run_at, created_at, updated_at
+	`
+
+	// Ambil satu job yang siap jalan. Job "running" yang lease-nya habis
+	// (worker mati di tengah jalan) ikut diambil lagi selama attempt masih tersisa.
+	queryClaim = `
+		UPDATE jobs SET
+			status = 'running',
+			attempts = attempts + 1,
+			locked_until = CURRENT_TIMESTAMP + ($1 * INTERVAL '1 second'),
+			started_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' AND run_at <= CURRENT_TIMESTAMP)
+				OR (status = 'running' AND locked_until < CURRENT_TIMESTAMP AND attempts < max_attempts)
+			ORDER BY run_at, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	// Job yang worker-nya mati di attempt terakhir tidak di-claim lagi, langsung failed.
+	// Job yang di-update dikembalikan supaya pemiliknya bisa membereskan status resource.
+	queryFailExpired = `
+		UPDATE jobs SET
+			status = 'failed',
+			last_error = 'lease expired on the last attempt',
+			locked_until = NULL,
+			finished_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'running' AND locked_until < CURRENT_TIMESTAMP AND attempts >= max_attempts
+		RETURNING ` + jobColumns + `
+	`
+
+	// Perpanjang lease selama handler masih jalan. attempts memastikan job belum
+	// di-claim ulang oleh worker lain.
+	queryHeartbeat = `
+		UPDATE jobs SET locked_until = CURRENT_TIMESTAMP + ($1 * INTERVAL '1 second')
+		WHERE id = $2 AND status = 'running' AND attempts = $3
+	`
+
+	jobColumns = `
+		id, type, user_id, deployment_id, payload, status, progress,
+		attempts, max_attempts, last_error, run_at, started_at, finished_at,
+		created_at, updated_at
+	`
+
+	queryUpdateProgress = `
+		UPDATE jobs SET progress = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	// Status akhir hanya ditulis oleh worker yang masih memegang lease (lihat queryHeartbeat)
+	queryComplete = `
+		UPDATE jobs SET
+			status = 'succeeded',
+			locked_until = NULL,
+			finished_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'running' AND attempts = $2
+	`
+
+	queryRetry = `
+		UPDATE jobs SET
+			status = 'queued',
+			run_at = $1,
+			last_error = $2,
+			locked_until = NULL,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'running' AND attempts = $4
+	`
+
+	queryFail = `
+		UPDATE jobs SET
+			status = 'failed',
+			last_error = $1,
+			locked_until = NULL,
+			finished_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = 'running' AND attempts = $3
+	`
+
+	queryGetByID = `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1 AND user_id = $2`
+
+	// Job deployment yang masih queued / running untuk tipe tertentu
+	queryHasActive = `SELECT EXISTS (
+		SELECT 1 FROM jobs
+		WHERE deployment_id = $1 AND type = ANY($2) AND status IN ('queued', 'running')
+	)`
+
+	queryListByDeployment = `SELECT ` + jobColumns + ` FROM jobs
+		WHERE deployment_id = $1 AND user_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 50`
+)
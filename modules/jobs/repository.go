@@ -0,0 +1,223 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	DB *sql.DB
+}
+
+func NewRepository(DB *sql.DB) JobRepository {
+	return &Repository{DB: DB}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *Repository) Enqueue(c context.Context, req EnqueueRequest) (*Job, error) {
+	payload, err := json.Marshal(req.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+
+	maxAttempts := req.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	job := Job{
+		Type:        req.Type,
+		Payload:     payload,
+		MaxAttempts: maxAttempts,
+	}
+	if req.UserId != 0 {
+		job.UserId = &req.UserId
+	}
+	if req.DeploymentId != 0 {
+		job.DeploymentId = &req.DeploymentId
+	}
+
+	err = r.DB.QueryRowContext(c, queryEnqueue, req.Type, req.UserId, req.DeploymentId, payload, maxAttempts).
+		Scan(&job.Id, &job.Status, &job.Attempts, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// FailExpired - Tandai failed job yang lease-nya habis di attempt terakhir. Setiap job hanya
+// dikembalikan sekali (UPDATE atomic), walaupun beberapa worker memanggil bersamaan.
+func (r *Repository) FailExpired(c context.Context) ([]Job, error) {
+	rows, err := r.DB.QueryContext(c, queryFailExpired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var results []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		results = append(results, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired jobs: %w", err)
+	}
+
+	return results, nil
+}
+
+// Claim - Ambil job berikutnya (SELECT ... FOR UPDATE SKIP LOCKED).
+// Return nil, nil kalau tidak ada job yang siap.
+func (r *Repository) Claim(c context.Context, lease time.Duration) (*Job, error) {
+	job, err := scanJob(r.DB.QueryRowContext(c, queryClaim, int(lease.Seconds())))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Heartbeat - Perpanjang lease job yang sedang jalan, error kalau job sudah diambil worker lain
+func (r *Repository) Heartbeat(c context.Context, job *Job, lease time.Duration) error {
+	result, err := r.DB.ExecContext(c, queryHeartbeat, int(lease.Seconds()), job.Id, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to renew job lease: %w", err)
+	}
+	return checkLease(result, job)
+}
+
+func (r *Repository) UpdateProgress(c context.Context, id int, progress string) error {
+	if _, err := r.DB.ExecContext(c, queryUpdateProgress, progress, id); err != nil {
+		return fmt.Errorf("failed to update job progress: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) Complete(c context.Context, job *Job) error {
+	result, err := r.DB.ExecContext(c, queryComplete, job.Id, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return checkLease(result, job)
+}
+
+func (r *Repository) Retry(c context.Context, job *Job, runAt time.Time, lastError string) error {
+	result, err := r.DB.ExecContext(c, queryRetry, runAt, lastError, job.Id, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule job: %w", err)
+	}
+	return checkLease(result, job)
+}
+
+func (r *Repository) Fail(c context.Context, job *Job, lastError string) error {
+	result, err := r.DB.ExecContext(c, queryFail, lastError, job.Id, job.Attempts)
+	if err != nil {
+		return fmt.Errorf("failed to mark job failed: %w", err)
+	}
+	return checkLease(result, job)
+}
+
+// checkLease - Tidak ada row ter-update berarti job sudah di-claim ulang worker lain
+func checkLease(result sql.Result, job *Job) error {
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("%w: job %d attempt %d", errLeaseLost, job.Id, job.Attempts)
+	}
+	return nil
+}
+
+func (r *Repository) FindById(c context.Context, id, userId int) (*Job, error) {
+	job, err := scanJob(r.DB.QueryRowContext(c, queryGetByID, id, userId))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: id %d", ErrJobNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *Repository) ListByDeployment(c context.Context, deploymentId, userId int) ([]Job, error) {
+	rows, err := r.DB.QueryContext(c, queryListByDeployment, deploymentId, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	results := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		results = append(results, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	return results, nil
+}
+
+func (r *Repository) HasActive(c context.Context, deploymentId int, types []string) (bool, error) {
+	var active bool
+	if err := r.DB.QueryRowContext(c, queryHasActive, deploymentId, pq.Array(types)).Scan(&active); err != nil {
+		return false, fmt.Errorf("failed to check active jobs: %w", err)
+	}
+	return active, nil
+}
+
+func scanJob(row rowScanner) (*Job, error) {
+	var job Job
+	var userId, deploymentId sql.NullInt64
+
+	err := row.Scan(
+		&job.Id,
+		&job.Type,
+		&userId,
+		&deploymentId,
+		&job.Payload,
+		&job.Status,
+		&job.Progress,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.RunAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userId.Valid {
+		id := int(userId.Int64)
+		job.UserId = &id
+	}
+	if deploymentId.Valid {
+		id := int(deploymentId.Int64)
+		job.DeploymentId = &id
+	}
+
+	return &job, nil
+}
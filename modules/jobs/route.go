@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/wafi11/backend-workspaces/modules/auth"
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+func NewRoute(db *sql.DB, cfg config.Config, app fiber.Router) {
+	repo := NewRepository(db)
+	service := NewService(repo)
+	handler := NewHandler(service)
+
+	api := app.Group("/jobs", auth.Protected(cfg))
+	api.Get("/:id", handler.FindById)
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+)
+
+type Service struct {
+	repo JobRepository
+}
+
+func NewService(repo JobRepository) Service {
+	return Service{repo: repo}
+}
+
+func (s Service) Enqueue(c context.Context, req EnqueueRequest) (*Job, error) {
+	return s.repo.Enqueue(c, req)
+}
+
+func (s Service) FindById(c context.Context, id, userId int) (*Job, error) {
+	return s.repo.FindById(c, id, userId)
+}
+
+func (s Service) ListByDeployment(c context.Context, deploymentId, userId int) ([]Job, error) {
+	return s.repo.ListByDeployment(c, deploymentId, userId)
+}
+
+// HasActive - True kalau deployment masih punya job queued / running dengan salah satu tipe ini
+func (s Service) HasActive(c context.Context, deploymentId int, types ...string) (bool, error) {
+	return s.repo.HasActive(c, deploymentId, types)
+}
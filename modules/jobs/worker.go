@@ -0,0 +1,264 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+const (
+	defaultMaxAttempts  = 3
+	defaultWorkers      = 2
+	defaultPollInterval = 2 * time.Second
+	defaultLease        = 10 * time.Minute
+	baseBackoff         = 5 * time.Second
+	maxBackoff          = 5 * time.Minute
+
+	// heartbeatDivisor - Lease diperpanjang setiap lease/4
+	heartbeatDivisor = 4
+)
+
+// WorkerPool - Jalankan job dari tabel jobs dengan N worker
+type WorkerPool struct {
+	repo         JobRepository
+	handlers     map[string]HandlerFunc
+	expired      map[string]ExpiredFunc
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	periodic     []periodicTask
+	wg           sync.WaitGroup
+}
+
+// periodicTask - Task yang dijalankan setiap interval (e.g., idle detector)
+type periodicTask struct {
+	name     string
+	interval time.Duration
+	fn       func(ctx context.Context) error
+}
+
+func NewWorkerPool(repo JobRepository, cfg config.JobsConfig) *WorkerPool {
+	pool := &WorkerPool{
+		repo:         repo,
+		handlers:     map[string]HandlerFunc{},
+		expired:      map[string]ExpiredFunc{},
+		workers:      cfg.Workers,
+		pollInterval: time.Duration(cfg.PollIntervalSeconds) * time.Second,
+		lease:        time.Duration(cfg.LeaseSeconds) * time.Second,
+	}
+
+	if pool.workers <= 0 {
+		pool.workers = defaultWorkers
+	}
+	if pool.pollInterval <= 0 {
+		pool.pollInterval = defaultPollInterval
+	}
+	if pool.lease <= 0 {
+		pool.lease = defaultLease
+	}
+
+	return pool
+}
+
+// Register - Daftarkan handler untuk satu job type. Harus dipanggil sebelum Start.
+func (p *WorkerPool) Register(jobType string, handler HandlerFunc) {
+	p.handlers[jobType] = handler
+}
+
+// OnExpired - Daftarkan hook untuk job type yang lease-nya habis di attempt terakhir.
+// Harus dipanggil sebelum Start.
+func (p *WorkerPool) OnExpired(jobType string, fn ExpiredFunc) {
+	p.expired[jobType] = fn
+}
+
+// Every - Daftarkan task periodik, dijalankan bersama worker. Harus dipanggil sebelum Start.
+func (p *WorkerPool) Every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	p.periodic = append(p.periodic, periodicTask{name: name, interval: interval, fn: fn})
+}
+
+// Start - Jalankan worker di background sampai ctx di-cancel
+func (p *WorkerPool) Start(ctx context.Context) {
+	log.Printf("🔧 Starting %d job workers", p.workers)
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.run(ctx)
+		}()
+	}
+
+	for _, task := range p.periodic {
+		p.wg.Add(1)
+		go func(task periodicTask) {
+			defer p.wg.Done()
+			p.runPeriodic(ctx, task)
+		}(task)
+	}
+}
+
+// Wait - Tunggu semua worker selesai (setelah ctx di-cancel)
+func (p *WorkerPool) Wait() {
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) run(ctx context.Context) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.failExpired(ctx)
+
+		job, err := p.repo.Claim(ctx, p.lease)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("failed to claim job: %s", err.Error())
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.pollInterval):
+			}
+			continue
+		}
+
+		p.execute(ctx, job)
+	}
+}
+
+// failExpired - Job yang worker-nya mati di attempt terakhir di-fail, lalu hook job type-nya
+// dijalankan. Error hook hanya di-log: job sudah failed dan tidak dikembalikan lagi.
+func (p *WorkerPool) failExpired(ctx context.Context) {
+	expired, err := p.repo.FailExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("failed to expire jobs: %s", err.Error())
+		}
+		return
+	}
+
+	statusCtx := context.WithoutCancel(ctx)
+	for i := range expired {
+		job := &expired[i]
+		log.Printf("job %d (%s) lease expired on the last attempt", job.Id, job.Type)
+
+		fn, ok := p.expired[job.Type]
+		if !ok {
+			continue
+		}
+		if err := fn(statusCtx, job); err != nil {
+			log.Printf("failed to clean up expired job %d: %s", job.Id, err.Error())
+		}
+	}
+}
+
+func (p *WorkerPool) runPeriodic(ctx context.Context, task periodicTask) {
+	ticker := time.NewTicker(task.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task.fn(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("periodic task %s failed: %s", task.name, err.Error())
+			}
+		}
+	}
+}
+
+func (p *WorkerPool) execute(ctx context.Context, job *Job) {
+	// Update status tetap jalan walaupun worker sedang shutdown
+	statusCtx := context.WithoutCancel(ctx)
+
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		p.fail(statusCtx, job, fmt.Sprintf("no handler registered for job type %s", job.Type))
+		return
+	}
+
+	report := func(message string) {
+		if err := p.repo.UpdateProgress(statusCtx, job.Id, message); err != nil {
+			log.Printf("failed to update job %d progress: %s", job.Id, err.Error())
+		}
+	}
+
+	// Lease diperpanjang selama handler jalan, jadi job tidak di-claim ulang worker lain.
+	// Timeout handler tetap p.lease per attempt.
+	leaseCtx, cancelLease := context.WithCancelCause(ctx)
+	jobCtx, cancel := context.WithTimeout(leaseCtx, p.lease)
+	go p.heartbeat(jobCtx, cancelLease, job)
+	err := handler(jobCtx, job, report)
+	cancel()
+	leaseLost := errors.Is(context.Cause(leaseCtx), errLeaseLost) || errors.Is(err, errLeaseLost)
+	cancelLease(nil)
+
+	// Job sudah milik worker lain, status akhir ditulis oleh worker itu
+	if leaseLost {
+		log.Printf("job %d (%s) attempt %d lost its lease, result discarded", job.Id, job.Type, job.Attempts)
+		return
+	}
+
+	if err == nil {
+		if err := p.repo.Complete(statusCtx, job); err != nil {
+			log.Printf("failed to complete job %d: %s", job.Id, err.Error())
+		}
+		return
+	}
+
+	log.Printf("job %d (%s) attempt %d/%d failed: %s", job.Id, job.Type, job.Attempts, job.MaxAttempts, err.Error())
+
+	if job.Attempts >= job.MaxAttempts || isPermanent(err) {
+		p.fail(statusCtx, job, err.Error())
+		return
+	}
+
+	runAt := time.Now().Add(backoff(job.Attempts))
+	if err := p.repo.Retry(statusCtx, job, runAt, err.Error()); err != nil {
+		log.Printf("failed to reschedule job %d: %s", job.Id, err.Error())
+	}
+}
+
+// heartbeat - Handler dibatalkan kalau lease hilang (job sudah dijalankan worker lain)
+func (p *WorkerPool) heartbeat(ctx context.Context, cancel context.CancelCauseFunc, job *Job) {
+	ticker := time.NewTicker(p.lease / heartbeatDivisor)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.repo.Heartbeat(context.WithoutCancel(ctx), job, p.lease); err != nil {
+				log.Printf("failed to renew lease of job %d: %s", job.Id, err.Error())
+				if errors.Is(err, errLeaseLost) {
+					cancel(errLeaseLost)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *WorkerPool) fail(ctx context.Context, job *Job, message string) {
+	if err := p.repo.Fail(ctx, job, message); err != nil {
+		log.Printf("failed to mark job %d failed: %s", job.Id, err.Error())
+	}
+}
+
+func backoff(attempt int) time.Duration {
+	delay := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempt-1)))
+	if delay > maxBackoff || delay <= 0 {
+		return maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wafi11/backend-workspaces/pkg/config"
+)
+
+// fakeRepo - Queue di memory, hanya mencatat status akhir yang ditulis worker
+type fakeRepo struct {
+	JobRepository
+
+	mu        sync.Mutex
+	queue     []*Job
+	expired   []Job
+	leaseLost bool
+	calls     []string
+}
+
+func (r *fakeRepo) FailExpired(c context.Context) ([]Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expired := r.expired
+	r.expired = nil
+	return expired, nil
+}
+
+func (r *fakeRepo) Claim(c context.Context, lease time.Duration) (*Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return nil, nil
+	}
+	job := r.queue[0]
+	r.queue = r.queue[1:]
+	job.Status = StatusRunning
+	job.Attempts++
+	return job, nil
+}
+
+func (r *fakeRepo) Heartbeat(c context.Context, job *Job, lease time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.leaseLost {
+		return fmt.Errorf("%w: job %d attempt %d", errLeaseLost, job.Id, job.Attempts)
+	}
+	return nil
+}
+
+func (r *fakeRepo) UpdateProgress(c context.Context, id int, progress string) error {
+	return nil
+}
+
+func (r *fakeRepo) Complete(c context.Context, job *Job) error {
+	return r.record("complete")
+}
+
+func (r *fakeRepo) Retry(c context.Context, job *Job, runAt time.Time, lastError string) error {
+	return r.record("retry")
+}
+
+func (r *fakeRepo) Fail(c context.Context, job *Job, lastError string) error {
+	return r.record("fail")
+}
+
+func (r *fakeRepo) record(call string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *fakeRepo) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.calls...)
+}
+
+func TestWorkerExecute(t *testing.T) {
+	errTransient := errors.New("cluster unavailable")
+
+	tests := []struct {
+		name      string
+		attempts  int
+		leaseLost bool
+		handler   HandlerFunc
+		want      []string
+	}{
+		{
+			name:    "succeeded",
+			handler: func(ctx context.Context, job *Job, report ProgressFunc) error { return nil },
+			want:    []string{"complete"},
+		},
+		{
+			name:    "transient error is retried",
+			handler: func(ctx context.Context, job *Job, report ProgressFunc) error { return errTransient },
+			want:    []string{"retry"},
+		},
+		{
+			name:     "transient error on the last attempt fails",
+			attempts: 2,
+			handler:  func(ctx context.Context, job *Job, report ProgressFunc) error { return errTransient },
+			want:     []string{"fail"},
+		},
+		{
+			name:    "permanent error fails",
+			handler: func(ctx context.Context, job *Job, report ProgressFunc) error { return Permanent(errTransient) },
+			want:    []string{"fail"},
+		},
+		{
+			name:      "lost lease skips the final status",
+			leaseLost: true,
+			handler: func(ctx context.Context, job *Job, report ProgressFunc) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			want: nil,
+		},
+		{
+			name:    "handler returning errLeaseLost skips the final status",
+			handler: func(ctx context.Context, job *Job, report ProgressFunc) error { return errLeaseLost },
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepo{leaseLost: tt.leaseLost}
+			repo.queue = []*Job{{Id: 1, Type: "test.job", Status: StatusQueued, Attempts: tt.attempts, MaxAttempts: defaultMaxAttempts}}
+
+			// Lease pendek supaya heartbeat jalan, handler lease-lost tetap selesai lewat timeout
+			pool := NewWorkerPool(repo, config.JobsConfig{Workers: 1})
+			pool.lease = 200 * time.Millisecond
+			pool.Register("test.job", tt.handler)
+
+			job, err := repo.Claim(context.Background(), pool.lease)
+			if err != nil || job == nil {
+				t.Fatalf("expected a claimed job, got %v, %v", job, err)
+			}
+			pool.execute(context.Background(), job)
+
+			got := repo.recorded()
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("expected status writes %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorkerUnknownJobType(t *testing.T) {
+	repo := &fakeRepo{queue: []*Job{{Id: 1, Type: "test.unknown", MaxAttempts: defaultMaxAttempts}}}
+	pool := NewWorkerPool(repo, config.JobsConfig{Workers: 1, PollIntervalSeconds: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	defer func() {
+		cancel()
+		pool.Wait()
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(repo.recorded()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := repo.recorded(); fmt.Sprint(got) != "[fail]" {
+		t.Fatalf("expected job without handler to fail, got %v", got)
+	}
+}
+
+func TestWorkerFailExpired(t *testing.T) {
+	lastError := "lease expired on the last attempt"
+	repo := &fakeRepo{expired: []Job{
+		{Id: 1, Type: "test.job", Status: StatusFailed, LastError: &lastError},
+		{Id: 2, Type: "test.other", Status: StatusFailed},
+	}}
+	pool := NewWorkerPool(repo, config.JobsConfig{Workers: 1})
+
+	var cleaned []int
+	pool.OnExpired("test.job", func(ctx context.Context, job *Job) error {
+		cleaned = append(cleaned, job.Id)
+		return nil
+	})
+
+	// Job hanya dikembalikan FailExpired sekali, hook tidak jalan dua kali
+	pool.failExpired(context.Background())
+	pool.failExpired(context.Background())
+	if fmt.Sprint(cleaned) != "[1]" {
+		t.Fatalf("expected only job 1 to be cleaned up once, got %v", cleaned)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 5 * time.Second},
+		{attempt: 2, want: 10 * time.Second},
+		{attempt: 3, want: 20 * time.Second},
+		{attempt: 10, want: maxBackoff},
+		{attempt: 100, want: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package k8s
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const certManagerGroupVersion = "cert-manager.io/v1"
+
+// TLSCertificate - Isi secret TLS (kubernetes.io/tls) yang dipakai Ingress
+type TLSCertificate struct {
+	SecretName string     `json:"secretName"`
+	Present    bool       `json:"present"`
+	Issuer     string     `json:"issuer,omitempty"`
+	Subject    string     `json:"subject,omitempty"`
+	NotBefore  *time.Time `json:"notBefore,omitempty"`
+	NotAfter   *time.Time `json:"notAfter,omitempty"`
+	DNSNames   []string   `json:"dnsNames,omitempty"`
+	Error      string     `json:"error,omitempty"` // tls.crt tidak bisa di-parse
+
+	// Dari annotation cert-manager di secret
+	IssuerName string `json:"issuerName,omitempty"`
+	IssuerKind string `json:"issuerKind,omitempty"`
+}
+
+// CertificateCondition - Condition Certificate cert-manager (e.g., Ready, Issuing)
+type CertificateCondition struct {
+	Type               string     `json:"type"`
+	Status             string     `json:"status"`
+	Reason             string     `json:"reason,omitempty"`
+	Message            string     `json:"message,omitempty"`
+	LastTransitionTime *time.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// CertManagerCertificate - Status Certificate cert-manager (dibuat ingress-shim dengan nama secret)
+type CertManagerCertificate struct {
+	Name        string                 `json:"name"`
+	Ready       bool                   `json:"ready"`
+	NotAfter    *time.Time             `json:"notAfter,omitempty"`
+	RenewalTime *time.Time             `json:"renewalTime,omitempty"`
+	Conditions  []CertificateCondition `json:"conditions"`
+}
+
+// GetTLSCertificate - Parse tls.crt dari secret, Present false kalau secret belum ada
+// (cert-manager belum selesai issue / TLS tidak aktif)
+func (k *K8sClient) GetTLSCertificate(ctx context.Context, namespace, secretName string) (*TLSCertificate, error) {
+	result := &TLSCertificate{SecretName: secretName}
+
+	secret, err := k.clientset.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+	result.Present = true
+	result.IssuerName = secret.Annotations["cert-manager.io/issuer-name"]
+	result.IssuerKind = secret.Annotations["cert-manager.io/issuer-kind"]
+
+	cert, err := parseLeafCertificate(secret.Data["tls.crt"])
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	notBefore, notAfter := cert.NotBefore, cert.NotAfter
+	result.Issuer = cert.Issuer.String()
+	result.Subject = cert.Subject.String()
+	result.NotBefore = &notBefore
+	result.NotAfter = &notAfter
+	result.DNSNames = cert.DNSNames
+
+	return result, nil
+}
+
+// parseLeafCertificate - Certificate pertama di chain PEM
+func parseLeafCertificate(data []byte) (*x509.Certificate, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("secret has no tls.crt")
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("tls.crt contains no PEM certificate")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tls.crt: %w", err)
+		}
+		return cert, nil
+	}
+}
+
+// CertManagerInstalled - true kalau CRD cert-manager.io/v1 terdaftar di cluster
+func (k *K8sClient) CertManagerInstalled(ctx context.Context) (bool, error) {
+	_, err := k.clientset.Discovery().ServerResourcesForGroupVersion(certManagerGroupVersion)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover %s: %w", certManagerGroupVersion, err)
+	}
+	return true, nil
+}
+
+// GetCertManagerCertificate - Get Certificate lewat REST (tanpa typed client cert-manager),
+// nil kalau Certificate tidak ada
+func (k *K8sClient) GetCertManagerCertificate(ctx context.Context, namespace, name string) (*CertManagerCertificate, error) {
+	restClient := k.clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, nil
+	}
+
+	raw, err := restClient.Get().
+		AbsPath("/apis", certManagerGroupVersion, "namespaces", namespace, "certificates", name).
+		DoRaw(ctx)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get certificate: %w", err)
+	}
+
+	var certificate struct {
+		Status struct {
+			NotAfter    *metav1.Time `json:"notAfter"`
+			RenewalTime *metav1.Time `json:"renewalTime"`
+			Conditions  []struct {
+				Type               string       `json:"type"`
+				Status             string       `json:"status"`
+				Reason             string       `json:"reason"`
+				Message            string       `json:"message"`
+				LastTransitionTime *metav1.Time `json:"lastTransitionTime"`
+			} `json:"conditions"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(raw, &certificate); err != nil {
+		return nil, fmt.Errorf("failed to decode certificate: %w", err)
+	}
+
+	result := &CertManagerCertificate{
+		Name:        name,
+		NotAfter:    metaTime(certificate.Status.NotAfter),
+		RenewalTime: metaTime(certificate.Status.RenewalTime),
+		Conditions:  []CertificateCondition{},
+	}
+	for _, condition := range certificate.Status.Conditions {
+		if condition.Type == "Ready" && condition.Status == "True" {
+			result.Ready = true
+		}
+		result.Conditions = append(result.Conditions, CertificateCondition{
+			Type:               condition.Type,
+			Status:             condition.Status,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: metaTime(condition.LastTransitionTime),
+		})
+	}
+
+	return result, nil
+}
+
+func metaTime(t *metav1.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := t.Time
+	return &value
+}
@@ -10,7 +10,7 @@ import (
 )
 
 type K8sClient struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 }
 
 // NewK8sClient - Create new K8s client wrapper
@@ -25,8 +25,15 @@ func NewK8sClient() (*K8sClient, error) {
 	}, nil
 }
 
+// NewK8sClientFromClientset - Wrap clientset yang sudah ada (misal fake.NewSimpleClientset() untuk testing)
+func NewK8sClientFromClientset(clientset kubernetes.Interface) *K8sClient {
+	return &K8sClient{
+		clientset: clientset,
+	}
+}
+
 // GetClientset - Get underlying clientset
-func (k *K8sClient) GetClientset() *kubernetes.Clientset {
+func (k *K8sClient) GetClientset() kubernetes.Interface {
 	return k.clientset
 }
 
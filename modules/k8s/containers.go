@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ContainerConfig - Container tambahan di pod app (sidecar atau init container)
+type ContainerConfig struct {
+	Name    string
+	Image   string
+	Command []string
+	Args    []string
+	EnvVars []corev1.EnvVar
+
+	// EnvFrom ConfigMap & Secret yang sama dengan container app
+	// (e.g., init container migration yang butuh DATABASE_URL)
+	InheritEnv bool
+
+	// Kosong = default LimitRange namespace
+	CPURequest    string
+	CPULimit      string
+	MemoryRequest string
+	MemoryLimit   string
+
+	// Volume dari DeploymentConfig.Volumes yang ikut di-mount
+	VolumeMounts []VolumeMountConfig
+}
+
+// VolumeMountConfig - Mount volume pod (berdasarkan VolumeConfig.Name) ke container tambahan
+type VolumeMountConfig struct {
+	Volume    string
+	MountPath string
+	ReadOnly  bool
+}
+
+// addContainers - Tambahkan sidecar & init container ke pod spec. Dipanggil setelah
+// container app lengkap supaya EnvFrom-nya bisa diwariskan.
+func addContainers(spec *corev1.PodSpec, config *DeploymentConfig) error {
+	names := map[string]bool{config.AppName: true}
+	volumes := map[string]bool{}
+	for _, volume := range config.Volumes {
+		volumes[volume.Name] = true
+	}
+	envFrom := spec.Containers[0].EnvFrom
+
+	groups := []struct {
+		kind       string
+		containers []ContainerConfig
+		target     *[]corev1.Container
+	}{
+		{"init container", config.InitContainers, &spec.InitContainers},
+		{"sidecar", config.Sidecars, &spec.Containers},
+	}
+	for _, group := range groups {
+		for _, item := range group.containers {
+			if item.Name == "" || item.Image == "" {
+				return fmt.Errorf("%s requires a name and image", group.kind)
+			}
+			if names[item.Name] {
+				return fmt.Errorf("duplicate container name %s", item.Name)
+			}
+			names[item.Name] = true
+
+			container, err := buildContainer(item, envFrom, volumes)
+			if err != nil {
+				return fmt.Errorf("%s %s: %w", group.kind, item.Name, err)
+			}
+			*group.target = append(*group.target, *container)
+		}
+	}
+
+	return nil
+}
+
+func buildContainer(config ContainerConfig, envFrom []corev1.EnvFromSource, volumes map[string]bool) (*corev1.Container, error) {
+	container := &corev1.Container{
+		Name:    config.Name,
+		Image:   config.Image,
+		Command: config.Command,
+		Args:    config.Args,
+		Env:     config.EnvVars,
+	}
+	if config.InheritEnv {
+		container.EnvFrom = append([]corev1.EnvFromSource{}, envFrom...)
+	}
+
+	resources, err := parseResourceRequirements(config.CPURequest, config.MemoryRequest, config.CPULimit, config.MemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	container.Resources = resources
+
+	for _, mount := range config.VolumeMounts {
+		if !volumes[mount.Volume] {
+			return nil, fmt.Errorf("volume %s is not defined", mount.Volume)
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      mount.Volume,
+			MountPath: mount.MountPath,
+			ReadOnly:  mount.ReadOnly,
+		})
+	}
+
+	return container, nil
+}
@@ -3,11 +3,11 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -19,6 +19,7 @@ type DeploymentConfig struct {
 	Image         string // Docker image
 	Replicas      int32  // Jumlah pods
 	ContainerPort int32  // Port aplikasi di container
+	Args          []string
 
 	// Resource limits
 	CPURequest    string // e.g., "100m", "500m"
@@ -32,18 +33,103 @@ type DeploymentConfig struct {
 	// Environment variables from Secret
 	SecretName string
 
+	// Secret tambahan untuk EnvFrom (e.g., credentials database add-on)
+	EnvFromSecrets []string
+
 	// Custom env vars (optional)
 	EnvVars []corev1.EnvVar
+
+	// Annotations di pod template (berubah → rolling update)
+	PodAnnotations map[string]string
+
+	// Autoscaling (optional). Kalau di-set, HPA yang mengatur replicas
+	Autoscaling *AutoscalingConfig
+
+	// Health checks (optional)
+	LivenessProbe  *ProbeConfig
+	ReadinessProbe *ProbeConfig
+	StartupProbe   *ProbeConfig
+
+	// PVC yang di-mount (PVC harus sudah dibuat)
+	Volumes []VolumeConfig
+
+	// Container tambahan di pod. Init container jalan berurutan sebelum app start.
+	Sidecars       []ContainerConfig
+	InitContainers []ContainerConfig
+
+	// Secret dockerconfigjson untuk pull image dari registry private (harus ada di namespace)
+	ImagePullSecrets []string
 }
 
 // CreateDeployment - Create Deployment di K8s
 func (k *K8sClient) CreateDeployment(ctx context.Context, config *DeploymentConfig) error {
+	deployment, err := buildDeployment(config)
+	if err != nil {
+		return err
+	}
+
+	// Create deployment
+	_, err = k.clientset.AppsV1().Deployments(config.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("deployment %s already exists in namespace %s", config.Name, config.Namespace)
+		}
+		return fmt.Errorf("failed to create deployment: %w", err)
+	}
+
+	if config.Autoscaling != nil {
+		if err := k.CreateHPA(ctx, config); err != nil {
+			// Deployment tanpa HPA tidak sesuai config, hapus supaya create bisa di-retry
+			if deleteErr := k.DeleteDeployment(context.WithoutCancel(ctx), config.Namespace, config.Name); deleteErr != nil {
+				return fmt.Errorf("%w (cleanup: %s)", err, deleteErr.Error())
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ApplyDeploymentConfig - Update Deployment yang sudah ada supaya sesuai dengan config
+// (image, replicas, resources, env). Selector tidak diubah karena immutable.
+func (k *K8sClient) ApplyDeploymentConfig(ctx context.Context, config *DeploymentConfig) error {
+	desired, err := buildDeployment(config)
+	if err != nil {
+		return err
+	}
+
+	deployment, err := k.GetDeployment(ctx, config.Namespace, config.Name)
+	if err != nil {
+		return err
+	}
+
+	deployment.Labels = desired.Labels
+	deployment.Spec.Template = desired.Spec.Template
+	deployment.Spec.Strategy = desired.Spec.Strategy
+	// Replicas dikelola HPA, jangan di-reset ke minReplicas setiap update
+	// (kecuali sedang 0, HPA tidak akan scale up dari 0)
+	if config.Autoscaling == nil || deployment.Spec.Replicas == nil || *deployment.Spec.Replicas == 0 {
+		deployment.Spec.Replicas = desired.Spec.Replicas
+	}
+
+	if err := k.UpdateDeployment(ctx, deployment); err != nil {
+		return err
+	}
+
+	return k.ApplyHPA(ctx, config)
+}
+
+// buildDeployment - Build Deployment object dari config (dipakai create & apply)
+func buildDeployment(config *DeploymentConfig) (*appsv1.Deployment, error) {
 	// Validate config
 	if config.Name == "" || config.Namespace == "" || config.Image == "" {
-		return fmt.Errorf("name, namespace, and image are required")
+		return nil, fmt.Errorf("name, namespace, and image are required")
 	}
 
 	// Default values
+	if config.Autoscaling != nil {
+		config.Replicas = config.Autoscaling.MinReplicas
+	}
 	if config.Replicas == 0 {
 		config.Replicas = 1
 	}
@@ -63,6 +149,11 @@ func (k *K8sClient) CreateDeployment(ctx context.Context, config *DeploymentConf
 		config.MemoryLimit = "512Mi"
 	}
 
+	resources, err := parseResourceRequirements(config.CPURequest, config.MemoryRequest, config.CPULimit, config.MemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build deployment spec
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -84,12 +175,14 @@ func (k *K8sClient) CreateDeployment(ctx context.Context, config *DeploymentConf
 					Labels: map[string]string{
 						"app": config.AppName,
 					},
+					Annotations: config.PodAnnotations,
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
 						{
 							Name:  config.AppName,
 							Image: config.Image,
+							Args:  config.Args,
 							Ports: []corev1.ContainerPort{
 								{
 									Name:          "http",
@@ -97,16 +190,7 @@ func (k *K8sClient) CreateDeployment(ctx context.Context, config *DeploymentConf
 									Protocol:      corev1.ProtocolTCP,
 								},
 							},
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse(config.CPURequest),
-									corev1.ResourceMemory: resource.MustParse(config.MemoryRequest),
-								},
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse(config.CPULimit),
-									corev1.ResourceMemory: resource.MustParse(config.MemoryLimit),
-								},
-							},
+							Resources:       resources,
 							ImagePullPolicy: corev1.PullAlways,
 						},
 					},
@@ -116,6 +200,11 @@ func (k *K8sClient) CreateDeployment(ctx context.Context, config *DeploymentConf
 		},
 	}
 
+	for _, name := range config.ImagePullSecrets {
+		deployment.Spec.Template.Spec.ImagePullSecrets = append(deployment.Spec.Template.Spec.ImagePullSecrets,
+			corev1.LocalObjectReference{Name: name})
+	}
+
 	// Add EnvFrom (ConfigMap & Secret)
 	if config.ConfigMapName != "" || config.SecretName != "" {
 		envFrom := []corev1.EnvFromSource{}
@@ -143,21 +232,53 @@ func (k *K8sClient) CreateDeployment(ctx context.Context, config *DeploymentConf
 		deployment.Spec.Template.Spec.Containers[0].EnvFrom = envFrom
 	}
 
+	for _, secretName := range config.EnvFromSecrets {
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+			},
+		})
+	}
+
 	// Add custom env vars
 	if len(config.EnvVars) > 0 {
 		deployment.Spec.Template.Spec.Containers[0].Env = config.EnvVars
 	}
 
-	// Create deployment
-	_, err := k.clientset.AppsV1().Deployments(config.Namespace).Create(ctx, deployment, metav1.CreateOptions{})
-	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			return fmt.Errorf("deployment %s already exists in namespace %s", config.Name, config.Namespace)
+	// Add volumes
+	if len(config.Volumes) > 0 && addVolumes(&deployment.Spec.Template.Spec, config.Volumes) {
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
 		}
-		return fmt.Errorf("failed to create deployment: %w", err)
 	}
 
-	return nil
+	// Add sidecars & init containers (setelah EnvFrom app lengkap)
+	if err := addContainers(&deployment.Spec.Template.Spec, config); err != nil {
+		return nil, err
+	}
+
+	// Add probes
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	probes := []struct {
+		target **corev1.Probe
+		config *ProbeConfig
+	}{
+		{&container.LivenessProbe, config.LivenessProbe},
+		{&container.ReadinessProbe, config.ReadinessProbe},
+		{&container.StartupProbe, config.StartupProbe},
+	}
+	for _, probe := range probes {
+		built, err := buildProbe(probe.config, config.ContainerPort)
+		if err != nil {
+			return nil, err
+		}
+		*probe.target = built
+	}
+
+	return deployment, nil
 }
 
 // GetDeployment - Get Deployment by name
@@ -196,6 +317,22 @@ func (k *K8sClient) UpdateDeploymentImage(ctx context.Context, namespace, name,
 	return k.UpdateDeployment(ctx, deployment)
 }
 
+// RestartDeployment - Rolling restart pods (sama seperti `kubectl rollout restart`),
+// e.g., supaya pod membaca ulang Secret yang dipakai di EnvFrom
+func (k *K8sClient) RestartDeployment(ctx context.Context, namespace, name string) error {
+	deployment, err := k.GetDeployment(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+
+	return k.UpdateDeployment(ctx, deployment)
+}
+
 // ScaleDeployment - Scale replicas
 func (k *K8sClient) ScaleDeployment(ctx context.Context, namespace, name string, replicas int32) error {
 	deployment, err := k.GetDeployment(ctx, namespace, name)
@@ -209,9 +346,14 @@ func (k *K8sClient) ScaleDeployment(ctx context.Context, namespace, name string,
 	return k.UpdateDeployment(ctx, deployment)
 }
 
-// DeleteDeployment - Delete Deployment
+// DeleteDeployment - Delete Deployment beserta HPA-nya (kalau ada)
 func (k *K8sClient) DeleteDeployment(ctx context.Context, namespace, name string) error {
-	err := k.clientset.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	err := k.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete hpa: %w", err)
+	}
+
+	err = k.clientset.AppsV1().Deployments(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return fmt.Errorf("deployment %s not found in namespace %s", name, namespace)
@@ -222,35 +364,40 @@ func (k *K8sClient) DeleteDeployment(ctx context.Context, namespace, name string
 	return nil
 }
 
-// GetDeploymentStatus - Get status deployment (ready replicas, etc)
+// GetDeploymentStatus - Get status deployment (ready replicas, etc).
+// Deployment yang tidak ada bisa dicek dengan IsNotFound.
 func (k *K8sClient) GetDeploymentStatus(ctx context.Context, namespace, name string) (*DeploymentStatus, error) {
-	deployment, err := k.GetDeployment(ctx, namespace, name)
+	deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return nil, err
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("deployment %s not found in namespace %s: %w", name, namespace, err)
+		}
+		return nil, fmt.Errorf("failed to get deployment: %w", err)
+	}
+
+	// spec.replicas kosong = default Kubernetes 1 replica
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
 	}
 
 	status := &DeploymentStatus{
 		Name:              deployment.Name,
 		Namespace:         deployment.Namespace,
+		DesiredReplicas:   desired,
 		Replicas:          deployment.Status.Replicas,
 		ReadyReplicas:     deployment.Status.ReadyReplicas,
 		AvailableReplicas: deployment.Status.AvailableReplicas,
 		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
-		IsReady:           deployment.Status.ReadyReplicas == *deployment.Spec.Replicas,
+		IsReady:           deployment.Status.ReadyReplicas == desired,
 	}
 
 	return status, nil
 }
 
-// WaitForDeploymentReady - Wait until deployment is ready
-func (k *K8sClient) WaitForDeploymentReady(ctx context.Context, namespace, name string, timeoutSeconds int) error {
-	// Implementation using watch API
-	// Simplified version: poll setiap 2 detik
-
-	// Note: Untuk production, pakai watch API
-	// Ini contoh simplified dengan polling
-
-	return fmt.Errorf("not implemented yet - use kubectl wait or watch API")
+// IsNotFound - True kalau resource yang diminta tidak ada di cluster
+func IsNotFound(err error) bool {
+	return errors.IsNotFound(err)
 }
 
 // ListDeployments - List all deployments in namespace
@@ -267,7 +414,8 @@ func (k *K8sClient) ListDeployments(ctx context.Context, namespace string) ([]ap
 type DeploymentStatus struct {
 	Name              string
 	Namespace         string
-	Replicas          int32
+	DesiredReplicas   int32 // spec.replicas
+	Replicas          int32 // replicas yang sedang berjalan
 	ReadyReplicas     int32
 	AvailableReplicas int32
 	UpdatedReplicas   int32
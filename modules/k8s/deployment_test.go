@@ -0,0 +1,39 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetDeploymentStatus(t *testing.T) {
+	client := NewK8sClientFromClientset(fake.NewClientset(testDeployment(true)))
+
+	status, err := client.GetDeploymentStatus(context.Background(), testNamespace, "shop-deployment")
+	if err != nil {
+		t.Fatalf("expected status, got %v", err)
+	}
+	if status.DesiredReplicas != 2 || status.ReadyReplicas != 2 || !status.IsReady {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// spec.replicas kosong dianggap 1, tidak panic
+	deployment := testDeployment(true)
+	deployment.Spec.Replicas = nil
+	deployment.Status.ReadyReplicas = 1
+	client = NewK8sClientFromClientset(fake.NewClientset(deployment))
+	status, err = client.GetDeploymentStatus(context.Background(), testNamespace, "shop-deployment")
+	if err != nil {
+		t.Fatalf("expected status, got %v", err)
+	}
+	if status.DesiredReplicas != 1 || !status.IsReady {
+		t.Fatalf("expected nil replicas to default to 1, got %+v", status)
+	}
+
+	// Deployment yang tidak ada harus bisa dibedakan dari 0 replica ready
+	status, err = client.GetDeploymentStatus(context.Background(), testNamespace, "missing-deployment")
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v (status %+v)", err, status)
+	}
+}
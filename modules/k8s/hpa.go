@@ -0,0 +1,191 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AutoscalingConfig - Configuration untuk HorizontalPodAutoscaler (autoscaling/v2).
+// HPA dibuat dengan nama yang sama dengan Deployment.
+type AutoscalingConfig struct {
+	MinReplicas int32
+	MaxReplicas int32
+
+	// Target rata-rata utilization (% dari resource request), nil = tidak dipakai
+	TargetCPUUtilization    *int32
+	TargetMemoryUtilization *int32
+}
+
+// AutoscalerStatus - Status HPA (replica sekarang vs yang diinginkan HPA)
+type AutoscalerStatus struct {
+	Name                     string `json:"name"`
+	MinReplicas              int32  `json:"minReplicas"`
+	MaxReplicas              int32  `json:"maxReplicas"`
+	CurrentReplicas          int32  `json:"currentReplicas"`
+	DesiredReplicas          int32  `json:"desiredReplicas"`
+	CurrentCPUUtilization    *int32 `json:"currentCpuUtilization,omitempty"`
+	CurrentMemoryUtilization *int32 `json:"currentMemoryUtilization,omitempty"`
+}
+
+// CreateHPA - Create HorizontalPodAutoscaler untuk Deployment di config
+func (k *K8sClient) CreateHPA(ctx context.Context, config *DeploymentConfig) error {
+	hpa, err := buildHPA(config)
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.AutoscalingV2().HorizontalPodAutoscalers(config.Namespace).Create(ctx, hpa, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("hpa %s already exists in namespace %s", config.Name, config.Namespace)
+		}
+		return fmt.Errorf("failed to create hpa: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyHPA - Create, update, atau delete HPA supaya sesuai dengan config.Autoscaling
+func (k *K8sClient) ApplyHPA(ctx context.Context, config *DeploymentConfig) error {
+	hpas := k.clientset.AutoscalingV2().HorizontalPodAutoscalers(config.Namespace)
+
+	existing, err := hpas.Get(ctx, config.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get hpa: %w", err)
+	}
+	exists := err == nil
+
+	if config.Autoscaling == nil {
+		if !exists {
+			return nil
+		}
+		return k.DeleteHPA(ctx, config.Namespace, config.Name)
+	}
+
+	if !exists {
+		return k.CreateHPA(ctx, config)
+	}
+
+	desired, err := buildHPA(config)
+	if err != nil {
+		return err
+	}
+
+	existing.Labels = desired.Labels
+	existing.Spec = desired.Spec
+	if _, err := hpas.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update hpa: %w", err)
+	}
+
+	return nil
+}
+
+// GetHPAStatus - Get status HPA, nil kalau Deployment tidak punya HPA
+func (k *K8sClient) GetHPAStatus(ctx context.Context, namespace, name string) (*AutoscalerStatus, error) {
+	hpa, err := k.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get hpa: %w", err)
+	}
+
+	status := &AutoscalerStatus{
+		Name:            hpa.Name,
+		MaxReplicas:     hpa.Spec.MaxReplicas,
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+	}
+	if hpa.Spec.MinReplicas != nil {
+		status.MinReplicas = *hpa.Spec.MinReplicas
+	}
+
+	for _, metric := range hpa.Status.CurrentMetrics {
+		if metric.Type != autoscalingv2.ResourceMetricSourceType || metric.Resource == nil {
+			continue
+		}
+		switch metric.Resource.Name {
+		case corev1.ResourceCPU:
+			status.CurrentCPUUtilization = metric.Resource.Current.AverageUtilization
+		case corev1.ResourceMemory:
+			status.CurrentMemoryUtilization = metric.Resource.Current.AverageUtilization
+		}
+	}
+
+	return status, nil
+}
+
+// DeleteHPA - Delete HorizontalPodAutoscaler
+func (k *K8sClient) DeleteHPA(ctx context.Context, namespace, name string) error {
+	err := k.clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("hpa %s not found in namespace %s", name, namespace)
+		}
+		return fmt.Errorf("failed to delete hpa: %w", err)
+	}
+
+	return nil
+}
+
+func buildHPA(config *DeploymentConfig) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	scaling := config.Autoscaling
+	if scaling == nil {
+		return nil, fmt.Errorf("autoscaling config is required")
+	}
+	if scaling.MinReplicas < 1 || scaling.MaxReplicas < scaling.MinReplicas {
+		return nil, fmt.Errorf("invalid autoscaling replicas: min %d, max %d", scaling.MinReplicas, scaling.MaxReplicas)
+	}
+	if scaling.TargetCPUUtilization == nil && scaling.TargetMemoryUtilization == nil {
+		return nil, fmt.Errorf("autoscaling needs a cpu or memory utilization target")
+	}
+
+	metrics := []autoscalingv2.MetricSpec{}
+	if scaling.TargetCPUUtilization != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, *scaling.TargetCPUUtilization))
+	}
+	if scaling.TargetMemoryUtilization != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceMemory, *scaling.TargetMemoryUtilization))
+	}
+
+	minReplicas := scaling.MinReplicas
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				"app": config.AppName,
+			},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       config.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: scaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+
+	return hpa, nil
+}
+
+func resourceMetric(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
@@ -9,30 +9,56 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// IngressConfig - Configuration untuk create Ingress
+type IngressConfig struct {
+	Name        string
+	Namespace   string
+	Host        string
+	ServiceName string
+	ServicePort int
+
+	ClassName     string // spec.ingressClassName, kosong = default IngressClass cluster
+	TLS           bool   // TLS dengan secret <name>-tls
+	ClusterIssuer string // cert-manager ClusterIssuer, hanya dipakai kalau TLS aktif
+
+	Annotations map[string]string
+}
+
 // CreateIngress - Create Ingress di K8s
-func (k *K8sClient) CreateIngress(ctx context.Context, namespace, name, host, serviceName string, servicePort int) error {
+func (k *K8sClient) CreateIngress(ctx context.Context, config *IngressConfig) error {
+	ingress := buildIngress(config)
+
+	_, err := k.clientset.NetworkingV1().Ingresses(config.Namespace).Create(ctx, ingress, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("ingress %s already exists in namespace %s", config.Name, config.Namespace)
+		}
+		return fmt.Errorf("failed to create ingress: %w", err)
+	}
+
+	return nil
+}
+
+func buildIngress(config *IngressConfig) *networkingv1.Ingress {
 	pathTypePrefix := networkingv1.PathTypePrefix
 
+	annotations := map[string]string{}
+	for key, value := range config.Annotations {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+
 	ingress := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
-			Annotations: map[string]string{
-				"kubernetes.io/ingress.class":                "nginx",
-				"cert-manager.io/cluster-issuer":             "letsencrypt-prod",
-				"nginx.ingress.kubernetes.io/rewrite-target": "/",
-			},
+			Name:        config.Name,
+			Namespace:   config.Namespace,
+			Annotations: annotations,
 		},
 		Spec: networkingv1.IngressSpec{
-			TLS: []networkingv1.IngressTLS{
-				{
-					Hosts:      []string{host},
-					SecretName: fmt.Sprintf("%s-tls", name), // secret untuk SSL cert
-				},
-			},
 			Rules: []networkingv1.IngressRule{
 				{
-					Host: host,
+					Host: config.Host,
 					IngressRuleValue: networkingv1.IngressRuleValue{
 						HTTP: &networkingv1.HTTPIngressRuleValue{
 							Paths: []networkingv1.HTTPIngressPath{
@@ -41,9 +67,9 @@ func (k *K8sClient) CreateIngress(ctx context.Context, namespace, name, host, se
 									PathType: &pathTypePrefix,
 									Backend: networkingv1.IngressBackend{
 										Service: &networkingv1.IngressServiceBackend{
-											Name: serviceName,
+											Name: config.ServiceName,
 											Port: networkingv1.ServiceBackendPort{
-												Number: int32(servicePort),
+												Number: int32(config.ServicePort),
 											},
 										},
 									},
@@ -56,15 +82,103 @@ func (k *K8sClient) CreateIngress(ctx context.Context, namespace, name, host, se
 		},
 	}
 
-	_, err := k.clientset.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, metav1.CreateOptions{})
+	if config.ClassName != "" {
+		className := config.ClassName
+		ingress.Spec.IngressClassName = &className
+	}
+
+	if config.TLS {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{config.Host},
+				SecretName: fmt.Sprintf("%s-tls", config.Name), // secret untuk SSL cert
+			},
+		}
+		if config.ClusterIssuer != "" {
+			ingress.Annotations["cert-manager.io/cluster-issuer"] = config.ClusterIssuer
+		}
+	}
+
+	return ingress
+}
+
+// IngressHost - Host tambahan (custom domain) dengan backend yang sama seperti host utama
+type IngressHost struct {
+	Host       string
+	SecretName string // kosong = tanpa TLS
+}
+
+// SetIngressHosts - Ganti semua host tambahan. Rule & TLS host utama (rule pertama) tidak
+// diubah, path host tambahan disalin dari host utama supaya ikut di-park / unpark.
+func (k *K8sClient) SetIngressHosts(ctx context.Context, namespace, name string, hosts []IngressHost) error {
+	ingress, err := k.GetIngress(ctx, namespace, name)
 	if err != nil {
-		if errors.IsAlreadyExists(err) {
-			return fmt.Errorf("ingress %s already exists in namespace %s", name, namespace)
+		return err
+	}
+	if len(ingress.Spec.Rules) == 0 {
+		return fmt.Errorf("ingress %s has no rules", name)
+	}
+
+	primary := ingress.Spec.Rules[0]
+	rules := []networkingv1.IngressRule{primary}
+
+	var tls []networkingv1.IngressTLS
+	for _, entry := range ingress.Spec.TLS {
+		for _, host := range entry.Hosts {
+			if host == primary.Host {
+				tls = append(tls, entry)
+				break
+			}
 		}
-		return fmt.Errorf("failed to create ingress: %w", err)
 	}
 
-	return nil
+	for _, host := range hosts {
+		rules = append(rules, networkingv1.IngressRule{
+			Host:             host.Host,
+			IngressRuleValue: *primary.IngressRuleValue.DeepCopy(),
+		})
+		if host.SecretName != "" {
+			tls = append(tls, networkingv1.IngressTLS{
+				Hosts:      []string{host.Host},
+				SecretName: host.SecretName,
+			})
+		}
+	}
+
+	ingress.Spec.Rules = rules
+	ingress.Spec.TLS = tls
+
+	return k.UpdateIngress(ctx, ingress)
+}
+
+// UpdateIngressHost - Ganti host rule & TLS yang memakai oldHost (rename subdomain).
+// Secret TLS tetap sama, cert-manager issue ulang certificate untuk host baru.
+func (k *K8sClient) UpdateIngressHost(ctx context.Context, namespace, name, oldHost, newHost string) error {
+	ingress, err := k.GetIngress(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i := range ingress.Spec.Rules {
+		if ingress.Spec.Rules[i].Host == oldHost {
+			ingress.Spec.Rules[i].Host = newHost
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("ingress %s has no rule for host %s", name, oldHost)
+	}
+
+	for i := range ingress.Spec.TLS {
+		for j, host := range ingress.Spec.TLS[i].Hosts {
+			if host == oldHost {
+				ingress.Spec.TLS[i].Hosts[j] = newHost
+			}
+		}
+	}
+
+	return k.UpdateIngress(ctx, ingress)
 }
 
 // GetIngress - Get Ingress by name
@@ -90,6 +204,60 @@ func (k *K8sClient) UpdateIngress(ctx context.Context, ingress *networkingv1.Ing
 	return nil
 }
 
+// UpdateIngressBackend - Arahkan semua path ke service lain dan merge annotations
+// (annotation dengan value "" akan dihapus)
+func (k *K8sClient) UpdateIngressBackend(ctx context.Context, namespace, name, serviceName string, servicePort int, annotations map[string]string) error {
+	ingress, err := k.GetIngress(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	for i := range ingress.Spec.Rules {
+		if ingress.Spec.Rules[i].HTTP == nil {
+			continue
+		}
+		for j := range ingress.Spec.Rules[i].HTTP.Paths {
+			ingress.Spec.Rules[i].HTTP.Paths[j].Backend = networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: serviceName,
+					Port: networkingv1.ServiceBackendPort{
+						Number: int32(servicePort),
+					},
+				},
+			}
+		}
+	}
+
+	mergeAnnotations(ingress, annotations)
+
+	return k.UpdateIngress(ctx, ingress)
+}
+
+// UpdateIngressAnnotations - Merge annotations (value "" = hapus annotation)
+func (k *K8sClient) UpdateIngressAnnotations(ctx context.Context, namespace, name string, annotations map[string]string) error {
+	ingress, err := k.GetIngress(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	mergeAnnotations(ingress, annotations)
+
+	return k.UpdateIngress(ctx, ingress)
+}
+
+func mergeAnnotations(ingress *networkingv1.Ingress, annotations map[string]string) {
+	if ingress.Annotations == nil {
+		ingress.Annotations = map[string]string{}
+	}
+	for key, value := range annotations {
+		if value == "" {
+			delete(ingress.Annotations, key)
+			continue
+		}
+		ingress.Annotations[key] = value
+	}
+}
+
 // DeleteIngress - Delete Ingress
 func (k *K8sClient) DeleteIngress(ctx context.Context, namespace, name string) error {
 	err := k.clientset.NetworkingV1().Ingresses(namespace).Delete(ctx, name, metav1.DeleteOptions{})
@@ -0,0 +1,275 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var ErrJobDeleted = errors.New("job was deleted while waiting")
+
+const (
+	// BuilderContainer - Init container builder (tanpa credential), log build dibaca dari sini
+	BuilderContainer = "builder"
+
+	// PushContainer - Push hasil build ke registry, termination message berisi digest image
+	PushContainer = "push"
+
+	// BuildOutputPath - emptyDir bersama builder & push, builder menulis tarball image ke sini
+	BuildOutputPath = "/workspace/output"
+
+	// dockerConfigPath - Credential registry, hanya di-mount di PushContainer
+	dockerConfigPath = "/etc/registry"
+)
+
+// BuildJobConfig - Job satu kali jalan untuk build & push image. Builder jalan sebagai init
+// container tanpa credential registry (step RUN di Dockerfile user jalan di container itu),
+// lalu PushImage mendorong tarball dari BuildOutputPath dengan credential DockerConfigSecret.
+type BuildJobConfig struct {
+	Name      string
+	Namespace string
+	Image     string // image builder, e.g., gcr.io/kaniko-project/executor
+	Args      []string
+	PushImage string // image push, e.g., gcr.io/go-containerregistry/crane
+	PushArgs  []string
+	Labels    map[string]string
+
+	// Secret dockerconfigjson untuk push ke registry, di-mount sebagai config.json
+	// dengan DOCKER_CONFIG di container push saja
+	DockerConfigSecret string
+
+	CPULimit    string
+	MemoryLimit string
+
+	ActiveDeadlineSeconds   int64
+	TTLSecondsAfterFinished int32
+}
+
+// JobResult - Hasil akhir Job, TerminationMessage berisi output container push
+// (digest image ditulis ke /dev/termination-log)
+type JobResult struct {
+	Succeeded          bool   `json:"succeeded"`
+	Message            string `json:"message,omitempty"`
+	TerminationMessage string `json:"terminationMessage,omitempty"`
+	PodName            string `json:"podName,omitempty"`
+}
+
+// CreateBuildJob - Job dengan BackoffLimit 0, retry diatur oleh job queue
+func (k *K8sClient) CreateBuildJob(ctx context.Context, config *BuildJobConfig) error {
+	if config.PushImage == "" {
+		return fmt.Errorf("build job %s has no push image", config.Name)
+	}
+	backoffLimit := int32(0)
+
+	limits, err := parseResourceList(map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    config.CPULimit,
+		corev1.ResourceMemory: config.MemoryLimit,
+	})
+	if err != nil {
+		return err
+	}
+	output := corev1.VolumeMount{Name: "output", MountPath: BuildOutputPath}
+
+	builder := corev1.Container{
+		Name:         BuilderContainer,
+		Image:        config.Image,
+		Args:         config.Args,
+		VolumeMounts: []corev1.VolumeMount{output},
+	}
+	builder.Resources.Limits = limits
+
+	output.ReadOnly = true
+	push := corev1.Container{
+		Name:                     PushContainer,
+		Image:                    config.PushImage,
+		Args:                     config.PushArgs,
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		VolumeMounts:             []corev1.VolumeMount{output},
+	}
+
+	// Token ServiceAccount tidak di-mount, step RUN di Dockerfile tidak boleh akses API server
+	automountToken := false
+	podSpec := corev1.PodSpec{
+		RestartPolicy:                corev1.RestartPolicyNever,
+		InitContainers:               []corev1.Container{builder},
+		AutomountServiceAccountToken: &automountToken,
+		Volumes: []corev1.Volume{{
+			Name:         "output",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}},
+	}
+	if config.DockerConfigSecret != "" {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: "docker-config",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: config.DockerConfigSecret,
+					Items:      []corev1.KeyToPath{{Key: corev1.DockerConfigJsonKey, Path: "config.json"}},
+				},
+			},
+		})
+		push.VolumeMounts = append(push.VolumeMounts, corev1.VolumeMount{
+			Name:      "docker-config",
+			MountPath: dockerConfigPath,
+			ReadOnly:  true,
+		})
+		push.Env = []corev1.EnvVar{{Name: "DOCKER_CONFIG", Value: dockerConfigPath}}
+	}
+	podSpec.Containers = []corev1.Container{push}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: config.Namespace,
+			Labels:    config.Labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: config.Labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	if config.ActiveDeadlineSeconds > 0 {
+		job.Spec.ActiveDeadlineSeconds = &config.ActiveDeadlineSeconds
+	}
+	if config.TTLSecondsAfterFinished > 0 {
+		job.Spec.TTLSecondsAfterFinished = &config.TTLSecondsAfterFinished
+	}
+
+	if _, err := k.clientset.BatchV1().Jobs(config.Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+func (k *K8sClient) JobExists(ctx context.Context, namespace, name string) (bool, error) {
+	_, err := k.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get job: %w", err)
+	}
+	return true, nil
+}
+
+// DeleteJob - Hapus Job beserta pod-nya, tidak error kalau sudah tidak ada
+func (k *K8sClient) DeleteJob(ctx context.Context, namespace, name string) error {
+	propagation := metav1.DeletePropagationBackground
+	err := k.clientset.BatchV1().Jobs(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	return nil
+}
+
+// WaitForJob - Poll sampai Job Complete/Failed. Pod yang gagal pull image builder / push
+// langsung dianggap gagal supaya tidak menunggu sampai ActiveDeadlineSeconds.
+func (k *K8sClient) WaitForJob(ctx context.Context, namespace, name string, timeoutSeconds int) (*JobResult, error) {
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := k.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, waitError(ctx, name)
+			}
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("%w: %s", ErrJobDeleted, name)
+			}
+			return nil, fmt.Errorf("failed to get job: %w", err)
+		}
+
+		if result := jobResult(job); result != nil {
+			pod, err := k.GetJobPod(ctx, namespace, name)
+			if err == nil && pod != nil {
+				result.PodName = pod.Name
+				result.TerminationMessage = terminationMessage(pod)
+			}
+			return result, nil
+		}
+
+		pod, err := k.GetJobPod(ctx, namespace, name)
+		if err == nil && pod != nil {
+			for _, statuses := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+				if err := containerFailure(pod.Name, statuses); err != nil {
+					return &JobResult{Message: err.Error(), PodName: pod.Name}, nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, waitError(ctx, name)
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetJobPod - Pod terbaru milik Job, nil kalau belum dibuat
+func (k *K8sClient) GetJobPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job pods: %w", err)
+	}
+
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if latest == nil || pod.CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = pod
+		}
+	}
+	return latest, nil
+}
+
+// jobResult - nil kalau Job masih berjalan
+func jobResult(job *batchv1.Job) *JobResult {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return &JobResult{Succeeded: true}
+		case batchv1.JobFailed:
+			message := cond.Message
+			if message == "" {
+				message = cond.Reason
+			}
+			return &JobResult{Message: message}
+		}
+	}
+	return nil
+}
+
+func terminationMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == PushContainer && status.State.Terminated != nil {
+			return strings.TrimSpace(status.State.Terminated.Message)
+		}
+	}
+	return ""
+}
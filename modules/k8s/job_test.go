@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreateBuildJob(t *testing.T) {
+	clientset := fake.NewClientset()
+	client := NewK8sClientFromClientset(clientset)
+
+	err := client.CreateBuildJob(context.Background(), &BuildJobConfig{
+		Name:               "build-1",
+		Namespace:          testNamespace,
+		Image:              "kaniko",
+		Args:               []string{"--no-push"},
+		PushImage:          "crane",
+		PushArgs:           []string{"push"},
+		DockerConfigSecret: "registry-push-user1",
+	})
+	if err != nil {
+		t.Fatalf("expected job to be created, got %v", err)
+	}
+
+	job, err := clientset.BatchV1().Jobs(testNamespace).Get(context.Background(), "build-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected job, got %v", err)
+	}
+	spec := job.Spec.Template.Spec
+	if len(spec.InitContainers) != 1 || len(spec.Containers) != 1 {
+		t.Fatalf("expected builder init container and push container, got %d / %d", len(spec.InitContainers), len(spec.Containers))
+	}
+
+	// Step RUN di Dockerfile jalan di builder, credential registry tidak boleh terlihat di sana
+	builder := spec.InitContainers[0]
+	if builder.Name != BuilderContainer || hasMount(builder, "docker-config") || len(builder.Env) != 0 {
+		t.Fatalf("expected builder without registry credentials, got %+v", builder)
+	}
+	if !hasMount(builder, "output") {
+		t.Fatal("expected builder to write to the shared output volume")
+	}
+
+	push := spec.Containers[0]
+	if push.Name != PushContainer || !hasMount(push, "docker-config") || !hasMount(push, "output") {
+		t.Fatalf("expected push container with credentials and output, got %+v", push)
+	}
+	if push.TerminationMessagePolicy != corev1.TerminationMessageReadFile {
+		t.Fatalf("expected push container to report the digest, got %s", push.TerminationMessagePolicy)
+	}
+}
+
+func TestCreateBuildJobWithoutPushImage(t *testing.T) {
+	client := NewK8sClientFromClientset(fake.NewClientset())
+
+	err := client.CreateBuildJob(context.Background(), &BuildJobConfig{Name: "build-1", Namespace: testNamespace, Image: "kaniko"})
+	if err == nil {
+		t.Fatal("expected error without push image")
+	}
+}
+
+func TestTerminationMessage(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{
+		InitContainerStatuses: []corev1.ContainerStatus{{
+			Name:  BuilderContainer,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "builder output"}},
+		}},
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  PushContainer,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "registry/app@sha256:abc\n"}},
+		}},
+	}}
+
+	if got := terminationMessage(pod); got != "registry/app@sha256:abc" {
+		t.Fatalf("expected push container message, got %q", got)
+	}
+	if !ContainerStarted(pod, BuilderContainer) {
+		t.Fatal("expected finished init container to count as started")
+	}
+}
+
+func hasMount(container corev1.Container, volume string) bool {
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == volume {
+			return true
+		}
+	}
+	return false
+}
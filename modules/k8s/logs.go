@@ -0,0 +1,236 @@
+package k8s
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MaxLogLineBytes - Baris lebih panjang dari ini dipotong, sisanya dibuang sampai newline
+	MaxLogLineBytes = 1 << 20
+
+	// TruncatedLineSuffix - Ditambahkan ke baris yang dipotong
+	TruncatedLineSuffix = " [truncated]"
+)
+
+// LogOptions - Subset PodLogOptions, timestamps selalu aktif
+type LogOptions struct {
+	Container    string
+	Follow       bool
+	TailLines    *int64
+	SinceSeconds *int64
+	LimitBytes   *int64 // dibatasi kubelet, sisa log tidak dikirim
+	Previous     bool   // log container sebelum restart (CrashLoopBackOff)
+}
+
+// LogLine - Satu baris log pod, Timestamp dari kubelet (RFC3339Nano)
+type LogLine struct {
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Pod       string     `json:"pod,omitempty"`
+	Container string     `json:"container,omitempty"`
+	Line      string     `json:"line"`
+}
+
+// PodInfo - Ringkasan pod workspace untuk memilih replica & container log
+type PodInfo struct {
+	Name           string     `json:"name"`
+	Phase          string     `json:"phase"`
+	Ready          bool       `json:"ready"`
+	Restarts       int32      `json:"restarts"`
+	Containers     []string   `json:"containers"`
+	InitContainers []string   `json:"initContainers,omitempty"`
+	StartedAt      *time.Time `json:"startedAt,omitempty"`
+}
+
+// ListAppPods - Pod dengan label app=<appName>, urut dari yang paling lama
+func (k *K8sClient) ListAppPods(ctx context.Context, namespace, appName string) ([]PodInfo, error) {
+	pods, err := k.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "app=" + appName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	sort.Slice(pods.Items, func(i, j int) bool {
+		return pods.Items[i].CreationTimestamp.Before(&pods.Items[j].CreationTimestamp)
+	})
+
+	result := make([]PodInfo, 0, len(pods.Items))
+	for i := range pods.Items {
+		result = append(result, podInfo(&pods.Items[i]))
+	}
+	return result, nil
+}
+
+func podInfo(pod *corev1.Pod) PodInfo {
+	info := PodInfo{
+		Name:  pod.Name,
+		Phase: string(pod.Status.Phase),
+	}
+	for _, container := range pod.Spec.Containers {
+		info.Containers = append(info.Containers, container.Name)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		info.InitContainers = append(info.InitContainers, container.Name)
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		info.Restarts += status.RestartCount
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			info.Ready = cond.Status == corev1.ConditionTrue
+		}
+	}
+	if pod.Status.StartTime != nil {
+		started := pod.Status.StartTime.Time
+		info.StartedAt = &started
+	}
+	return info
+}
+
+// HasContainer - Container biasa atau init container
+func (p PodInfo) HasContainer(name string) bool {
+	for _, container := range p.Containers {
+		if container == name {
+			return true
+		}
+	}
+	for _, container := range p.InitContainers {
+		if container == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StreamPodLogs - Baca log lewat GetLogs(...).Stream, fn dipanggil per baris.
+// Dengan Follow, return saat container berhenti, ctx dibatalkan, atau fn return error.
+func (k *K8sClient) StreamPodLogs(ctx context.Context, namespace, pod string, opts LogOptions, fn func(LogLine) error) error {
+	stream, err := k.openLogStream(ctx, namespace, pod, opts)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var callbackErr error
+	err = ReadLines(stream, MaxLogLineBytes, func(raw string) error {
+		line := ParseLogLine(raw)
+		line.Pod = pod
+		line.Container = opts.Container
+		callbackErr = fn(line)
+		return callbackErr
+	})
+	if callbackErr != nil {
+		return callbackErr
+	}
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read pod logs: %w", err)
+	}
+	return nil
+}
+
+// ReadLines - fn dipanggil per baris (tanpa newline). Beda dengan bufio.Scanner, baris
+// yang lebih panjang dari maxLineBytes tidak menghentikan pembacaan (ErrTooLong):
+// baris dipotong, diberi TruncatedLineSuffix, dan sisanya dibuang.
+func ReadLines(r io.Reader, maxLineBytes int, fn func(line string) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	var line []byte
+	truncated := false
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !truncated {
+			if room := maxLineBytes - len(line); len(chunk) > room {
+				line = append(line, chunk[:room]...)
+				truncated = true
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		// err nil = chunk diakhiri newline; EOF dengan sisa data = baris terakhir tanpa newline
+		if err == nil || (err == io.EOF && (len(line) > 0 || truncated)) {
+			text := strings.TrimRight(string(line), "\r\n")
+			if truncated {
+				text += TruncatedLineSuffix
+			}
+			if err := fn(text); err != nil {
+				return err
+			}
+			line = line[:0]
+			truncated = false
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// GetPodLogs - Log lengkap (tanpa follow) dalam format asli "<timestamp> <line>"
+func (k *K8sClient) GetPodLogs(ctx context.Context, namespace, pod string, opts LogOptions) ([]byte, error) {
+	opts.Follow = false
+	stream, err := k.openLogStream(ctx, namespace, pod, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod logs: %w", err)
+	}
+	return data, nil
+}
+
+func (k *K8sClient) openLogStream(ctx context.Context, namespace, pod string, opts LogOptions) (io.ReadCloser, error) {
+	stream, err := k.clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container:    opts.Container,
+		Follow:       opts.Follow,
+		TailLines:    opts.TailLines,
+		SinceSeconds: opts.SinceSeconds,
+		LimitBytes:   opts.LimitBytes,
+		Previous:     opts.Previous,
+		Timestamps:   true,
+	}).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stream logs of pod %s: %w", pod, err)
+	}
+	return stream, nil
+}
+
+// ParseLogLine - Pisahkan timestamp kubelet dari isi baris
+func ParseLogLine(raw string) LogLine {
+	prefix, rest, found := strings.Cut(raw, " ")
+	if found {
+		if ts, err := time.Parse(time.RFC3339Nano, prefix); err == nil {
+			return LogLine{Timestamp: &ts, Line: rest}
+		}
+	}
+	return LogLine{Line: raw}
+}
+
+// ContainerStarted - Log container (termasuk init container) sudah bisa dibaca (running atau sudah selesai)
+func ContainerStarted(pod *corev1.Pod, container string) bool {
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	for _, status := range append(statuses, pod.Status.ContainerStatuses...) {
+		if status.Name == container {
+			return status.State.Running != nil || status.State.Terminated != nil
+		}
+	}
+	return false
+}
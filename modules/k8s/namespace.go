@@ -74,3 +74,24 @@ func (k *K8sClient) ListNamespaces(ctx context.Context, labelSelector string) ([
 
 	return namespaceList.Items, nil
 }
+
+// UpdateNamespaceLabels - Set label namespace, label lain tidak berubah
+func (k *K8sClient) UpdateNamespaceLabels(ctx context.Context, name string, labels map[string]string) error {
+	namespace, err := k.GetNamespace(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if namespace.Labels == nil {
+		namespace.Labels = map[string]string{}
+	}
+	for key, value := range labels {
+		namespace.Labels[key] = value
+	}
+
+	if _, err := k.clientset.CoreV1().Namespaces().Update(ctx, namespace, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update namespace %s: %w", name, err)
+	}
+
+	return nil
+}
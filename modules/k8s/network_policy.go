@@ -0,0 +1,225 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// Label otomatis di setiap namespace (Kubernetes >= 1.21)
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+
+	// EgressPolicyName - Policy berisi egress yang dibuka user, dibuat ulang setiap ada perubahan
+	EgressPolicyName = "allow-user-egress"
+
+	// BuildEgressPolicyName - Egress pod build (git & registry) di namespace build
+	BuildEgressPolicyName = "allow-build-egress"
+)
+
+// NetworkIsolationConfig - Sumber traffic yang tetap diizinkan setelah default-deny
+type NetworkIsolationConfig struct {
+	IngressNamespace string // namespace ingress controller, e.g., "ingress-nginx"
+	DNSNamespace     string // namespace CoreDNS / kube-dns, e.g., "kube-system"
+}
+
+// EgressRule - Tujuan egress yang dibuka (CIDR, optional port)
+type EgressRule struct {
+	CIDR     string
+	Except   []string // bagian CIDR yang tetap diblok (e.g., CIDR cluster)
+	Port     int32    // 0 = semua port
+	Protocol string   // TCP / UDP
+}
+
+// ApplyNamespaceIsolation - Create / update default-deny ingress & egress, lalu buka
+// traffic dari ingress controller, antar pod di namespace yang sama, dan DNS
+func (k *K8sClient) ApplyNamespaceIsolation(ctx context.Context, namespace string, config NetworkIsolationConfig) error {
+	if config.IngressNamespace == "" || config.DNSNamespace == "" {
+		return fmt.Errorf("ingress namespace and dns namespace are required")
+	}
+
+	for _, policy := range buildIsolationPolicies(namespace, config) {
+		if err := k.applyNetworkPolicy(ctx, policy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ApplyEgressRules - Sinkronkan policy egress user, rules kosong = policy dihapus
+func (k *K8sClient) ApplyEgressRules(ctx context.Context, namespace string, rules []EgressRule) error {
+	if len(rules) == 0 {
+		err := k.clientset.NetworkingV1().NetworkPolicies(namespace).Delete(ctx, EgressPolicyName, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete networkpolicy: %w", err)
+		}
+		return nil
+	}
+
+	policy, err := buildEgressPolicy(namespace, EgressPolicyName, rules)
+	if err != nil {
+		return err
+	}
+
+	return k.applyNetworkPolicy(ctx, policy)
+}
+
+// ApplyBuildIsolation - Namespace build: default-deny ingress & egress, DNS, dan egress
+// sesuai rules. Pod build tidak menerima traffic sama sekali, termasuk dari pod build lain.
+func (k *K8sClient) ApplyBuildIsolation(ctx context.Context, namespace, dnsNamespace string, rules []EgressRule) error {
+	if dnsNamespace == "" {
+		return fmt.Errorf("dns namespace is required")
+	}
+
+	egress, err := buildEgressPolicy(namespace, BuildEgressPolicyName, rules)
+	if err != nil {
+		return err
+	}
+
+	policies := []*networkingv1.NetworkPolicy{
+		defaultDenyPolicy(namespace),
+		dnsPolicy(namespace, dnsNamespace),
+		egress,
+	}
+	for _, policy := range policies {
+		if err := k.applyNetworkPolicy(ctx, policy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (k *K8sClient) applyNetworkPolicy(ctx context.Context, policy *networkingv1.NetworkPolicy) error {
+	policies := k.clientset.NetworkingV1().NetworkPolicies(policy.Namespace)
+
+	existing, err := policies.Get(ctx, policy.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get networkpolicy: %w", err)
+		}
+		if _, err := policies.Create(ctx, policy, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create networkpolicy %s: %w", policy.Name, err)
+		}
+		return nil
+	}
+
+	existing.Spec = policy.Spec
+	if _, err := policies.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update networkpolicy %s: %w", policy.Name, err)
+	}
+
+	return nil
+}
+
+func buildIsolationPolicies(namespace string, config NetworkIsolationConfig) []*networkingv1.NetworkPolicy {
+	allPods := metav1.LabelSelector{}
+	both := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+	policy := func(name string, spec networkingv1.NetworkPolicySpec) *networkingv1.NetworkPolicy {
+		return networkPolicy(namespace, name, spec)
+	}
+
+	return []*networkingv1.NetworkPolicy{
+		defaultDenyPolicy(namespace),
+		policy("allow-same-namespace", networkingv1.NetworkPolicySpec{
+			PodSelector: allPods,
+			PolicyTypes: both,
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+			},
+		}),
+		policy("allow-ingress-controller", networkingv1.NetworkPolicySpec{
+			PodSelector: allPods,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{From: []networkingv1.NetworkPolicyPeer{namespacePeer(config.IngressNamespace)}},
+			},
+		}),
+		dnsPolicy(namespace, config.DNSNamespace),
+	}
+}
+
+func networkPolicy(namespace, name string, spec networkingv1.NetworkPolicySpec) *networkingv1.NetworkPolicy {
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// defaultDenyPolicy - Tanpa rule: semua ingress & egress ditolak kecuali dibuka policy lain
+func defaultDenyPolicy(namespace string) *networkingv1.NetworkPolicy {
+	return networkPolicy(namespace, "default-deny", networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+	})
+}
+
+func dnsPolicy(namespace, dnsNamespace string) *networkingv1.NetworkPolicy {
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+	dnsPort := intstr.FromInt(53)
+
+	return networkPolicy(namespace, "allow-dns", networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		Egress: []networkingv1.NetworkPolicyEgressRule{
+			{
+				To: []networkingv1.NetworkPolicyPeer{namespacePeer(dnsNamespace)},
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: &udp, Port: &dnsPort},
+					{Protocol: &tcp, Port: &dnsPort},
+				},
+			},
+		},
+	})
+}
+
+func buildEgressPolicy(namespace, name string, rules []EgressRule) (*networkingv1.NetworkPolicy, error) {
+	egress := make([]networkingv1.NetworkPolicyEgressRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.CIDR == "" {
+			return nil, fmt.Errorf("egress rule requires a cidr")
+		}
+
+		item := networkingv1.NetworkPolicyEgressRule{
+			To: []networkingv1.NetworkPolicyPeer{
+				{IPBlock: &networkingv1.IPBlock{CIDR: rule.CIDR, Except: rule.Except}},
+			},
+		}
+		if rule.Port > 0 {
+			protocol := corev1.Protocol(rule.Protocol)
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+			port := intstr.FromInt(int(rule.Port))
+			item.Ports = []networkingv1.NetworkPolicyPort{{Protocol: &protocol, Port: &port}}
+		}
+		egress = append(egress, item)
+	}
+
+	return networkPolicy(namespace, name, networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		Egress:      egress,
+	}), nil
+}
+
+func namespacePeer(namespace string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: namespace},
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package k8s
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+	ProbeExec = "exec"
+)
+
+// ProbeConfig - Configuration untuk liveness/readiness/startup probe.
+// Port 0 = ContainerPort, nilai 0 lainnya memakai default Kubernetes.
+type ProbeConfig struct {
+	Type    string // "http", "tcp", atau "exec"
+	Path    string // HTTP path, default "/"
+	Port    int32
+	Command []string // untuk exec
+
+	InitialDelaySeconds int32
+	PeriodSeconds       int32
+	TimeoutSeconds      int32
+	SuccessThreshold    int32
+	FailureThreshold    int32
+}
+
+// buildProbe - Convert ProbeConfig ke corev1.Probe, nil config = tanpa probe
+func buildProbe(probe *ProbeConfig, containerPort int32) (*corev1.Probe, error) {
+	if probe == nil {
+		return nil, nil
+	}
+
+	port := probe.Port
+	if port == 0 {
+		port = containerPort
+	}
+
+	var handler corev1.ProbeHandler
+	switch probe.Type {
+	case ProbeHTTP:
+		path := probe.Path
+		if path == "" {
+			path = "/"
+		}
+		handler.HTTPGet = &corev1.HTTPGetAction{
+			Path: path,
+			Port: intstr.FromInt32(port),
+		}
+	case ProbeTCP:
+		handler.TCPSocket = &corev1.TCPSocketAction{
+			Port: intstr.FromInt32(port),
+		}
+	case ProbeExec:
+		if len(probe.Command) == 0 {
+			return nil, fmt.Errorf("exec probe requires a command")
+		}
+		handler.Exec = &corev1.ExecAction{
+			Command: probe.Command,
+		}
+	default:
+		return nil, fmt.Errorf("unknown probe type %q", probe.Type)
+	}
+
+	return &corev1.Probe{
+		ProbeHandler:        handler,
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}, nil
+}
@@ -0,0 +1,176 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Nama tetap, satu ResourceQuota & LimitRange per namespace user
+	ResourceQuotaName = "workspace-quota"
+	LimitRangeName    = "workspace-limits"
+)
+
+// QuotaConfig - Batas total resource namespace (ResourceQuota) dan
+// default/max per container (LimitRange). Field kosong / 0 = tidak dibatasi.
+type QuotaConfig struct {
+	CPU      string // total limits.cpu
+	Memory   string // total limits.memory
+	Storage  string // total requests.storage semua PVC
+	Pods     int
+	Services int
+
+	DefaultCPURequest    string
+	DefaultCPULimit      string
+	DefaultMemoryRequest string
+	DefaultMemoryLimit   string
+	MaxCPU               string
+	MaxMemory            string
+}
+
+// QuotaUsage - Hard limit dan pemakaian dari status ResourceQuota
+type QuotaUsage struct {
+	Hard map[string]string `json:"hard"`
+	Used map[string]string `json:"used"`
+}
+
+// ApplyResourceQuota - Create atau update ResourceQuota dan LimitRange namespace
+func (k *K8sClient) ApplyResourceQuota(ctx context.Context, namespace string, config QuotaConfig) error {
+	quota, err := buildResourceQuota(namespace, config)
+	if err != nil {
+		return err
+	}
+	limitRange, err := buildLimitRange(namespace, config)
+	if err != nil {
+		return err
+	}
+
+	// LimitRange dulu: pod tanpa resource limits ditolak begitu quota limits.* aktif
+	limitRanges := k.clientset.CoreV1().LimitRanges(namespace)
+	existingRange, err := limitRanges.Get(ctx, LimitRangeName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		if _, err := limitRanges.Create(ctx, limitRange, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create limitrange: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get limitrange: %w", err)
+	default:
+		existingRange.Spec = limitRange.Spec
+		if _, err := limitRanges.Update(ctx, existingRange, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update limitrange: %w", err)
+		}
+	}
+
+	quotas := k.clientset.CoreV1().ResourceQuotas(namespace)
+	existingQuota, err := quotas.Get(ctx, ResourceQuotaName, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		if _, err := quotas.Create(ctx, quota, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create resourcequota: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get resourcequota: %w", err)
+	default:
+		existingQuota.Spec = quota.Spec
+		if _, err := quotas.Update(ctx, existingQuota, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update resourcequota: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetResourceQuotaUsage - Get hard & used ResourceQuota, nil kalau namespace belum punya quota
+func (k *K8sClient) GetResourceQuotaUsage(ctx context.Context, namespace string) (*QuotaUsage, error) {
+	quota, err := k.clientset.CoreV1().ResourceQuotas(namespace).Get(ctx, ResourceQuotaName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get resourcequota: %w", err)
+	}
+
+	usage := &QuotaUsage{
+		Hard: map[string]string{},
+		Used: map[string]string{},
+	}
+	for name, quantity := range quota.Status.Hard {
+		usage.Hard[string(name)] = quantity.String()
+	}
+	for name, quantity := range quota.Status.Used {
+		usage.Used[string(name)] = quantity.String()
+	}
+
+	return usage, nil
+}
+
+func buildResourceQuota(namespace string, config QuotaConfig) (*corev1.ResourceQuota, error) {
+	hard, err := parseResourceList(map[corev1.ResourceName]string{
+		corev1.ResourceLimitsCPU:       config.CPU,
+		corev1.ResourceLimitsMemory:    config.Memory,
+		corev1.ResourceRequestsStorage: config.Storage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hard == nil {
+		hard = corev1.ResourceList{}
+	}
+
+	if config.Pods > 0 {
+		hard[corev1.ResourcePods] = *resource.NewQuantity(int64(config.Pods), resource.DecimalSI)
+	}
+	if config.Services > 0 {
+		hard[corev1.ResourceServices] = *resource.NewQuantity(int64(config.Services), resource.DecimalSI)
+	}
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResourceQuotaName,
+			Namespace: namespace,
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}, nil
+}
+
+func buildLimitRange(namespace string, config QuotaConfig) (*corev1.LimitRange, error) {
+	item := corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}
+
+	lists := []struct {
+		target *corev1.ResourceList
+		cpu    string
+		memory string
+	}{
+		{&item.Default, config.DefaultCPULimit, config.DefaultMemoryLimit},
+		{&item.DefaultRequest, config.DefaultCPURequest, config.DefaultMemoryRequest},
+		{&item.Max, config.MaxCPU, config.MaxMemory},
+	}
+	for _, l := range lists {
+		list, err := parseResourceList(map[corev1.ResourceName]string{
+			corev1.ResourceCPU:    l.cpu,
+			corev1.ResourceMemory: l.memory,
+		})
+		if err != nil {
+			return nil, err
+		}
+		*l.target = list
+	}
+
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LimitRangeName,
+			Namespace: namespace,
+		},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{item},
+		},
+	}, nil
+}
@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// parseResourceList - Value kosong dilewati, quantity yang tidak valid dikembalikan sebagai error.
+// nil kalau semua value kosong.
+func parseResourceList(values map[corev1.ResourceName]string) (corev1.ResourceList, error) {
+	var list corev1.ResourceList
+	for name, value := range values {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s quantity %q: %w", name, value, err)
+		}
+		if list == nil {
+			list = corev1.ResourceList{}
+		}
+		list[name] = quantity
+	}
+	return list, nil
+}
+
+// parseResourceRequirements - Requests & limits CPU / memory container
+func parseResourceRequirements(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) (corev1.ResourceRequirements, error) {
+	requests, err := parseResourceList(map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    cpuRequest,
+		corev1.ResourceMemory: memoryRequest,
+	})
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	limits, err := parseResourceList(map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    cpuLimit,
+		corev1.ResourceMemory: memoryLimit,
+	})
+	if err != nil {
+		return corev1.ResourceRequirements{}, err
+	}
+	return corev1.ResourceRequirements{Requests: requests, Limits: limits}, nil
+}
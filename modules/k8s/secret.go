@@ -122,6 +122,31 @@ func (k *K8sClient) UpdateSecret(
 	return nil
 }
 
+// UpdateSecretKeys - Set dan hapus sebagian key tanpa mengubah key lain
+func (k *K8sClient) UpdateSecretKeys(ctx context.Context, namespace, secretName string, set map[string]string, remove []string) error {
+	secret, err := k.GetSecret(ctx, namespace, secretName)
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for _, key := range remove {
+		delete(secret.Data, key)
+	}
+	for key, value := range set {
+		secret.Data[key] = []byte(value)
+	}
+
+	_, err = k.clientset.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	return nil
+}
+
 // DeleteSecret - Delete Secret
 func (k *K8sClient) DeleteSecret(ctx context.Context, namespace, name string) error {
 	err := k.clientset.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
@@ -147,3 +172,44 @@ func (k *K8sClient) SecretExists(ctx context.Context, namespace, name string) (b
 
 	return true, nil
 }
+
+// CopySecret - Create / update Secret dengan data & type yang sama di namespace lain
+func (k *K8sClient) CopySecret(ctx context.Context, fromNamespace, toNamespace, name string, labels map[string]string) error {
+	return k.CopySecretAs(ctx, fromNamespace, name, toNamespace, name, labels)
+}
+
+// CopySecretAs - CopySecret dengan nama tujuan berbeda (bisa di namespace yang sama)
+func (k *K8sClient) CopySecretAs(ctx context.Context, fromNamespace, fromName, toNamespace, toName string, labels map[string]string) error {
+	source, err := k.GetSecret(ctx, fromNamespace, fromName)
+	if err != nil {
+		return err
+	}
+
+	secrets := k.clientset.CoreV1().Secrets(toNamespace)
+	existing, err := secrets.Get(ctx, toName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      toName,
+				Namespace: toNamespace,
+				Labels:    labels,
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}
+		if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create secret: %w", err)
+		}
+		return nil
+	}
+
+	// Type Secret immutable, hanya data yang diikutkan (e.g., credential di-rotate)
+	existing.Data = source.Data
+	if _, err := secrets.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+	return nil
+}
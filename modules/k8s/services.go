@@ -98,3 +98,49 @@ func (k *K8sClient) GetServiceEndpoint(ctx context.Context, namespace, name stri
 	// Return format: ClusterIP:Port
 	return fmt.Sprintf("%s:%d", service.Spec.ClusterIP, service.Spec.Ports[0].Port), nil
 }
+
+// CreateExternalNameService - Service yang meneruskan traffic ke host di luar namespace
+// (e.g., backend API di namespace lain)
+func (k *K8sClient) CreateExternalNameService(ctx context.Context, namespace, serviceName, externalName string, port int) error {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: externalName,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(port),
+					TargetPort: intstr.FromInt(port),
+				},
+			},
+		},
+	}
+
+	_, err := k.clientset.CoreV1().Services(namespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("service %s already exists in namespace %s", serviceName, namespace)
+		}
+		return fmt.Errorf("failed to create service: %w", err)
+	}
+
+	return nil
+}
+
+// ServiceExists - Check if Service exists
+func (k *K8sClient) ServiceExists(ctx context.Context, namespace, name string) (bool, error) {
+	_, err := k.clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check service: %w", err)
+	}
+
+	return true, nil
+}
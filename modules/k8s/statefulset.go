@@ -0,0 +1,264 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StatefulSetConfig - Configuration untuk single-replica StatefulSet (e.g., database add-on)
+type StatefulSetConfig struct {
+	Name          string
+	Namespace     string
+	AppName       string // Label app
+	ServiceName   string // Service yang mengatur network identity pod
+	Image         string
+	ContainerPort int32
+	Args          []string
+
+	// Resource limits
+	CPURequest    string
+	CPULimit      string
+	MemoryRequest string
+	MemoryLimit   string
+
+	// Environment variables from Secret
+	SecretName string
+	EnvVars    []corev1.EnvVar
+
+	// PVC yang di-mount (PVC harus sudah dibuat)
+	Volumes []VolumeConfig
+
+	ReadinessProbe *ProbeConfig
+	LivenessProbe  *ProbeConfig
+}
+
+// StatefulSetStatus - Status StatefulSet
+type StatefulSetStatus struct {
+	Name          string `json:"name"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
+	IsReady       bool   `json:"isReady"`
+}
+
+// CreateStatefulSet - Create StatefulSet di K8s
+func (k *K8sClient) CreateStatefulSet(ctx context.Context, config *StatefulSetConfig) error {
+	statefulSet, err := buildStatefulSet(config)
+	if err != nil {
+		return err
+	}
+
+	_, err = k.clientset.AppsV1().StatefulSets(config.Namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("statefulset %s already exists in namespace %s", config.Name, config.Namespace)
+		}
+		return fmt.Errorf("failed to create statefulset: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatefulSetStatus - Get status StatefulSet (ready replicas).
+// StatefulSet yang tidak ada bisa dicek dengan IsNotFound.
+func (k *K8sClient) GetStatefulSetStatus(ctx context.Context, namespace, name string) (*StatefulSetStatus, error) {
+	statefulSet, err := k.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("statefulset %s not found in namespace %s: %w", name, namespace, err)
+		}
+		return nil, fmt.Errorf("failed to get statefulset: %w", err)
+	}
+
+	desired := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		desired = *statefulSet.Spec.Replicas
+	}
+
+	return &StatefulSetStatus{
+		Name:          statefulSet.Name,
+		Replicas:      desired,
+		ReadyReplicas: statefulSet.Status.ReadyReplicas,
+		IsReady:       statefulSet.Status.ReadyReplicas >= desired,
+	}, nil
+}
+
+// ScaleStatefulSet - Scale replicas (0 = stop tanpa menghapus PVC)
+func (k *K8sClient) ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int32) error {
+	statefulSets := k.clientset.AppsV1().StatefulSets(namespace)
+
+	statefulSet, err := statefulSets.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("statefulset %s not found in namespace %s", name, namespace)
+		}
+		return fmt.Errorf("failed to get statefulset: %w", err)
+	}
+
+	statefulSet.Spec.Replicas = &replicas
+	if _, err := statefulSets.Update(ctx, statefulSet, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update statefulset: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStatefulSet - Delete StatefulSet (PVC tidak ikut terhapus)
+func (k *K8sClient) DeleteStatefulSet(ctx context.Context, namespace, name string) error {
+	err := k.clientset.AppsV1().StatefulSets(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("statefulset %s not found in namespace %s", name, namespace)
+		}
+		return fmt.Errorf("failed to delete statefulset: %w", err)
+	}
+
+	return nil
+}
+
+// WaitForStatefulSetReady - Polling sampai semua replica ready.
+// Return error kalau pod CrashLoopBackOff / ImagePullBackOff.
+func (k *K8sClient) WaitForStatefulSetReady(ctx context.Context, namespace, name string, timeoutSeconds int) error {
+	timeout := defaultWaitTimeout
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		statefulSet, err := k.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return waitError(ctx, name)
+			}
+			return fmt.Errorf("failed to get statefulset: %w", err)
+		}
+
+		desired := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			desired = *statefulSet.Spec.Replicas
+		}
+		if statefulSet.Generation <= statefulSet.Status.ObservedGeneration && statefulSet.Status.ReadyReplicas >= desired {
+			return nil
+		}
+
+		if err := k.checkStatefulSetPods(ctx, statefulSet); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return waitError(ctx, name)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *K8sClient) checkStatefulSetPods(ctx context.Context, statefulSet *appsv1.StatefulSet) error {
+	selector, err := metav1.LabelSelectorAsSelector(statefulSet.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid statefulset selector: %w", err)
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(statefulSet.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil
+	}
+
+	for _, pod := range pods.Items {
+		if err := containerFailure(pod.Name, pod.Status.ContainerStatuses); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func buildStatefulSet(config *StatefulSetConfig) (*appsv1.StatefulSet, error) {
+	if config.Name == "" || config.Namespace == "" || config.Image == "" || config.ServiceName == "" {
+		return nil, fmt.Errorf("name, namespace, image, and service name are required")
+	}
+
+	resources, err := parseResourceRequirements(config.CPURequest, config.MemoryRequest, config.CPULimit, config.MemoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := int32(1)
+	labels := map[string]string{
+		"app": config.AppName,
+	}
+
+	container := corev1.Container{
+		Name:  config.AppName,
+		Image: config.Image,
+		Args:  config.Args,
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "tcp",
+				ContainerPort: config.ContainerPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		Env:       config.EnvVars,
+		Resources: resources,
+	}
+	if config.SecretName != "" {
+		container.EnvFrom = []corev1.EnvFromSource{
+			{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: config.SecretName,
+					},
+				},
+			},
+		}
+	}
+
+	if container.ReadinessProbe, err = buildProbe(config.ReadinessProbe, config.ContainerPort); err != nil {
+		return nil, err
+	}
+	if container.LivenessProbe, err = buildProbe(config.LivenessProbe, config.ContainerPort); err != nil {
+		return nil, err
+	}
+
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      config.Name,
+			Namespace: config.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: config.ServiceName,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers:    []corev1.Container{container},
+					RestartPolicy: corev1.RestartPolicyAlways,
+				},
+			},
+		},
+	}
+
+	addVolumes(&statefulSet.Spec.Template.Spec, config.Volumes)
+
+	return statefulSet, nil
+}
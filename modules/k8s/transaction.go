@@ -0,0 +1,180 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Transaction - Catat setiap resource yang berhasil dibuat supaya bisa di-rollback
+// (compensation) dengan urutan terbalik kalau ada step yang gagal.
+//
+//	tx := k8sClient.BeginTransaction()
+//	if err := tx.CreateConfigMap(ctx, ns, name, data); err != nil {
+//		return tx.Rollback(ctx, err)
+//	}
+//	tx.Commit()
+type Transaction struct {
+	k     *K8sClient
+	steps []TransactionStep
+}
+
+// TransactionStep - Resource yang sudah dibuat beserta cara menghapusnya
+type TransactionStep struct {
+	Kind      string
+	Namespace string
+	Name      string
+	undo      func(ctx context.Context) error
+}
+
+// RollbackError - Error asli beserta error yang terjadi saat cleanup
+type RollbackError struct {
+	Cause         error
+	CleanupErrors []error
+}
+
+func (e *RollbackError) Error() string {
+	if len(e.CleanupErrors) == 0 {
+		return fmt.Sprintf("%v (rolled back)", e.Cause)
+	}
+
+	msgs := make([]string, 0, len(e.CleanupErrors))
+	for _, err := range e.CleanupErrors {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%v (rollback failed: %s)", e.Cause, strings.Join(msgs, "; "))
+}
+
+func (e *RollbackError) Unwrap() []error {
+	return append([]error{e.Cause}, e.CleanupErrors...)
+}
+
+// BeginTransaction - Mulai transaction baru
+func (k *K8sClient) BeginTransaction() *Transaction {
+	return &Transaction{k: k}
+}
+
+// Steps - Resource yang sudah tercatat, urut sesuai waktu dibuat
+func (t *Transaction) Steps() []TransactionStep {
+	return t.steps
+}
+
+func (t *Transaction) record(kind, namespace, name string, undo func(ctx context.Context) error) {
+	t.steps = append(t.steps, TransactionStep{Kind: kind, Namespace: namespace, Name: name, undo: undo})
+}
+
+// Commit - Anggap semua step sukses, rollback tidak akan menghapus apa-apa lagi
+func (t *Transaction) Commit() {
+	t.steps = nil
+}
+
+// Rollback - Jalankan Delete* untuk semua step dengan urutan terbalik.
+// Selalu return *RollbackError yang membungkus cause.
+func (t *Transaction) Rollback(ctx context.Context, cause error) error {
+	// Cleanup tetap jalan walaupun request context sudah di-cancel
+	ctx = context.WithoutCancel(ctx)
+
+	rollbackErr := &RollbackError{Cause: cause}
+	for i := len(t.steps) - 1; i >= 0; i-- {
+		step := t.steps[i]
+		if err := step.undo(ctx); err != nil {
+			rollbackErr.CleanupErrors = append(rollbackErr.CleanupErrors,
+				fmt.Errorf("delete %s %s/%s: %w", step.Kind, step.Namespace, step.Name, err))
+		}
+	}
+	t.steps = nil
+
+	return rollbackErr
+}
+
+func (t *Transaction) CreateNamespace(ctx context.Context, name string, labels map[string]string) error {
+	if err := t.k.CreateNamespace(ctx, name, labels); err != nil {
+		return err
+	}
+
+	t.record("namespace", "", name, func(ctx context.Context) error {
+		return t.k.DeleteNamespace(ctx, name)
+	})
+	return nil
+}
+
+func (t *Transaction) CreateConfigMap(ctx context.Context, namespace, name string, data map[string]string) error {
+	if err := t.k.CreateConfigMap(ctx, namespace, name, data); err != nil {
+		return err
+	}
+
+	t.record("configmap", namespace, name, func(ctx context.Context) error {
+		return t.k.DeleteConfigMap(ctx, namespace, name)
+	})
+	return nil
+}
+
+func (t *Transaction) CreateSecretFromStringData(ctx context.Context, namespace, name string, data map[string]string) error {
+	if err := t.k.CreateSecretFromStringData(ctx, namespace, name, data); err != nil {
+		return err
+	}
+
+	t.record("secret", namespace, name, func(ctx context.Context) error {
+		return t.k.DeleteSecret(ctx, namespace, name)
+	})
+	return nil
+}
+
+func (t *Transaction) CreateDeployment(ctx context.Context, config *DeploymentConfig) error {
+	if err := t.k.CreateDeployment(ctx, config); err != nil {
+		return err
+	}
+
+	namespace, name := config.Namespace, config.Name
+	t.record("deployment", namespace, name, func(ctx context.Context) error {
+		return t.k.DeleteDeployment(ctx, namespace, name)
+	})
+	return nil
+}
+
+func (t *Transaction) CreateService(ctx context.Context, namespace, serviceName, appName string, port, targetPort int) error {
+	if err := t.k.CreateService(ctx, namespace, serviceName, appName, port, targetPort); err != nil {
+		return err
+	}
+
+	t.record("service", namespace, serviceName, func(ctx context.Context) error {
+		return t.k.DeleteService(ctx, namespace, serviceName)
+	})
+	return nil
+}
+
+func (t *Transaction) CreateIngress(ctx context.Context, config *IngressConfig) error {
+	if err := t.k.CreateIngress(ctx, config); err != nil {
+		return err
+	}
+
+	namespace, name := config.Namespace, config.Name
+	t.record("ingress", namespace, name, func(ctx context.Context) error {
+		return t.k.DeleteIngress(ctx, namespace, name)
+	})
+	return nil
+}
+
+func (t *Transaction) CreatePersistentVolumeClaim(ctx context.Context, namespace string, volume VolumeConfig, labels map[string]string) error {
+	if err := t.k.CreatePersistentVolumeClaim(ctx, namespace, volume, labels); err != nil {
+		return err
+	}
+
+	name := volume.ClaimName
+	t.record("pvc", namespace, name, func(ctx context.Context) error {
+		return t.k.DeletePersistentVolumeClaim(ctx, namespace, name)
+	})
+	return nil
+}
+
+func (t *Transaction) CreateStatefulSet(ctx context.Context, config *StatefulSetConfig) error {
+	if err := t.k.CreateStatefulSet(ctx, config); err != nil {
+		return err
+	}
+
+	namespace, name := config.Namespace, config.Name
+	t.record("statefulset", namespace, name, func(ctx context.Context) error {
+		return t.k.DeleteStatefulSet(ctx, namespace, name)
+	})
+	return nil
+}
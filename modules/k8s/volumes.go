@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeConfig - PersistentVolumeClaim yang di-mount ke container
+type VolumeConfig struct {
+	Name         string  // Nama volume di pod spec
+	ClaimName    string  // Nama PVC
+	MountPath    string  // e.g., "/data"
+	Size         string  // e.g., "1Gi"
+	AccessMode   string  // default "ReadWriteOnce"
+	StorageClass *string // nil = default storage class
+}
+
+func (v VolumeConfig) accessMode() corev1.PersistentVolumeAccessMode {
+	if v.AccessMode == "" {
+		return corev1.ReadWriteOnce
+	}
+	return corev1.PersistentVolumeAccessMode(v.AccessMode)
+}
+
+// CreatePersistentVolumeClaim - Create PVC di namespace
+func (k *K8sClient) CreatePersistentVolumeClaim(ctx context.Context, namespace string, volume VolumeConfig, labels map[string]string) error {
+	size, err := resource.ParseQuantity(volume.Size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q for volume %s: %w", volume.Size, volume.Name, err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      volume.ClaimName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{volume.accessMode()},
+			StorageClassName: volume.StorageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+
+	_, err = k.clientset.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return fmt.Errorf("pvc %s already exists in namespace %s", volume.ClaimName, namespace)
+		}
+		return fmt.Errorf("failed to create pvc: %w", err)
+	}
+
+	return nil
+}
+
+// PersistentVolumeClaimExists - Check if PVC exists
+func (k *K8sClient) PersistentVolumeClaimExists(ctx context.Context, namespace, name string) (bool, error) {
+	_, err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check pvc: %w", err)
+	}
+
+	return true, nil
+}
+
+// DeletePersistentVolumeClaim - Delete PVC (data ikut hilang kalau reclaim policy Delete)
+func (k *K8sClient) DeletePersistentVolumeClaim(ctx context.Context, namespace, name string) error {
+	err := k.clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("pvc %s not found in namespace %s", name, namespace)
+		}
+		return fmt.Errorf("failed to delete pvc: %w", err)
+	}
+
+	return nil
+}
+
+// addVolumes - Mount PVC ke container pertama. Volume ReadWriteOnce tidak bisa
+// di-attach ke dua pod di node berbeda, jadi rolling update diganti Recreate.
+func addVolumes(spec *corev1.PodSpec, volumes []VolumeConfig) bool {
+	exclusive := false
+	for _, volume := range volumes {
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: volume.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: volume.ClaimName,
+				},
+			},
+		})
+		spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+			ReadOnly:  volume.accessMode() == corev1.ReadOnlyMany,
+		})
+
+		if mode := volume.accessMode(); mode == corev1.ReadWriteOnce || mode == corev1.ReadWriteOncePod {
+			exclusive = true
+		}
+	}
+
+	return exclusive
+}
@@ -0,0 +1,309 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var (
+	ErrWaitTimeout              = errors.New("timed out waiting for deployment to be ready")
+	ErrDeploymentDeleted        = errors.New("deployment was deleted while waiting")
+	ErrProgressDeadlineExceeded = errors.New("deployment exceeded its progress deadline")
+	ErrCrashLoopBackOff         = errors.New("pod is in CrashLoopBackOff")
+	ErrImagePullBackOff         = errors.New("pod failed to pull image")
+)
+
+const (
+	defaultWaitTimeout = 5 * time.Minute
+	waitPollInterval   = 2 * time.Second
+
+	// deploymentRevisionAnnotation - Diisi deployment controller di Deployment & ReplicaSet-nya
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// DeploymentProgress - Progress rollout yang dikirim ke ProgressFunc
+type DeploymentProgress struct {
+	Name              string
+	Namespace         string
+	DesiredReplicas   int32
+	UpdatedReplicas   int32
+	ReadyReplicas     int32
+	AvailableReplicas int32
+	IsReady           bool
+}
+
+// Message - e.g., "2/3 replicas ready"
+func (p DeploymentProgress) Message() string {
+	return fmt.Sprintf("%d/%d replicas ready", p.ReadyReplicas, p.DesiredReplicas)
+}
+
+// ProgressFunc - Dipanggil setiap kali jumlah replica berubah
+type ProgressFunc func(progress DeploymentProgress)
+
+// WaitForDeploymentReady - Wait until deployment is ready.
+// Pakai watch API; kalau watch expired atau gagal dibuat, fallback ke polling.
+// Return error kalau rollout gagal (ProgressDeadlineExceeded, CrashLoopBackOff, ImagePullBackOff).
+func (k *K8sClient) WaitForDeploymentReady(ctx context.Context, namespace, name string, timeoutSeconds int, onProgress ProgressFunc) error {
+	timeout := defaultWaitTimeout
+	if timeoutSeconds > 0 {
+		timeout = time.Duration(timeoutSeconds) * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var last *DeploymentProgress
+	check := func(deployment *appsv1.Deployment) (bool, error) {
+		progress := deploymentProgress(deployment)
+		if onProgress != nil && (last == nil || *last != progress) {
+			onProgress(progress)
+		}
+		last = &progress
+
+		if err := deploymentFailure(deployment); err != nil {
+			return false, err
+		}
+		if progress.IsReady {
+			return true, nil
+		}
+
+		return false, k.checkDeploymentPods(ctx, deployment)
+	}
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return waitError(ctx, name)
+			}
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
+
+		done, err := check(deployment)
+		if done || err != nil {
+			return err
+		}
+
+		watcher, err := k.clientset.AppsV1().Deployments(namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: deployment.ResourceVersion,
+		})
+		if err != nil {
+			// Watch tidak tersedia, fallback ke polling
+			select {
+			case <-ctx.Done():
+				return waitError(ctx, name)
+			case <-ticker.C:
+				continue
+			}
+		}
+
+		done, err = k.consumeDeploymentWatch(ctx, watcher, namespace, name, ticker, check)
+		watcher.Stop()
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// consumeDeploymentWatch - Proses event sampai deployment ready, gagal, atau watch expired.
+// Setiap tick juga poll deployment, karena status pod (CrashLoopBackOff, dll)
+// tidak selalu memicu event di deployment. Return (false, nil) kalau watch harus dibuat ulang.
+func (k *K8sClient) consumeDeploymentWatch(
+	ctx context.Context,
+	watcher watch.Interface,
+	namespace, name string,
+	ticker *time.Ticker,
+	check func(*appsv1.Deployment) (bool, error),
+) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, waitError(ctx, name)
+
+		case <-ticker.C:
+			deployment, err := k.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				if ctx.Err() != nil {
+					return false, waitError(ctx, name)
+				}
+				return false, fmt.Errorf("failed to get deployment: %w", err)
+			}
+
+			done, err := check(deployment)
+			if done || err != nil {
+				return done, err
+			}
+
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+
+			switch event.Type {
+			case watch.Error:
+				return false, nil
+			case watch.Deleted:
+				return false, fmt.Errorf("%w: %s", ErrDeploymentDeleted, name)
+			}
+
+			deployment, ok := event.Object.(*appsv1.Deployment)
+			if !ok || deployment.Name != name {
+				continue
+			}
+
+			done, err := check(deployment)
+			if done || err != nil {
+				return done, err
+			}
+		}
+	}
+}
+
+// checkDeploymentPods - Cari pod ReplicaSet baru yang stuck karena crash atau gagal pull image.
+// Pod dari ReplicaSet lama diabaikan, crash di versi lama sering jadi alasan update.
+func (k *K8sClient) checkDeploymentPods(ctx context.Context, deployment *appsv1.Deployment) error {
+	// Controller belum memproses spec terbaru, ReplicaSet baru belum ada
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid deployment selector: %w", err)
+	}
+
+	replicaSet, err := k.newReplicaSet(ctx, deployment, selector)
+	if err != nil || replicaSet == nil {
+		return err
+	}
+	hash, err := labels.NewRequirement(appsv1.DefaultDeploymentUniqueLabelKey, selection.Equals,
+		[]string{replicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey]})
+	if err != nil {
+		return fmt.Errorf("invalid pod-template-hash of replicaset %s: %w", replicaSet.Name, err)
+	}
+
+	pods, err := k.clientset.CoreV1().Pods(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.Add(*hash).String(),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return waitError(ctx, deployment.Name)
+		}
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if err := containerFailure(pod.Name, pod.Status.InitContainerStatuses); err != nil {
+			return err
+		}
+		if err := containerFailure(pod.Name, pod.Status.ContainerStatuses); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newReplicaSet - ReplicaSet milik deployment dengan revision yang sama dengan deployment,
+// nil kalau belum dibuat controller
+func (k *K8sClient) newReplicaSet(ctx context.Context, deployment *appsv1.Deployment, selector labels.Selector) (*appsv1.ReplicaSet, error) {
+	revision := deployment.Annotations[deploymentRevisionAnnotation]
+	if revision == "" {
+		return nil, nil
+	}
+
+	replicaSets, err := k.clientset.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, waitError(ctx, deployment.Name)
+		}
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		if metav1.IsControlledBy(replicaSet, deployment) && replicaSet.Annotations[deploymentRevisionAnnotation] == revision {
+			return replicaSet, nil
+		}
+	}
+	return nil, nil
+}
+
+func containerFailure(podName string, statuses []corev1.ContainerStatus) error {
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+
+		waiting := status.State.Waiting
+		switch waiting.Reason {
+		case "CrashLoopBackOff":
+			return fmt.Errorf("%w: %s/%s: %s", ErrCrashLoopBackOff, podName, status.Name, waiting.Message)
+		case "ImagePullBackOff", "ErrImagePull", "InvalidImageName":
+			return fmt.Errorf("%w: %s/%s: %s", ErrImagePullBackOff, podName, status.Name, waiting.Message)
+		}
+	}
+
+	return nil
+}
+
+// deploymentFailure - Check condition Progressing=False (ProgressDeadlineExceeded)
+func deploymentFailure(deployment *appsv1.Deployment) error {
+	for _, cond := range deployment.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return fmt.Errorf("%w: %s", ErrProgressDeadlineExceeded, cond.Message)
+		}
+	}
+
+	return nil
+}
+
+func deploymentProgress(deployment *appsv1.Deployment) DeploymentProgress {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+
+	status := deployment.Status
+	progress := DeploymentProgress{
+		Name:              deployment.Name,
+		Namespace:         deployment.Namespace,
+		DesiredReplicas:   desired,
+		UpdatedReplicas:   status.UpdatedReplicas,
+		ReadyReplicas:     status.ReadyReplicas,
+		AvailableReplicas: status.AvailableReplicas,
+	}
+
+	// Sama seperti `kubectl rollout status`: controller sudah lihat spec terbaru,
+	// semua replica sudah versi baru, tidak ada replica lama, dan semuanya available
+	progress.IsReady = deployment.Generation <= status.ObservedGeneration &&
+		status.UpdatedReplicas >= desired &&
+		status.Replicas == status.UpdatedReplicas &&
+		status.ReadyReplicas >= desired &&
+		status.AvailableReplicas >= desired
+
+	return progress
+}
+
+func waitError(ctx context.Context, name string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %s", ErrWaitTimeout, name)
+	}
+	return ctx.Err()
+}
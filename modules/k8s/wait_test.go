@@ -0,0 +1,201 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testNamespace = "user1-workspaces"
+
+func testDeployment(ready bool) *appsv1.Deployment {
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shop-deployment",
+			Namespace:   testNamespace,
+			UID:         types.UID("deployment-uid"),
+			Generation:  2,
+			Annotations: map[string]string{deploymentRevisionAnnotation: "2"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "shop"}},
+		},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           3,
+			UpdatedReplicas:    1,
+			ReadyReplicas:      2,
+			AvailableReplicas:  2,
+		},
+	}
+	if ready {
+		deployment.Status.Replicas = 2
+		deployment.Status.UpdatedReplicas = 2
+	}
+	return deployment
+}
+
+func testReplicaSet(deployment *appsv1.Deployment, revision, hash string) *appsv1.ReplicaSet {
+	controller := true
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "shop-deployment-" + hash,
+			Namespace:   testNamespace,
+			Labels:      map[string]string{"app": "shop", appsv1.DefaultDeploymentUniqueLabelKey: hash},
+			Annotations: map[string]string{deploymentRevisionAnnotation: revision},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       deployment.Name,
+				UID:        deployment.UID,
+				Controller: &controller,
+			}},
+		},
+	}
+}
+
+func testPod(name, hash, waitingReason string) *corev1.Pod {
+	status := corev1.ContainerStatus{Name: "shop", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+	if waitingReason != "" {
+		status.State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waitingReason}}
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testNamespace,
+			Labels:    map[string]string{"app": "shop", appsv1.DefaultDeploymentUniqueLabelKey: hash},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{status}},
+	}
+}
+
+func testStalledDeployment() *appsv1.Deployment {
+	deployment := testDeployment(false)
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: `ReplicaSet "shop-deployment-new" has timed out progressing.`,
+	}}
+	return deployment
+}
+
+func TestWaitForDeploymentReady(t *testing.T) {
+	notReady := testDeployment(false)
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantErr error
+	}{
+		{
+			name:    "ready",
+			objects: []runtime.Object{testDeployment(true)},
+		},
+		{
+			name: "timeout",
+			objects: []runtime.Object{
+				notReady,
+				testReplicaSet(notReady, "2", "new"),
+				testPod("shop-new-1", "new", "ContainerCreating"),
+			},
+			wantErr: ErrWaitTimeout,
+		},
+		{
+			name: "crashloop in new replicaset",
+			objects: []runtime.Object{
+				notReady,
+				testReplicaSet(notReady, "1", "old"),
+				testReplicaSet(notReady, "2", "new"),
+				testPod("shop-old-1", "old", ""),
+				testPod("shop-new-1", "new", "CrashLoopBackOff"),
+			},
+			wantErr: ErrCrashLoopBackOff,
+		},
+		{
+			name: "crashloop in old replicaset is ignored",
+			objects: []runtime.Object{
+				notReady,
+				testReplicaSet(notReady, "1", "old"),
+				testReplicaSet(notReady, "2", "new"),
+				testPod("shop-old-1", "old", "CrashLoopBackOff"),
+				testPod("shop-new-1", "new", ""),
+			},
+			wantErr: ErrWaitTimeout,
+		},
+		{
+			name:    "progress deadline exceeded",
+			objects: []runtime.Object{testStalledDeployment()},
+			wantErr: ErrProgressDeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewK8sClientFromClientset(fake.NewClientset(tt.objects...))
+
+			err := client.WaitForDeploymentReady(context.Background(), testNamespace, "shop-deployment", 1, nil)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected ready, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWaitForDeploymentReadyProgress(t *testing.T) {
+	deployment := testDeployment(false)
+	deployment.Status.ReadyReplicas = 1
+	deployment.Status.AvailableReplicas = 1
+	clientset := fake.NewClientset(deployment, testReplicaSet(deployment, "2", "new"))
+	client := NewK8sClientFromClientset(clientset)
+
+	var progress []DeploymentProgress
+	err := client.WaitForDeploymentReady(context.Background(), testNamespace, "shop-deployment", 10, func(p DeploymentProgress) {
+		progress = append(progress, p)
+		if len(progress) != 1 {
+			return
+		}
+
+		// Rollout selesai setelah progress pertama terkirim
+		go func() {
+			ready := testDeployment(true)
+			ready.ResourceVersion = ""
+			if _, err := clientset.AppsV1().Deployments(testNamespace).UpdateStatus(context.Background(), ready, metav1.UpdateOptions{}); err != nil {
+				t.Errorf("failed to update deployment: %v", err)
+			}
+		}()
+	})
+	if err != nil {
+		t.Fatalf("expected ready, got %v", err)
+	}
+
+	if len(progress) < 2 {
+		t.Fatalf("expected at least 2 progress events, got %d", len(progress))
+	}
+	if first := progress[0]; first.IsReady || first.Message() != "1/2 replicas ready" {
+		t.Fatalf("unexpected first progress %+v", first)
+	}
+	if last := progress[len(progress)-1]; !last.IsReady || last.Message() != "2/2 replicas ready" {
+		t.Fatalf("unexpected last progress %+v", last)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] == progress[i-1] {
+			t.Fatalf("progress %d was reported twice: %+v", i, progress[i])
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidAnnotation = errors.New("ingress annotation is invalid")
+
+const nginxAnnotationPrefix = "nginx.ingress.kubernetes.io/"
+
+// allowedIngressAnnotations - Annotation ingress-nginx yang hanya mengatur proxy / routing.
+// Annotation lain (snippet, auth-url, mirror, dll) bisa menyisipkan config nginx atau
+// mengarahkan traffic ke luar workspace, jadi tidak bisa dipasang lewat template.
+var allowedIngressAnnotations = map[string]bool{
+	"proxy-body-size":         true,
+	"proxy-read-timeout":      true,
+	"proxy-send-timeout":      true,
+	"proxy-connect-timeout":   true,
+	"proxy-buffering":         true,
+	"proxy-buffer-size":       true,
+	"proxy-request-buffering": true,
+	"proxy-http-version":      true,
+	"rewrite-target":          true,
+	"use-regex":               true,
+	"ssl-redirect":            true,
+	"force-ssl-redirect":      true,
+	"backend-protocol":        true,
+	"enable-cors":             true,
+	"cors-allow-origin":       true,
+	"cors-allow-methods":      true,
+	"cors-allow-headers":      true,
+	"cors-allow-credentials":  true,
+	"cors-max-age":            true,
+	"limit-rps":               true,
+	"limit-rpm":               true,
+	"limit-connections":       true,
+}
+
+// IsAllowedIngressAnnotation - Dipakai juga saat Ingress dibuat, supaya template lama
+// yang tersimpan sebelum allowlist ada tidak ikut memasang annotation lain
+func IsAllowedIngressAnnotation(key string) bool {
+	name, ok := strings.CutPrefix(key, nginxAnnotationPrefix)
+	if !ok || strings.HasSuffix(name, "-snippet") {
+		return false
+	}
+	return allowedIngressAnnotations[name]
+}
+
+// validateIngressAnnotations - Key harus ada di allowlist (e.g., "nginx.ingress.kubernetes.io/proxy-body-size").
+// Value "" menghapus annotation default dari config.
+func validateIngressAnnotations(annotations map[string]string) error {
+	for key := range annotations {
+		if strings.HasSuffix(key, "-snippet") {
+			return fmt.Errorf("%w: %s: snippet annotations are not allowed", ErrInvalidAnnotation, key)
+		}
+		if !IsAllowedIngressAnnotation(key) {
+			return fmt.Errorf("%w: %s is not allowed", ErrInvalidAnnotation, key)
+		}
+	}
+
+	return nil
+}
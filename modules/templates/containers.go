@@ -0,0 +1,122 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var (
+	ErrInvalidContainer = errors.New("container config is invalid")
+
+	envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Container - Container tambahan di pod workspace: sidecar (jalan bersama app) atau
+// init container (jalan berurutan sebelum app start, e.g., migration)
+type Container struct {
+	Name    string            `json:"name"`
+	Image   string            `json:"image"`
+	Command []string          `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
+	// Ikut membaca ConfigMap & Secret workspace (env vars app, credentials database)
+	InheritEnv bool `json:"inheritEnv,omitempty"`
+
+	// Kosong = default LimitRange namespace
+	CPURequest    string `json:"cpuRequest,omitempty"`
+	CPULimit      string `json:"cpuLimit,omitempty"`
+	MemoryRequest string `json:"memoryRequest,omitempty"`
+	MemoryLimit   string `json:"memoryLimit,omitempty"`
+
+	// Volume template yang di-share dengan app
+	VolumeMounts []VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+type VolumeMount struct {
+	Volume    string `json:"volume"` // Volume.Name
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// validateContainers - Nama unik di semua sidecar & init container, volume harus ada di template
+func validateContainers(sidecars, initContainers []Container, volumes []Volume) error {
+	declared := map[string]bool{}
+	for _, volume := range volumes {
+		declared[volume.Name] = true
+	}
+
+	names := map[string]bool{}
+	groups := []struct {
+		kind       string
+		containers []Container
+	}{
+		{"init container", initContainers},
+		{"sidecar", sidecars},
+	}
+	for _, group := range groups {
+		for _, container := range group.containers {
+			if err := validateContainer(container, declared); err != nil {
+				return fmt.Errorf("%w: %s: %s", ErrInvalidContainer, group.kind, err.Error())
+			}
+
+			if names[container.Name] {
+				return fmt.Errorf("%w: duplicate container name %s", ErrInvalidContainer, container.Name)
+			}
+			names[container.Name] = true
+		}
+	}
+
+	return nil
+}
+
+func validateContainer(container Container, volumes map[string]bool) error {
+	if len(container.Name) > 40 || !volumeNameRegex.MatchString(container.Name) {
+		return fmt.Errorf("name %q must be a DNS label of at most 40 characters", container.Name)
+	}
+
+	if container.Image == "" {
+		return fmt.Errorf("image of %s is required", container.Name)
+	}
+
+	for key := range container.Env {
+		if !envNameRegex.MatchString(key) {
+			return fmt.Errorf("env %q of %s is invalid", key, container.Name)
+		}
+	}
+
+	quantities := map[string]string{
+		"cpuRequest":    container.CPURequest,
+		"cpuLimit":      container.CPULimit,
+		"memoryRequest": container.MemoryRequest,
+		"memoryLimit":   container.MemoryLimit,
+	}
+	for field, value := range quantities {
+		if value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return fmt.Errorf("%s %q of %s is invalid", field, value, container.Name)
+		}
+	}
+
+	mountPaths := map[string]bool{}
+	for _, mount := range container.VolumeMounts {
+		if !volumes[mount.Volume] {
+			return fmt.Errorf("volume %s of %s is not declared in template volumes", mount.Volume, container.Name)
+		}
+		if !path.IsAbs(mount.MountPath) || path.Clean(mount.MountPath) == "/" {
+			return fmt.Errorf("mountPath %q of %s must be an absolute path other than /", mount.MountPath, container.Name)
+		}
+		if mountPaths[mount.MountPath] {
+			return fmt.Errorf("duplicate mount path %s in %s", mount.MountPath, container.Name)
+		}
+		mountPaths[mount.MountPath] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidGitRepo = errors.New("git repository url is invalid")
+
+// gitRepoSchemes - Builder meng-clone repo tanpa credentials, jadi hanya URL publik
+// (https/http/git) yang bisa di-build. URL ssh (ssh://, git@host:repo) ditolak di sini
+// supaya tidak baru gagal saat build.
+var gitRepoSchemes = map[string]bool{
+	"https": true,
+	"http":  true,
+	"git":   true,
+}
+
+func validateGitRepoURL(repoURL string) error {
+	parsed, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil || parsed.Host == "" || parsed.Path == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidGitRepo, repoURL)
+	}
+	if !gitRepoSchemes[parsed.Scheme] {
+		return fmt.Errorf("%w: scheme %q is not supported, use https, http or git", ErrInvalidGitRepo, parsed.Scheme)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -26,6 +27,9 @@ func (h Handler) Create(c *fiber.Ctx) error {
 
 	err := h.s.Create(c.Context(), req)
 	if err != nil {
+		if errors.Is(err, ErrInvalidProbe) || errors.Is(err, ErrInvalidVolume) || errors.Is(err, ErrInvalidAnnotation) || errors.Is(err, ErrInvalidContainer) || errors.Is(err, ErrInvalidGitRepo) {
+			return response.Error(c, http.StatusBadRequest, err.Error())
+		}
 		return response.Error(c, http.StatusInternalServerError, err.Error())
 	}
 
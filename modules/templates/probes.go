@@ -0,0 +1,151 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	ProbeHTTP = "http"
+	ProbeTCP  = "tcp"
+	ProbeExec = "exec"
+)
+
+var ErrInvalidProbe = errors.New("probe config is invalid")
+
+// Probe - Definisi health check container. Field 0 / kosong memakai default Kubernetes,
+// kecuali Port (default: Template.DefaultPort) dan Path (default: "/").
+type Probe struct {
+	Type    string   `json:"type"`
+	Path    string   `json:"path,omitempty"`
+	Port    int      `json:"port,omitempty"`
+	Command []string `json:"command,omitempty"`
+
+	InitialDelaySeconds int `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      int `json:"timeoutSeconds,omitempty"`
+	SuccessThreshold    int `json:"successThreshold,omitempty"`
+	FailureThreshold    int `json:"failureThreshold,omitempty"`
+}
+
+// Probes - nil probe = tidak dipasang
+type Probes struct {
+	Liveness  *Probe `json:"liveness,omitempty"`
+	Readiness *Probe `json:"readiness,omitempty"`
+	Startup   *Probe `json:"startup,omitempty"`
+}
+
+// DefaultProbes - TCP check ke port aplikasi. Startup probe memberi waktu boot
+// sampai 5 menit sebelum liveness mulai jalan.
+func DefaultProbes(port int) Probes {
+	return Probes{
+		Startup: &Probe{
+			Type:             ProbeTCP,
+			Port:             port,
+			PeriodSeconds:    5,
+			FailureThreshold: 60,
+		},
+		Readiness: &Probe{
+			Type:             ProbeTCP,
+			Port:             port,
+			PeriodSeconds:    5,
+			FailureThreshold: 3,
+		},
+		Liveness: &Probe{
+			Type:             ProbeTCP,
+			Port:             port,
+			PeriodSeconds:    10,
+			FailureThreshold: 3,
+		},
+	}
+}
+
+// ResolvedProbes - Probes template dengan port/path kosong diisi dari DefaultPort.
+// Template tanpa probes memakai DefaultProbes.
+func (t *Template) ResolvedProbes() Probes {
+	port := t.DefaultPort
+	if port <= 0 {
+		port = 8080
+	}
+
+	if t.Probes == nil {
+		return DefaultProbes(port)
+	}
+
+	return Probes{
+		Liveness:  resolveProbe(t.Probes.Liveness, port),
+		Readiness: resolveProbe(t.Probes.Readiness, port),
+		Startup:   resolveProbe(t.Probes.Startup, port),
+	}
+}
+
+func resolveProbe(probe *Probe, port int) *Probe {
+	if probe == nil {
+		return nil
+	}
+
+	resolved := *probe
+	if resolved.Port <= 0 {
+		resolved.Port = port
+	}
+	if resolved.Type == ProbeHTTP && resolved.Path == "" {
+		resolved.Path = "/"
+	}
+	return &resolved
+}
+
+func validateProbes(probes *Probes) error {
+	if probes == nil {
+		return nil
+	}
+
+	all := map[string]*Probe{
+		"liveness":  probes.Liveness,
+		"readiness": probes.Readiness,
+		"startup":   probes.Startup,
+	}
+	for name, probe := range all {
+		if probe == nil {
+			continue
+		}
+		if err := validateProbe(probe); err != nil {
+			return fmt.Errorf("%w: %s %s", ErrInvalidProbe, name, err.Error())
+		}
+		// Kubernetes mewajibkan successThreshold = 1 untuk liveness & startup
+		if name != "readiness" && probe.SuccessThreshold > 1 {
+			return fmt.Errorf("%w: %s successThreshold must be 1", ErrInvalidProbe, name)
+		}
+	}
+
+	return nil
+}
+
+func validateProbe(probe *Probe) error {
+	switch probe.Type {
+	case ProbeHTTP, ProbeTCP:
+		if probe.Port < 0 || probe.Port > 65535 {
+			return fmt.Errorf("port %d is out of range", probe.Port)
+		}
+	case ProbeExec:
+		if len(probe.Command) == 0 {
+			return fmt.Errorf("exec probe requires a command")
+		}
+	default:
+		return fmt.Errorf("type must be one of %s, %s, %s", ProbeHTTP, ProbeTCP, ProbeExec)
+	}
+
+	values := []int{
+		probe.InitialDelaySeconds,
+		probe.PeriodSeconds,
+		probe.TimeoutSeconds,
+		probe.SuccessThreshold,
+		probe.FailureThreshold,
+	}
+	for _, value := range values {
+		if value < 0 {
+			return fmt.Errorf("delays and thresholds cannot be negative")
+		}
+	}
+
+	return nil
+}
@@ -26,11 +26,17 @@ var (
 			features,
 			icon_url,
 			is_active,
-			is_featured
+			is_featured,
+			default_autoscaling,
+			probes,
+			volumes,
+			ingress_annotations,
+			sidecars,
+			init_containers
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
 			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
-			$21, $22, $23, $24
+			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30
 		) RETURNING id, created_at, updated_at
 	`
 	queryListWithCursor = `SELECT
@@ -54,7 +60,9 @@ var (
 			default_cpu_request, default_cpu_limit, default_memory_request, default_memory_limit, default_replicas,
 			requires_database, default_database_type, requires_redis, requires_rabbitmq, default_port,
 			env_vars_schema, tags, features, icon_url, screenshot_urls,
-			is_active, is_featured, created_at, updated_at
+			is_active, is_featured, created_at, updated_at,
+			default_autoscaling, probes, volumes, ingress_annotations,
+			sidecars, init_containers
 		FROM templates
 		WHERE id = $1 AND deleted_at IS NULL
 	`
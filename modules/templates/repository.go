@@ -34,6 +34,58 @@ func (r *Repository) Create(c context.Context, req CreateTemplateRequest) error
 		features = []string{}
 	}
 
+	var autoscalingJSON []byte
+	if req.DefaultAutoscaling != nil {
+		autoscalingJSON, err = json.Marshal(req.DefaultAutoscaling)
+		if err != nil {
+			return fmt.Errorf("failed to marshal default autoscaling: %w", err)
+		}
+	}
+
+	var probesJSON []byte
+	if req.Probes != nil {
+		probesJSON, err = json.Marshal(req.Probes)
+		if err != nil {
+			return fmt.Errorf("failed to marshal probes: %w", err)
+		}
+	}
+
+	volumes := req.Volumes
+	if volumes == nil {
+		volumes = []Volume{}
+	}
+	volumesJSON, err := json.Marshal(volumes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal volumes: %w", err)
+	}
+
+	annotations := req.IngressAnnotations
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotationsJSON, err := json.Marshal(annotations)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ingress annotations: %w", err)
+	}
+
+	sidecars := req.Sidecars
+	if sidecars == nil {
+		sidecars = []Container{}
+	}
+	sidecarsJSON, err := json.Marshal(sidecars)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sidecars: %w", err)
+	}
+
+	initContainers := req.InitContainers
+	if initContainers == nil {
+		initContainers = []Container{}
+	}
+	initContainersJSON, err := json.Marshal(initContainers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal init containers: %w", err)
+	}
+
 	// Execute query
 	var id int64
 	var createdAt, updatedAt sql.NullTime
@@ -65,6 +117,12 @@ func (r *Repository) Create(c context.Context, req CreateTemplateRequest) error
 		req.IconURL,
 		true,
 		false,
+		autoscalingJSON,
+		probesJSON,
+		volumesJSON,
+		annotationsJSON,
+		sidecarsJSON,
+		initContainersJSON,
 	).Scan(&id, &createdAt, &updatedAt)
 
 	if err != nil {
@@ -167,6 +225,8 @@ func (r *Repository) FindById(c context.Context, ID int) (*Template, error) {
 	var envVarsJSON []byte
 	var tagsArray, featuresArray []string
 	var screenshotURLsArray []string
+	var autoscalingJSON, probesJSON, volumesJSON, annotationsJSON []byte
+	var sidecarsJSON, initContainersJSON []byte
 
 	err := r.DB.QueryRowContext(c, queryGetByID, ID).Scan(
 		&data.Id,
@@ -198,6 +258,12 @@ func (r *Repository) FindById(c context.Context, ID int) (*Template, error) {
 		&data.IsFeatured,
 		&data.CreatedAt,
 		&data.UpdatedAt,
+		&autoscalingJSON,
+		&probesJSON,
+		&volumesJSON,
+		&annotationsJSON,
+		&sidecarsJSON,
+		&initContainersJSON,
 	)
 
 	if err != nil {
@@ -211,6 +277,36 @@ func (r *Repository) FindById(c context.Context, ID int) (*Template, error) {
 			return nil, fmt.Errorf("failed to unmarshal env_vars_schema: %w", err)
 		}
 	}
+	if len(autoscalingJSON) > 0 {
+		if err := json.Unmarshal(autoscalingJSON, &data.DefaultAutoscaling); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal default_autoscaling: %w", err)
+		}
+	}
+	if len(probesJSON) > 0 {
+		if err := json.Unmarshal(probesJSON, &data.Probes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal probes: %w", err)
+		}
+	}
+	if len(volumesJSON) > 0 {
+		if err := json.Unmarshal(volumesJSON, &data.Volumes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal volumes: %w", err)
+		}
+	}
+	if len(annotationsJSON) > 0 {
+		if err := json.Unmarshal(annotationsJSON, &data.IngressAnnotations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal ingress_annotations: %w", err)
+		}
+	}
+	if len(sidecarsJSON) > 0 {
+		if err := json.Unmarshal(sidecarsJSON, &data.Sidecars); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal sidecars: %w", err)
+		}
+	}
+	if len(initContainersJSON) > 0 {
+		if err := json.Unmarshal(initContainersJSON, &data.InitContainers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal init_containers: %w", err)
+		}
+	}
 
 	return &data, nil
 }
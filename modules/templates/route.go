@@ -4,15 +4,19 @@ import (
 	"database/sql"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/wafi11/backend-workspaces/modules/auth"
+	"github.com/wafi11/backend-workspaces/pkg/config"
 )
 
-func NewTemplates(db *sql.DB, app fiber.Router) {
+func NewTemplates(db *sql.DB, cfg config.Config, app fiber.Router) {
 	repo := NewRepository(db)
 	service := NewService(repo)
 	handler := NewHandler(service)
 
 	api := app.Group("/templates")
-	api.Post("", handler.Create)
+	// Template menentukan image, annotation Ingress, dan resource workspace semua user
+	admin := auth.RequireRole(auth.NewRepository(db, cfg), auth.RoleAdmin)
+	api.Post("", auth.Protected(cfg), admin, handler.Create)
 	api.Get("", handler.List)
 	api.Get("/:id", handler.FindById)
 }
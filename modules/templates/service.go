@@ -13,6 +13,22 @@ func NewService(repo TemplatesRepository) Service {
 }
 
 func (s Service) Create(c context.Context, req CreateTemplateRequest) error {
+	if err := validateGitRepoURL(req.GitRepoURL); err != nil {
+		return err
+	}
+	if err := validateProbes(req.Probes); err != nil {
+		return err
+	}
+	if err := validateVolumes(req.Volumes); err != nil {
+		return err
+	}
+	if err := validateContainers(req.Sidecars, req.InitContainers, req.Volumes); err != nil {
+		return err
+	}
+	if err := validateIngressAnnotations(req.IngressAnnotations); err != nil {
+		return err
+	}
+
 	return s.repo.Create(c, req)
 }
 
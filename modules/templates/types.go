@@ -30,6 +30,9 @@ type Template struct {
 	DefaultMemoryLimit   string `json:"defaultMemoryLimit" db:"default_memory_limit"`
 	DefaultReplicas      int    `json:"defaultReplicas" db:"default_replicas"`
 
+	// Autoscaling default untuk workspace dari template ini (nil = replicas tetap)
+	DefaultAutoscaling *Autoscaling `json:"defaultAutoscaling" db:"default_autoscaling"`
+
 	// Database Requirements
 	RequiresDatabase    bool    `json:"requiresDatabase" db:"requires_database"`
 	DefaultDatabaseType *string `json:"defaultDatabaseType" db:"default_database_type"`
@@ -41,6 +44,19 @@ type Template struct {
 	// Port Configuration
 	DefaultPort int `json:"defaultPort" db:"default_port"`
 
+	// Health checks (nil = DefaultProbes berdasarkan DefaultPort)
+	Probes *Probes `json:"probes" db:"probes"`
+
+	// Persistent storage
+	Volumes []Volume `json:"volumes" db:"volumes"`
+
+	// Container tambahan di pod workspace
+	Sidecars       []Container `json:"sidecars" db:"sidecars"`
+	InitContainers []Container `json:"initContainers" db:"init_containers"`
+
+	// Annotations Ingress tambahan (e.g., body size, timeout), override default config
+	IngressAnnotations map[string]string `json:"ingressAnnotations" db:"ingress_annotations"`
+
 	// Environment Variables Schema
 	EnvVarsSchema EnvVarsSchema `json:"envVarsSchema" db:"env_vars_schema"`
 
@@ -84,6 +100,14 @@ type ListType struct {
 	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
 }
 
+// Autoscaling - Setting HorizontalPodAutoscaler, utilization dalam persen dari resource request
+type Autoscaling struct {
+	MinReplicas             int  `json:"minReplicas"`
+	MaxReplicas             int  `json:"maxReplicas"`
+	TargetCPUUtilization    *int `json:"targetCpuUtilization,omitempty"`
+	TargetMemoryUtilization *int `json:"targetMemoryUtilization,omitempty"`
+}
+
 type EnvVarsSchema map[string]EnvVarProperty
 
 type EnvVarProperty struct {
@@ -95,28 +119,34 @@ type EnvVarProperty struct {
 }
 
 type CreateTemplateRequest struct {
-	Name                 string        `json:"name" validate:"required,max=100"`
-	DisplayName          string        `json:"displayName" validate:"required,max=150"`
-	Description          string        `json:"description"`
-	Category             string        `json:"category" validate:"required,max=50"`
-	GitRepoURL           string        `json:"gitRepoUrl" validate:"required,url"`
-	GitBranch            string        `json:"gitBranch"`
-	HelmChartPath        *string       `json:"helmChartPath"`
-	DockerfilePath       string        `json:"dockerfilePath"`
-	DefaultCPURequest    string        `json:"defaultCpuRequest"`
-	DefaultCPULimit      string        `json:"defaultCpuLimit"`
-	DefaultMemoryRequest string        `json:"defaultMemoryRequest"`
-	DefaultMemoryLimit   string        `json:"defaultMemoryLimit"`
-	DefaultReplicas      int           `json:"defaultReplicas"`
-	RequiresDatabase     bool          `json:"requiresDatabase"`
-	DefaultDatabaseType  *string       `json:"defaultDatabaseType"`
-	RequiresRedis        bool          `json:"requiresRedis"`
-	RequiresRabbitMQ     bool          `json:"requiresRabbitmq"`
-	DefaultPort          int           `json:"defaultPort"`
-	EnvVarsSchema        EnvVarsSchema `json:"envVarsSchema"`
-	Tags                 []string      `json:"tags"`
-	Features             []string      `json:"features"`
-	IconURL              *string       `json:"iconUrl"`
+	Name                 string            `json:"name" validate:"required,max=100"`
+	DisplayName          string            `json:"displayName" validate:"required,max=150"`
+	Description          string            `json:"description"`
+	Category             string            `json:"category" validate:"required,max=50"`
+	GitRepoURL           string            `json:"gitRepoUrl" validate:"required,url"`
+	GitBranch            string            `json:"gitBranch"`
+	HelmChartPath        *string           `json:"helmChartPath"`
+	DockerfilePath       string            `json:"dockerfilePath"`
+	DefaultCPURequest    string            `json:"defaultCpuRequest"`
+	DefaultCPULimit      string            `json:"defaultCpuLimit"`
+	DefaultMemoryRequest string            `json:"defaultMemoryRequest"`
+	DefaultMemoryLimit   string            `json:"defaultMemoryLimit"`
+	DefaultReplicas      int               `json:"defaultReplicas"`
+	DefaultAutoscaling   *Autoscaling      `json:"defaultAutoscaling"`
+	RequiresDatabase     bool              `json:"requiresDatabase"`
+	DefaultDatabaseType  *string           `json:"defaultDatabaseType"`
+	RequiresRedis        bool              `json:"requiresRedis"`
+	RequiresRabbitMQ     bool              `json:"requiresRabbitmq"`
+	DefaultPort          int               `json:"defaultPort"`
+	Probes               *Probes           `json:"probes"`
+	Volumes              []Volume          `json:"volumes"`
+	Sidecars             []Container       `json:"sidecars"`
+	InitContainers       []Container       `json:"initContainers"`
+	IngressAnnotations   map[string]string `json:"ingressAnnotations"`
+	EnvVarsSchema        EnvVarsSchema     `json:"envVarsSchema"`
+	Tags                 []string          `json:"tags"`
+	Features             []string          `json:"features"`
+	IconURL              *string           `json:"iconUrl"`
 }
 type ListTemplatesRequest struct {
 	Limit  int     `query:"limit"`
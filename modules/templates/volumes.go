@@ -0,0 +1,79 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	AccessReadWriteOnce    = "ReadWriteOnce"
+	AccessReadOnlyMany     = "ReadOnlyMany"
+	AccessReadWriteMany    = "ReadWriteMany"
+	AccessReadWriteOncePod = "ReadWriteOncePod"
+)
+
+var (
+	ErrInvalidVolume = errors.New("volume config is invalid")
+
+	volumeNameRegex = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+)
+
+// Volume - Storage persistent yang dibutuhkan template, dibuat sebagai PVC per workspace
+type Volume struct {
+	Name         string  `json:"name"`
+	Size         string  `json:"size"`      // e.g., "1Gi"
+	MountPath    string  `json:"mountPath"` // e.g., "/data"
+	AccessMode   string  `json:"accessMode,omitempty"`
+	StorageClass *string `json:"storageClass,omitempty"` // nil = default storage class cluster
+}
+
+func validateVolumes(volumes []Volume) error {
+	names := map[string]bool{}
+	mountPaths := map[string]bool{}
+
+	for _, volume := range volumes {
+		if err := validateVolume(volume); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidVolume, err.Error())
+		}
+
+		if names[volume.Name] {
+			return fmt.Errorf("%w: duplicate volume name %s", ErrInvalidVolume, volume.Name)
+		}
+		names[volume.Name] = true
+
+		if mountPaths[volume.MountPath] {
+			return fmt.Errorf("%w: duplicate mount path %s", ErrInvalidVolume, volume.MountPath)
+		}
+		mountPaths[volume.MountPath] = true
+	}
+
+	return nil
+}
+
+func validateVolume(volume Volume) error {
+	// Nama dipakai di nama PVC ("<workspace>-<volume>")
+	if len(volume.Name) > 20 || !volumeNameRegex.MatchString(volume.Name) {
+		return fmt.Errorf("name %q must be a DNS label of at most 20 characters", volume.Name)
+	}
+
+	size, err := resource.ParseQuantity(volume.Size)
+	if err != nil || size.Sign() <= 0 {
+		return fmt.Errorf("size %q of volume %s is invalid", volume.Size, volume.Name)
+	}
+
+	if !path.IsAbs(volume.MountPath) || path.Clean(volume.MountPath) == "/" {
+		return fmt.Errorf("mountPath %q of volume %s must be an absolute path other than /", volume.MountPath, volume.Name)
+	}
+
+	switch volume.AccessMode {
+	case "", AccessReadWriteOnce, AccessReadOnlyMany, AccessReadWriteMany, AccessReadWriteOncePod:
+	default:
+		return fmt.Errorf("accessMode %q of volume %s is invalid", volume.AccessMode, volume.Name)
+	}
+
+	return nil
+}
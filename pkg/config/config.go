@@ -5,11 +5,19 @@ import (
 )
 
 type Config struct {
-	Database  DatabaseConfig `mapstructure:"database"`
-	SecretKey SecretKey      `mapstructure:"secret_key"`
-	Duration  Duration       `mapstructure:"duration"`
-	Server    ServerConfig   `mapstructure:"server"`
-	Docker    DockerConfig   `mapstructure:"docker"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	SecretKey SecretKey       `mapstructure:"secret_key"`
+	Duration  Duration        `mapstructure:"duration"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Docker    DockerConfig    `mapstructure:"docker"`
+	Jobs      JobsConfig      `mapstructure:"jobs"`
+	Workspace WorkspaceConfig `mapstructure:"workspace"`
+	Addons    AddonsConfig    `mapstructure:"addons"`
+	Plans     PlansConfig     `mapstructure:"plans"`
+	Network   NetworkConfig   `mapstructure:"network"`
+	Ingress   IngressConfig   `mapstructure:"ingress"`
+	Builds    BuildsConfig    `mapstructure:"builds"`
+	Previews  PreviewsConfig  `mapstructure:"previews"`
 }
 
 type ServerConfig struct {
@@ -22,6 +30,157 @@ type DockerConfig struct {
 	DefaultImage  string `mapstructure:"default_image"`
 }
 
+type JobsConfig struct {
+	Workers             int `mapstructure:"workers"`
+	PollIntervalSeconds int `mapstructure:"poll_interval_seconds"`
+	LeaseSeconds        int `mapstructure:"lease_seconds"`
+}
+
+type WorkspaceConfig struct {
+	MaxReplicasPerUser       int `mapstructure:"max_replicas_per_user"`
+	MaxReplicasPerDeployment int `mapstructure:"max_replicas_per_deployment"`
+
+	// Scale-to-zero: 0 = idle detector tidak aktif untuk workspace baru
+	DefaultIdleTimeoutMinutes int `mapstructure:"default_idle_timeout_minutes"`
+	IdleCheckIntervalSeconds  int `mapstructure:"idle_check_interval_seconds"`
+
+	// Service backend API (dipakai Ingress workspace yang sedang tidur)
+	WakeServiceHost string `mapstructure:"wake_service_host"`
+	WakeServicePort int    `mapstructure:"wake_service_port"`
+
+	// Shared secret endpoint /wake, dikirim Ingress workspace lewat query "token"
+	// (atau header X-Wake-Token). Kosong = workspace tidak bisa di-park.
+	WakeToken string `mapstructure:"wake_token"`
+}
+
+// AddonsConfig - Service pendukung yang di-provision di namespace user
+type AddonsConfig struct {
+	Postgres AddonConfig `mapstructure:"postgres"`
+	Redis    AddonConfig `mapstructure:"redis"`
+	RabbitMQ AddonConfig `mapstructure:"rabbitmq"`
+}
+
+type AddonConfig struct {
+	Image         string `mapstructure:"image"`
+	StorageSize   string `mapstructure:"storage_size"`
+	StorageClass  string `mapstructure:"storage_class"` // kosong = default storage class
+	CPURequest    string `mapstructure:"cpu_request"`
+	CPULimit      string `mapstructure:"cpu_limit"`
+	MemoryRequest string `mapstructure:"memory_request"`
+	MemoryLimit   string `mapstructure:"memory_limit"`
+
+	// Default persistence untuk add-on (redis/rabbitmq), bisa di-override saat attach
+	Persistent bool `mapstructure:"persistent"`
+}
+
+// IngressConfig - Ingress workspace. Annotations template di-merge di atas DefaultAnnotations.
+type IngressConfig struct {
+	ClassName     string `mapstructure:"class_name"`     // kosong = default IngressClass cluster
+	ClusterIssuer string `mapstructure:"cluster_issuer"` // cert-manager, hanya dipakai kalau TLS aktif
+	BaseDomain    string `mapstructure:"base_domain"`    // host default: <subdomain>.<base_domain>
+	TLS           bool   `mapstructure:"tls"`
+
+	// Certificate dengan sisa masa berlaku <= ini ditandai "expiring"
+	CertExpiryWarningDays int `mapstructure:"cert_expiry_warning_days"`
+
+	// List (bukan map) karena viper memecah key yang mengandung titik
+	DefaultAnnotations []AnnotationConfig `mapstructure:"default_annotations"`
+
+	// Tambahan untuk daftar subdomain reserved bawaan (api, admin, www, ...)
+	ReservedSubdomains []string `mapstructure:"reserved_subdomains"`
+
+	CustomDomains CustomDomainsConfig `mapstructure:"custom_domains"`
+}
+
+// CustomDomainsConfig - Domain milik user, diverifikasi lewat record TXT <verification_prefix>.<domain>
+type CustomDomainsConfig struct {
+	VerificationPrefix    string `mapstructure:"verification_prefix"`
+	VerifyIntervalSeconds int    `mapstructure:"verify_interval_seconds"` // re-check domain pending secara periodik
+	MaxPerDeployment      int    `mapstructure:"max_per_deployment"`      // 0 = tidak dibatasi
+}
+
+type AnnotationConfig struct {
+	Key   string `mapstructure:"key"`
+	Value string `mapstructure:"value"`
+}
+
+// BuildsConfig - Build image dari git repo template dengan Job di dalam cluster.
+// Job jalan di namespace sendiri supaya credential registry tidak ikut ke namespace user.
+type BuildsConfig struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Namespace    string   `mapstructure:"namespace"`
+	BuilderImage string   `mapstructure:"builder_image"` // Kaniko executor atau image lain dengan argumen yang kompatibel
+	PushImage    string   `mapstructure:"push_image"`    // crane, push tarball hasil builder dengan credential registry
+	Registry     string   `mapstructure:"registry"`      // image: <registry>/user<id>-<name>:build-<buildId>
+	ExtraArgs    []string `mapstructure:"extra_args"`    // e.g., --cache=true
+
+	// Secret dockerconfigjson di namespace build. Build memakai <registry_secret>-user<id>;
+	// kalau belum ada, di-copy dari <registry_secret> saat build pertama user. Admin bisa
+	// membuat secret per user lebih dulu (robot account yang hanya bisa push ke
+	// <registry>/user<id>-*). Credential hanya di-mount di container push, bukan di builder.
+	RegistrySecret string `mapstructure:"registry_secret"`
+
+	// Secret dockerconfigjson read-only di namespace build, di-copy ke namespace user
+	// sebagai imagePullSecrets workspace. Kosong = registry public.
+	PullSecret string `mapstructure:"pull_secret"`
+
+	// CIDR registry / git server yang ada di dalam cluster. Network cluster, node, dan
+	// link-local diblok dari pod build.
+	EgressCIDRs []string `mapstructure:"egress_cidrs"`
+
+	// URL publik API untuk webhook git: <webhook_base_url>/webhooks/git/<deploymentId>
+	WebhookBaseURL string `mapstructure:"webhook_base_url"`
+
+	TimeoutSeconds          int    `mapstructure:"timeout_seconds"`
+	TTLSecondsAfterFinished int    `mapstructure:"ttl_seconds_after_finished"`
+	CPULimit                string `mapstructure:"cpu_limit"`
+	MemoryLimit             string `mapstructure:"memory_limit"`
+}
+
+// PreviewsConfig - Salinan workspace per branch / pull request, dihapus saat PR ditutup atau TTL habis
+type PreviewsConfig struct {
+	DefaultTTLHours      int `mapstructure:"default_ttl_hours"`
+	MaxTTLHours          int `mapstructure:"max_ttl_hours"`
+	MaxPerDeployment     int `mapstructure:"max_per_deployment"` // 0 = tidak dibatasi
+	CheckIntervalSeconds int `mapstructure:"check_interval_seconds"`
+}
+
+// NetworkConfig - Isolasi NetworkPolicy antar namespace user
+type NetworkConfig struct {
+	Isolation        bool     `mapstructure:"isolation"`
+	IngressNamespace string   `mapstructure:"ingress_namespace"`
+	DNSNamespace     string   `mapstructure:"dns_namespace"`
+	ClusterCIDRs     []string `mapstructure:"cluster_cidrs"` // pod & service CIDR, tidak bisa dibuka lewat egress rule
+
+	// IP node (kubelet, NodePort) & API server, diblok seperti cluster_cidrs
+	NodeCIDRs         []string `mapstructure:"node_cidrs"`
+	ControlPlaneCIDRs []string `mapstructure:"control_plane_cidrs"`
+}
+
+// PlansConfig - Quota namespace per plan user. Tanpa tiers = quota tidak aktif.
+type PlansConfig struct {
+	Default             string                `mapstructure:"default"` // plan untuk user yang plan-nya tidak dikenal
+	SyncIntervalSeconds int                   `mapstructure:"sync_interval_seconds"`
+	Tiers               map[string]PlanConfig `mapstructure:"tiers"`
+}
+
+type PlanConfig struct {
+	// ResourceQuota: total untuk semua workspace user
+	CPU      string `mapstructure:"cpu"`    // total limits.cpu
+	Memory   string `mapstructure:"memory"` // total limits.memory
+	Storage  string `mapstructure:"storage"`
+	Pods     int    `mapstructure:"pods"`
+	Services int    `mapstructure:"services"`
+
+	// LimitRange: default & max per container
+	DefaultCPURequest    string `mapstructure:"default_cpu_request"`
+	DefaultCPULimit      string `mapstructure:"default_cpu_limit"`
+	DefaultMemoryRequest string `mapstructure:"default_memory_request"`
+	DefaultMemoryLimit   string `mapstructure:"default_memory_limit"`
+	MaxCPU               string `mapstructure:"max_cpu"`
+	MaxMemory            string `mapstructure:"max_memory"`
+}
+
 type SecretKey struct {
 	JwtSecretKey string `mapstructure:"jwt_secret_key"`
 }
@@ -0,0 +1,63 @@
+package response
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// EventWriter - Kirim Server-Sent Events ke client. Send return error kalau client
+// sudah disconnect, ctx stream juga ikut dibatalkan.
+type EventWriter struct {
+	w      *bufio.Writer
+	cancel context.CancelFunc
+}
+
+// Stream - Set header SSE lalu jalankan fn di body stream writer. fn jalan setelah
+// handler return, jadi semua data dari *fiber.Ctx harus dibaca sebelumnya.
+func Stream(c *fiber.Ctx, fn func(ctx context.Context, events *EventWriter)) error {
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // nginx ingress tidak boleh buffer response
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fn(ctx, &EventWriter{w: w, cancel: cancel})
+	})
+	return nil
+}
+
+// Send - Satu event, data di-encode sebagai JSON
+func (e *EventWriter) Send(event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(e.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		e.cancel()
+		return err
+	}
+	return e.flush()
+}
+
+// Comment - Keep-alive, diabaikan oleh EventSource
+func (e *EventWriter) Comment(text string) error {
+	if _, err := fmt.Fprintf(e.w, ": %s\n\n", text); err != nil {
+		e.cancel()
+		return err
+	}
+	return e.flush()
+}
+
+func (e *EventWriter) flush() error {
+	if err := e.w.Flush(); err != nil {
+		e.cancel()
+		return err
+	}
+	return nil
+}
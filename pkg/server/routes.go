@@ -2,16 +2,28 @@ package server
 
 import (
 	"database/sql"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/wafi11/backend-workspaces/modules/auth"
+	"github.com/wafi11/backend-workspaces/modules/deployments"
+	"github.com/wafi11/backend-workspaces/modules/jobs"
+	"github.com/wafi11/backend-workspaces/modules/k8s"
 	"github.com/wafi11/backend-workspaces/modules/products"
 	"github.com/wafi11/backend-workspaces/modules/templates"
 	"github.com/wafi11/backend-workspaces/pkg/config"
 )
 
-func NewRoutes(db *sql.DB, cfg config.Config, api fiber.Router) {
+func NewRoutes(db *sql.DB, cfg config.Config, k8sClient *k8s.K8sClient, pool *jobs.WorkerPool, api fiber.Router) {
 	auth.NewAuthRoute(db, cfg, api)
 	products.NewRoute(db, api)
-	templates.NewTemplates(db, api)
+	templates.NewTemplates(db, cfg, api)
+	jobs.NewRoute(db, cfg, api)
+
+	// Deployments butuh akses ke cluster
+	if k8sClient == nil {
+		log.Println("⚠️  K8s client not available, deployments routes disabled")
+		return
+	}
+	deployments.NewRoute(db, cfg, k8sClient, pool, api)
 }